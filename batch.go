@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/jobs"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Apply the same split or template options to many videos",
+	Long: `Process every video in a directory or matching a glob pattern with the same options,
+reporting success or failure per input and a summary at the end.`,
+}
+
+var batchSplitCmd = &cobra.Command{
+	Use:   "split <dir|glob>",
+	Short: "Split every matching video into chunks",
+	Long: `Split every video in a directory or matching a glob pattern into chunks.
+
+Example:
+  video-processor batch split ./raw -o ./output -d 15 -t instagram-reel
+  video-processor batch split "./raw/*.mov" -o ./output -d 15 -t instagram-reel`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBatchSplit,
+}
+
+var batchTemplateCmd = &cobra.Command{
+	Use:   "apply-template <dir|glob>",
+	Short: "Arrange matching videos into templates",
+	Long: `Group the videos in a directory or matching a glob pattern by the template's input
+count and arrange each group into the template.
+
+Example:
+  video-processor batch apply-template ./clips -o ./output --video-template 2x2 -t reddit`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBatchTemplate,
+}
+
+func init() {
+	batchCmd.PersistentFlags().String("jobs-db", "",
+		"BoltDB file to record progress in; rerunning with the same file skips inputs that already succeeded")
+
+	batchSplitCmd.Flags().StringP("output", "o", "", "Output directory")
+	addCommonFlags(batchSplitCmd)
+	addSplitFlags(batchSplitCmd)
+	batchSplitCmd.MarkFlagRequired("output")
+
+	batchTemplateCmd.Flags().StringP("output", "o", "", "Output directory")
+	addCommonFlags(batchTemplateCmd)
+	addTemplateFlags(batchTemplateCmd)
+	batchTemplateCmd.MarkFlagRequired("output")
+
+	batchCmd.AddCommand(batchSplitCmd)
+	batchCmd.AddCommand(batchTemplateCmd)
+	rootCmd.AddCommand(batchCmd)
+}
+
+func runBatchSplit(cmd *cobra.Command, args []string) error {
+	opts := splitOptionsFromFlags(cmd)
+	opts.OutputDir, _ = cmd.Flags().GetString("output")
+
+	if jobsDB, _ := cmd.Flags().GetString("jobs-db"); jobsDB != "" {
+		inputs, err := videoprocessor.ResolveInputs(args[0])
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		groups := make([][]string, len(inputs))
+		fileOpts := make([]config.VideoSplitterOptions, len(inputs))
+		keys := make([]string, len(inputs))
+		for i, input := range inputs {
+			groups[i] = []string{input}
+			fileOpts[i] = *opts
+			fileOpts[i].InputPath = input
+			keys[i] = jobs.SplitKey(fileOpts[i])
+		}
+		return runBatchJobs(resultOutput(cmd), jobsDB, opts.OutputDir, groups, keys, func(m *jobs.Manager, i int) error {
+			_, err := m.SubmitSplit(fileOpts[i])
+			return err
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	results, err := videoprocessor.SplitBatchContext(ctx, args[0], opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return reportBatch(resultOutput(cmd), results)
+}
+
+func runBatchTemplate(cmd *cobra.Command, args []string) error {
+	opts := templateOptionsFromFlags(cmd)
+	outputDir, _ := cmd.Flags().GetString("output")
+
+	if jobsDB, _ := cmd.Flags().GetString("jobs-db"); jobsDB != "" {
+		templateGroups, err := videoprocessor.TemplateBatchGroups(args[0], outputDir, opts)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		groups := make([][]string, len(templateGroups))
+		keys := make([]string, len(templateGroups))
+		for i, group := range templateGroups {
+			groups[i] = group.InputPaths
+			keys[i] = jobs.TemplateKey(group)
+		}
+		return runBatchJobs(resultOutput(cmd), jobsDB, outputDir, groups, keys, func(m *jobs.Manager, i int) error {
+			_, err := m.SubmitTemplate(templateGroups[i])
+			return err
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	results, err := videoprocessor.ApplyTemplateBatchContext(ctx, args[0], outputDir, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return reportBatch(resultOutput(cmd), results)
+}
+
+// runBatchJobs runs the batch through a persistent job manager. Jobs left
+// unfinished by an earlier interrupted run are resumed, groups that already
+// succeeded with the same options, keys[i] being the Key of group i's job,
+// are skipped and everything else is submitted via submit. The results are
+// reported to w.
+func runBatchJobs(w io.Writer, jobsDB, outputDir string, groups [][]string, keys []string, submit func(m *jobs.Manager, i int) error) error {
+	store, err := openJobStore(jobsDB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	manager := jobs.NewManager(outputDir, 1, store, jobs.OriginCLI, nil)
+	if err := manager.Start(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Anything not failed is either done or was just resumed by Start
+	known := make(map[string]bool)
+	for _, job := range manager.List() {
+		if job.Status != jobs.StatusFailed {
+			known[job.Key()] = true
+		}
+	}
+
+	for i, group := range groups {
+		if known[keys[i]] {
+			continue
+		}
+		if err := submit(manager, i); err != nil {
+			return errors.Wrapf(err, "failed to submit %s", strings.Join(group, ", "))
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		manager.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		manager.Drain()
+		return fmt.Errorf("%w: rerun with --jobs-db %s to resume", videoprocessor.ErrCancelled, jobsDB)
+	}
+
+	// Report the latest job for each group of this batch
+	latest := make(map[string]jobs.Job)
+	for _, job := range manager.List() {
+		latest[job.Key()] = job
+	}
+
+	results := make([]types.BatchResult, 0, len(groups))
+	for i, group := range groups {
+		job := latest[keys[i]]
+		result := types.BatchResult{InputPaths: group, OutputPaths: job.Outputs}
+		if job.Status == jobs.StatusFailed {
+			result.Err = errors.New(job.Error)
+		}
+		results = append(results, result)
+	}
+
+	return reportBatch(w, results)
+}
+
+// reportBatch prints one line per batch result followed by a summary to w, and
+// returns an error when any input failed
+func reportBatch(w io.Writer, results []types.BatchResult) error {
+	failed := 0
+	for _, result := range results {
+		inputs := strings.Join(result.InputPaths, ", ")
+		if result.Err != nil {
+			failed++
+			fmt.Fprintf(w, "FAILED %s: %v\n", inputs, result.Err)
+			continue
+		}
+		fmt.Fprintf(w, "OK     %s -> %s\n", inputs, strings.Join(result.OutputPaths, ", "))
+	}
+
+	fmt.Fprintf(w, "\nProcessed %d inputs: %d succeeded, %d failed\n", len(results), len(results)-failed, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batch inputs failed", failed, len(results))
+	}
+	return nil
+}
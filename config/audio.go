@@ -0,0 +1,29 @@
+package config
+
+// Background music defaults
+const (
+	DefaultMusicVolume   = 0.3  // Linear gain of the music under the original audio
+	DefaultDuckThreshold = 0.05 // Linear level of the original audio above which the music ducks
+
+	DefaultDenoiseStrength = 0.5
+)
+
+// AudioOptions controls the audio processing applied to every output after
+// it's encoded. The video stream is copied, only the audio is re-encoded.
+type AudioOptions struct {
+	AudioFile     string  // Replaces the original audio, trimmed or looped to the video's length
+	MusicFile     string  // Mixed under the audio, looped to the video's length
+	MusicVolume   float64 // Linear gain of the music; zero means DefaultMusicVolume
+	DuckThreshold float64 // Level, 0 to 1, the audio ducks the music above; zero means DefaultDuckThreshold
+
+	DenoiseAudio    bool    // Reduce background noise in the audio before mixing in music
+	DenoiseStrength float64 // From 0 to 1; zero means DefaultDenoiseStrength
+	DenoiseModel    string  // RNNoise model file; denoises with arnndn instead of afftdn when set
+
+	VoiceBoost bool // Even out speech with a highpass, compressor and loudness normalization
+}
+
+// ProcessesAudio reports whether any audio processing is enabled
+func (o *AudioOptions) ProcessesAudio() bool {
+	return o.AudioFile != "" || o.MusicFile != "" || o.DenoiseAudio || o.VoiceBoost
+}
@@ -1,30 +1,236 @@
 package config
 
-import "github.com/ZacxDev/video-splitter/pkg/types"
+import (
+	"time"
+
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
 
 // VideoSplitterOptions defines options for splitting videos
 type VideoSplitterOptions struct {
-	InputPath      string
-	OutputDir      string
-	ChunkDuration  int
-	Skip           string
-	TargetPlatform types.ProcessingPlatform
-	OutputFormat   string // "mp4" or "webm"
-	Verbose        bool
+	InputPath       string // A video, or an image sequence pattern such as frames/%05d.png
+	OutputDir       string
+	ChunkDuration   int
+	Skip            string
+	TargetPlatform  types.ProcessingPlatform
+	OutputFormat    string // "mp4", "webm", "av1", "hevc", "prores", "mkv", "dash", "gif" or "webp"
+	Verbose         bool
+	IntroClipPath   string        // Optional clip prepended to every chunk
+	OutroClipPath   string        // Optional clip appended to every chunk
+	RecapSeconds    int           // Seconds of the previous chunk to replay at the start of the next
+	RecapText       string        // Optional overlay shown during the recap
+	NameTemplate    string        // Go template for chunk file names, see DefaultChunkNameTemplate
+	StreamCopy      bool          // Cut without re-encoding when no target platform is set
+	CacheDir        string        // Keeps downloaded URL inputs between runs; a temp dir is used when empty
+	EncodeTimeout   time.Duration // Fails the run when a single ffmpeg invocation takes longer; zero disables
+	ExtraOutputArgs ffmpeg.KwArgs // Output arguments added to every encode, overriding the tool's own
+	PreserveHDR     bool          // Keep 10-bit pixel formats and HDR metadata instead of converting to 8-bit
+	TonemapOperator string        // Tone mapping operator for HDR sources converted to SDR, "hable" when empty
+	TwoPass         bool          // Encode bitrate-targeted x264 and VP9 outputs in two passes to hit the target size
+	Quality         int           // Constant quality CRF between MinCRF and MaxCRF used instead of bitrates; zero disables
+	EncodePreset    string        // Encoder speed/quality tier: "fast", "balanced" (default) or "best"
+	QualityReport   bool          // Score outputs against their source with VMAF, SSIM and PSNR
+	Fragmented      bool          // Write fragmented MP4 (fMP4/CMAF) instead of progressive MP4 with faststart
+	AnimationFPS    int           // Frame rate of gif and webp outputs; zero means DefaultAnimationFPS
+	AnimationWidth  int           // Width of gif and webp outputs, never upscaled; zero means DefaultAnimationWidth
+	NoAudio         bool          // Leave the audio stream out of every output
+	BurnTimecode    bool          // Draw each chunk's running timestamp in the source on it
+	AudioCopy       bool          // Copy AAC or Opus audio already within the target bitrate instead of re-encoding it
+	AudioCodec      string        // Audio encoder replacing the platform's or output format's, e.g. "aac"
+	AudioBitrate    string        // Audio bitrate replacing the platform's or encoder's default, e.g. "320k"
+	Renditions      []Rendition   // Encode every chunk once per rung of this bitrate ladder; needs no target platform
+	AllowCopy       bool          // Stream copy chunks of sources that already meet the target platform's specs
+	AudioTracks     []int         // Audio tracks of the input to keep, counting from 0; several are mixed into one. ffmpeg picks one when empty
+	Sharpen         string        // unsharp options applied after scaling, e.g. DefaultSharpen; none when empty
+	FrameRate       int           // Frame rate every chunk is converted to; zero converts only sources faster than the platform's maximum
+	SmoothFrameRate bool          // Interpolate motion when converting frame rates instead of dropping or repeating frames
+	InputFPS        float64       // Frame rate image sequence inputs play at; DefaultInputFPS when zero
+	AudioOptions
+	TextOptions
+	EffectOptions
+
+	// OnProgress receives live progress from every encode when set. Events
+	// arrive on the goroutine reading ffmpeg's output, one encode at a time.
+	OnProgress func(types.ProgressEvent) `json:"-"`
 }
 
 // VideoTemplateOptions defines options for applying video templates
 type VideoTemplateOptions struct {
 	InputPaths               []string
 	OutputPath               string
-	TemplateType             string
-	OutputFormat             string // "mp4" or "webm"
+	TemplateType             string    // Grid as <columns>x<rows>, e.g. "2x2" or "3x1", vertical stack as <videos>v, e.g. "2v", or "greenscreen"
+	Layout                   *Layout   // Places the inputs instead of TemplateType when set
+	TemplateGap              int       // Pixels between grid cells, showing the background
+	TemplateBorder           int       // Width in pixels of the border drawn inside every cell
+	TemplateBorderColor      string    // Border color, an ffmpeg color name or hex code; DefaultTemplateBorderColor when empty
+	TemplateBackground       string    // Canvas color behind gaps and layout cells; DefaultTemplateBackground when empty
+	TemplateBackgroundImage  string    // Image covering the canvas; cells keep their aspect ratio over it
+	TemplateBackgroundBlur   bool      // Cover the canvas with a blurred copy of the first input; cells keep their aspect ratio over it
+	KeyBackground            string    // Video or image the keyed input of greenscreen templates is composited over
+	KeyColor                 string    // Color keyed out of greenscreen templates, a name or hex code; DefaultKeyColor when empty
+	KeySimilarity            float64   // How far from the key color, 0.01 to 1, is still keyed out; DefaultKeySimilarity when zero
+	KeyBlend                 float64   // How far past the similarity, 0 to 1, edges fade out instead of cutting hard; DefaultKeyBlend when zero
+	ImageDuration            float64   // Seconds still image inputs play for; DefaultImageDuration when zero
+	NoKenBurns               bool      // Show still image inputs still instead of slowly zooming
+	TemplateAudio            string    // Grid audio: "mix" (the default when empty), "first", "mute" or "index=N" to keep cell N
+	TemplateAudioWeights     []float64 // Volume of each cell when mixing, in input order; equal when empty
+	DurationStrategy         string    // Inputs of different lengths: "shortest", "longest-loop" or "longest-freeze" (the default when empty)
+	FrameRate                int       // Frame rate every input is normalized to; zero uses the platform's
+	SmoothFrameRate          bool      // Interpolate motion when normalizing frame rates instead of dropping or repeating frames
+	Reframe                  string    // Portrait crop of landscape inputs: "center" (the default when empty) or "smart", following the subject
+	ReframeDetector          string    // Command finding the subject of smart reframes instead of the built-in edge heuristic
+	OutputFormat             string    // "mp4", "webm", "av1", "hevc", "prores", "mkv", "dash", "gif" or "webp"
 	Verbose                  bool
 	Obscurify                bool
 	LandscapeBottomRightText string
 	PortraitBottomRightText  string
+	InputTexts               []string // Captions drawn on single cells before they're arranged, as "index=text" with inputs counted from 0
+	TextColor                string   // Color of the bottom right text; picked from TextPalette when empty
+	TextPalette              []string // Colors the bottom right text color is picked from; DefaultTextPalette when empty
+	Seed                     uint64   // Seeds the text color pick and obscurify jitter so renders are reproducible; a random seed when zero
+	WatermarkImage           string   // Logo overlaid in the bottom right corner, or moving per WatermarkMotion
+	WatermarkMotion          string   // "static" (the default when empty), "corners" or "drift"; moves the bottom right text too
+	WatermarkInterval        float64  // Seconds in each corner, or to drift across the frame; DefaultWatermarkInterval when zero
 	TargetPlatform           types.ProcessingPlatform
+	IntroLines               []string // Title card lines shown before the video; no card when empty
+	IntroDuration            int      // Seconds the title card lasts; DefaultIntroDuration when zero
 	OutroLines               []string
+	OutroDuration            int           // Seconds the outro lasts; OutroDuration when zero
+	OutroFontSize            int           // Zero scales the outro text with the video height
+	OutroTextColor           string        // ffmpeg color name or hex code; OutroTextColor when empty
+	OutroBackground          string        // ffmpeg color name or hex code; DefaultOutroBackground when empty
+	OutroFadeIn              float64       // Seconds the outro text and logo fade in over; DefaultOutroFadeIn when zero
+	OutroFadeOut             float64       // Seconds the outro fades out to its background at the end; zero for none
+	OutroLogo                string        // Image centered above the outro text
+	OutroQR                  string        // URL or text shown as a QR code on the outro
+	OutroQRSize              int           // QR code side in pixels; zero for a third of the video's smaller side
+	OutroQRPosition          string        // "bottom-right" (the default when empty), "bottom-left", "top-right", "top-left" or "center"
+	OutroAudio               string        // Audio file played under the outro, faded out at its end
+	Transition               string        // Crossfade into the outro: "fade", "dissolve" or "slide"; a hard cut when empty
+	TransitionDuration       float64       // Seconds; DefaultTransitionDuration when zero
+	NameTemplate             string        // Go template for intermediate and final file names
+	CacheDir                 string        // Keeps downloaded URL inputs between runs; a temp dir is used when empty
+	EncodeTimeout            time.Duration // Fails the run when a single ffmpeg invocation takes longer; zero disables
+	ExtraOutputArgs          ffmpeg.KwArgs // Output arguments added to every encode, overriding the tool's own
+	PreserveHDR              bool          // Keep 10-bit pixel formats and HDR metadata instead of converting to 8-bit
+	TonemapOperator          string        // Tone mapping operator for HDR sources converted to SDR, "hable" when empty
+	TwoPass                  bool          // Encode bitrate-targeted x264 and VP9 outputs in two passes to hit the target size
+	Quality                  int           // Constant quality CRF between MinCRF and MaxCRF used instead of bitrates; zero disables
+	EncodePreset             string        // Encoder speed/quality tier: "fast", "balanced" (default) or "best"
+	QualityReport            bool          // Score outputs against their source with VMAF, SSIM and PSNR
+	Fragmented               bool          // Write fragmented MP4 (fMP4/CMAF) instead of progressive MP4 with faststart
+	AnimationFPS             int           // Frame rate of gif and webp outputs; zero means DefaultAnimationFPS
+	AnimationWidth           int           // Width of gif and webp outputs, never upscaled; zero means DefaultAnimationWidth
+	NoAudio                  bool          // Leave the audio stream out of every output
+	BurnTimecode             bool          // Draw the running timestamp on the video
+	AudioCopy                bool          // Copy AAC or Opus audio already within the target bitrate instead of re-encoding it
+	AudioCodec               string        // Audio encoder replacing the platform's or output format's, e.g. "aac"
+	AudioBitrate             string        // Audio bitrate replacing the platform's or encoder's default, e.g. "320k"
+	Sharpen                  string        // unsharp options applied to the laid out video, below any text; none when empty
+	AudioOptions
+	TextOptions
+	EffectOptions
+	ObscurifyOptions
+
+	// OnProgress receives live progress from every encode when set. Events
+	// arrive on the goroutine reading ffmpeg's output, one encode at a time.
+	OnProgress func(types.ProgressEvent) `json:"-"`
+}
+
+// RemuxOptions defines options for rewrapping a video into another container
+// without re-encoding
+type RemuxOptions struct {
+	InputPath  string
+	OutputPath string // Its extension picks the container
+	Fragmented bool   // Write fragmented MP4 (fMP4/CMAF) instead of progressive MP4 with faststart
+	Verbose    bool
+
+	// OnProgress receives live progress from the remux when set
+	OnProgress func(types.ProgressEvent) `json:"-"`
+}
+
+// TransformOptions defines options for rotating and mirroring a video
+type TransformOptions struct {
+	InputPath      string
+	OutputPath     string
+	Rotate         int  // Degrees clockwise, a multiple of 90, relative to how the input displays
+	FlipHorizontal bool // Mirror left to right, after rotating
+	FlipVertical   bool // Mirror top to bottom, after rotating
+	MetadataOnly   bool // Only rewrite the display rotation, without re-encoding; rotation only
+	Verbose        bool
+
+	// OnProgress receives live progress from the transform when set
+	OnProgress func(types.ProgressEvent) `json:"-"`
+}
+
+// OverlayOptions defines options for compositing a picture-in-picture clip,
+// e.g. a facecam, over a main video such as gameplay or a screen recording
+type OverlayOptions struct {
+	InputPath   string  // Main video, which sets the size and length of the output
+	OverlayPath string  // Clip shown in a corner of the main video
+	OutputPath  string  // Its extension picks the codecs, mp4's when it names no format
+	Position    string  // "bottom-right" (the default when empty), "bottom-left", "top-right" or "top-left"
+	Scale       float64 // Overlay width, border included, as a share of the main video's; DefaultOverlayScale when zero
+	Margin      int     // Pixels between the overlay and the edges of the main video
+	Radius      int     // Pixels the overlay's corners are rounded by; square corners when zero
+	Border      int     // Width in pixels of a border around the overlay; none when zero
+	BorderColor string  // Border color, an ffmpeg color name or hex code; DefaultTemplateBorderColor when empty
+	Offset      float64 // Seconds into the main video the overlay starts, to sync the two
+	Audio       string  // "main" (the default when empty), "overlay" or "mix"
+	Verbose     bool
+
+	// OnProgress receives live progress from the overlay encode when set
+	OnProgress func(types.ProgressEvent) `json:"-"`
+}
+
+// SlideshowOptions defines options for turning a folder of photos into a video
+type SlideshowOptions struct {
+	InputDir           string // Images in it are shown in name order
+	OutputPath         string
+	TargetPlatform     types.ProcessingPlatform
+	OutputFormat       string  // "mp4" when empty
+	ImageDuration      float64 // Seconds each image shows for, transitions included; DefaultImageDuration when zero
+	NoKenBurns         bool    // Show the images still instead of slowly zooming in and out
+	Transition         string  // Crossfade between images: "fade", "dissolve" or "slide"; hard cuts when empty
+	TransitionDuration float64 // Seconds; DefaultTransitionDuration when zero
+	Verbose            bool
+
+	// OnProgress receives live progress from every encode when set
+	OnProgress func(types.ProgressEvent) `json:"-"`
+}
+
+// ExtractAudioOptions defines options for pulling the audio track out of a video
+type ExtractAudioOptions struct {
+	InputPath     string
+	OutputDir     string
+	Format        string // mp3, aac, opus or wav
+	Bitrate       string // The format's default when empty; ignored for wav
+	ChunkDuration int    // Split on the same boundaries as a split with this chunk duration; zero extracts one file
+	Skip          string // Duration to skip from the start, as for splits
+	Verbose       bool
+
+	// OnProgress receives live progress from the extraction when set
+	OnProgress func(types.ProgressEvent) `json:"-"`
+}
+
+// PipelineOptions defines options for splitting a video and arranging the
+// resulting chunks into templates
+type PipelineOptions struct {
+	Split      VideoSplitterOptions
+	Template   VideoTemplateOptions // InputPaths and OutputPath are filled per group
+	OutputDir  string
+	KeepChunks bool // Keep the intermediate chunks in OutputDir/chunks
+}
+
+// WatchOptions defines options for the watch-folder daemon
+type WatchOptions struct {
+	WatchDir     string
+	DoneDir      string // Processed inputs are moved here
+	FailedDir    string // Inputs that failed processing are moved here
+	PollInterval time.Duration
+	StableFor    time.Duration        // How long a file's size must stay unchanged before processing
+	Split        VideoSplitterOptions // InputPath is filled per file
 }
 
 type VideoDimensions struct {
@@ -37,6 +243,24 @@ const (
 	OutputWidth  = 1280
 	OutputHeight = 720
 
+	// Canvas of vertical stack templates, e.g. "2v", for reels and TikTok
+	VerticalStackWidth  = 1080
+	VerticalStackHeight = 1920
+
+	// Template framing defaults
+	DefaultTemplateBorderColor = "white"
+	DefaultTemplateBackground  = "black"
+
+	// Picture-in-picture overlay defaults, a facecam a quarter of the width
+	// off the corner
+	DefaultOverlayScale  = 0.25
+	DefaultOverlayMargin = 32
+
+	// Greenscreen template defaults, keying a studio green screen
+	DefaultKeyColor      = "0x00ff00"
+	DefaultKeySimilarity = 0.3
+	DefaultKeyBlend      = 0.1
+
 	// Template dimensions
 	Template1x1Width  = OutputWidth      // 1920
 	Template1x1Height = OutputHeight     // 1080
@@ -55,8 +279,34 @@ const (
 	MinCRF = 18 // Best quality
 	MaxCRF = 28 // Lowest acceptable quality
 
-	// Temporary directory prefix
-	TempDirPrefix = "video_template_"
+	// Seconds a moving watermark stays in each corner or takes to drift across
+	DefaultWatermarkInterval = 10.0
+
+	// Length in seconds of the title card
+	DefaultIntroDuration = 3
+
+	// Length in seconds of the crossfade into the outro
+	DefaultTransitionDuration = 1.0
+
+	// Seconds a still image plays for in templates and slideshows
+	DefaultImageDuration = 5.0
+
+	// Frame rate image sequence inputs play at
+	DefaultInputFPS = 30.0
+
+	// Animated GIF and WebP output defaults
+	DefaultAnimationFPS   = 15
+	DefaultAnimationWidth = 480
+
+	// Quality report written to the output directory of a split
+	QualityReportFileName = "quality_report.json"
+
+	// Default chunk file name, rendered with processor.NameData
+	DefaultChunkNameTemplate = `{{.Base}}_chunk_{{printf "%03d" .Index}}`
+
+	// Temporary directory prefixes
+	TempDirPrefix      = "video_template_"
+	SplitTempDirPrefix = "video_split_"
 
 	// Text overlay settings
 	TextSize        = "36"    // Font size for bottom right text
@@ -69,4 +319,15 @@ const (
 	OutroDuration  = 5       // Duration in seconds for outro screen
 	OutroTextColor = "white" // Text color for outro
 	OutroFadeIn    = "0.5"
+
+	// Outro card defaults for options left empty
+	DefaultOutroBackground = "black"
+	DefaultOutroFadeIn     = 0.5
 )
+
+// DefaultTextPalette holds the vibrant colors the bottom right text color is
+// picked from
+var DefaultTextPalette = []string{
+	"yellow", "magenta", "cyan", "lime", "red",
+	"orange", "#00ff00", "#ff00ff", "#00ffff", "#ff3366",
+}
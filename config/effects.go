@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Effect is one step of an effect chain: an ffmpeg filter with its options,
+// or a raw piece of filter graph
+type Effect struct {
+	Filter  string            `yaml:"filter"`  // ffmpeg filter name, e.g. "eq"
+	Args    string            `yaml:"args"`    // Positional options, e.g. "3:3:1.5" for unsharp, placed before Options
+	Options map[string]string `yaml:"options"` // Named options, e.g. {saturation: 1.2}
+	Raw     string            `yaml:"raw"`     // Filter graph used verbatim instead of Filter, e.g. "hue=s=0,boxblur=2"
+}
+
+// EffectChain lists the filters applied to every input before it's split or
+// templated, so new looks don't need code changes
+type EffectChain struct {
+	Effects []Effect `yaml:"effects"`
+}
+
+// EffectOptions grade and filter every input before it's split or templated
+type EffectOptions struct {
+	BlurRegions     []string // "x,y,w,h[,start,end]" rectangles of the input blurred from start to end seconds, or throughout; applied first
+	BlurFaces       bool     // Blur the faces FaceDetector finds, along with the blur regions
+	FaceDetector    string   // Command finding faces, given the input path and printing "start end x y w h" lines
+	Crop            string   // Region of the input to keep, "WxH+X+Y" in pixels, applied after blurring
+	CropKeyframes   string   // File of "seconds X Y" lines moving the crop region over time, linearly between them
+	Stabilize       bool     // Steady shaky footage with vid.stab before anything else
+	Speed           float64  // Playback speed, e.g. 0.5 for half speed slow motion; 1 when zero
+	Interpolate     bool     // Synthesize the frames slow motion lacks with minterpolate instead of repeating frames
+	SpeedAudio      string   // Audio of speed changes: "tempo" (the default when empty) keeps its pitch, "mute" drops it
+	MinDuration     float64  // Loop inputs shorter than this many seconds, after the speed change, up to it; none when zero
+	LoopMode        string   // How short inputs loop: "loop" (the default when empty) or "pingpong", forward then backward
+	Boomerang       bool     // Play inputs forward then backward, without sound
+	BoomerangRepeat int      // Forward and backward plays of boomerangs; DefaultBoomerangRepeat when zero
+	Denoise         string   // Video denoise level, "light", "medium" or "strong", applied after cropping
+	LUTFile         string   // 3D LUT, e.g. a .cube file, applied after denoising
+	Look            string   // Built-in color grade from Looks, applied after the LUT
+	EffectsFile     string   // YAML or JSON effect chain applied last
+}
+
+// Audio handling of speed changes
+const (
+	SpeedAudioTempo = "tempo"
+	SpeedAudioMute  = "mute"
+)
+
+// Loop modes of inputs shorter than the minimum duration
+const (
+	LoopForward  = "loop"
+	LoopPingPong = "pingpong"
+)
+
+// DefaultBoomerangRepeat is how many times a boomerang plays forward and
+// backward
+const DefaultBoomerangRepeat = 3
+
+// DefaultSharpen is a mild unsharp mask, 5x5 luma at 0.5, suited to video
+// downscaled to platform dimensions
+const DefaultSharpen = "5:5:0.5"
+
+// Looks are the built-in color grades for users without LUT files
+var Looks = map[string]EffectChain{
+	// Teal shadows, warm highlights and a gentle S curve
+	"cinematic": {Effects: []Effect{
+		{Filter: "colorbalance", Options: map[string]string{"rs": "-0.05", "bs": "0.08", "rh": "0.08", "bh": "-0.06"}},
+		{Filter: "curves", Options: map[string]string{"preset": "medium_contrast"}},
+		{Filter: "eq", Options: map[string]string{"saturation": "0.9"}},
+	}},
+	"vivid": {Effects: []Effect{
+		{Filter: "eq", Options: map[string]string{"saturation": "1.4", "contrast": "1.05"}},
+		{Filter: "curves", Options: map[string]string{"preset": "increase_contrast"}},
+	}},
+	"warm": {Effects: []Effect{
+		{Filter: "colorbalance", Options: map[string]string{"rs": "0.06", "bs": "-0.06", "rm": "0.05", "bm": "-0.05", "rh": "0.03", "bh": "-0.03"}},
+		{Filter: "eq", Options: map[string]string{"saturation": "1.1"}},
+	}},
+	"bw": {Effects: []Effect{
+		{Filter: "eq", Options: map[string]string{"saturation": "0", "contrast": "1.15"}},
+		{Filter: "curves", Options: map[string]string{"preset": "lighter"}},
+	}},
+}
+
+// LookNames returns the names of the built-in looks in sorted order
+func LookNames() []string {
+	names := make([]string, 0, len(Looks))
+	for name := range Looks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+var filterNameRe = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// LoadEffectChain reads an effect chain from a YAML or JSON file
+func LoadEffectChain(path string) (*EffectChain, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read effect chain")
+	}
+
+	chain := &EffectChain{}
+	if err := yaml.Unmarshal(data, chain); err != nil {
+		return nil, errors.Wrap(err, "failed to parse effect chain")
+	}
+
+	if err := chain.Validate(); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// Validate checks every effect sets a well-formed filter or a raw graph
+func (c *EffectChain) Validate() error {
+	if len(c.Effects) == 0 {
+		return fmt.Errorf("effect chain has no effects")
+	}
+	for i, effect := range c.Effects {
+		switch {
+		case (effect.Filter == "") == (effect.Raw == ""):
+			return fmt.Errorf("effect %d must set exactly one of filter or raw", i+1)
+		case effect.Raw != "" && (effect.Args != "" || len(effect.Options) > 0):
+			return fmt.Errorf("effect %d: a raw filter graph can't have args or options", i+1)
+		case effect.Filter != "" && !filterNameRe.MatchString(effect.Filter):
+			return fmt.Errorf("effect %d: invalid filter name %q", i+1, effect.Filter)
+		}
+	}
+	return nil
+}
+
+// Filter returns the chain as a filter graph, e.g. "eq=saturation=1.2,hflip".
+// Option values holding filter syntax are quoted so they're taken literally.
+func (c *EffectChain) Filter() string {
+	filters := make([]string, len(c.Effects))
+	for i, effect := range c.Effects {
+		if effect.Raw != "" {
+			filters[i] = effect.Raw
+			continue
+		}
+
+		var options []string
+		if effect.Args != "" {
+			options = append(options, effect.Args)
+		}
+		names := make([]string, 0, len(effect.Options))
+		for name := range effect.Options {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			value := effect.Options[name]
+			if strings.ContainsAny(value, `:,;[]='\`) {
+				value = "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+			}
+			options = append(options, name+"="+value)
+		}
+
+		filters[i] = effect.Filter
+		if len(options) > 0 {
+			filters[i] += "=" + strings.Join(options, ":")
+		}
+	}
+	return strings.Join(filters, ",")
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rendition is one rung of a bitrate ladder
+type Rendition struct {
+	Name         string `yaml:"name"`          // Added to the output file names, e.g. "720p"
+	Height       int    `yaml:"height"`        // Of the short side, so 720 is 1280x720 or 720x1280
+	VideoBitrate string `yaml:"video_bitrate"` // e.g. "3M" or "1200k"
+	AudioBitrate string `yaml:"audio_bitrate"` // The encoder's default when empty
+}
+
+// DefaultLadder is used for the "default" ladder spec
+var DefaultLadder = []Rendition{
+	{Name: "1080p", Height: 1080, VideoBitrate: "5M", AudioBitrate: "192k"},
+	{Name: "720p", Height: 720, VideoBitrate: "3M", AudioBitrate: "128k"},
+	{Name: "480p", Height: 480, VideoBitrate: "1200k", AudioBitrate: "96k"},
+}
+
+// ParseLadder parses a comma separated ladder spec of
+// <height>p:<video bitrate>[:<audio bitrate>] rungs, e.g. "1080p:5M,720p:3M:128k",
+// or "default" for DefaultLadder
+func ParseLadder(spec string) ([]Rendition, error) {
+	if spec == "default" {
+		return append([]Rendition{}, DefaultLadder...), nil
+	}
+
+	var ladder []Rendition
+	for _, rung := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(rung), ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid ladder rung %q, expected <height>p:<video bitrate>[:<audio bitrate>]", rung)
+		}
+
+		height, err := strconv.Atoi(strings.TrimSuffix(parts[0], "p"))
+		if err != nil || height <= 0 {
+			return nil, fmt.Errorf("invalid ladder rung height %q", parts[0])
+		}
+
+		rendition := Rendition{
+			Name:         fmt.Sprintf("%dp", height),
+			Height:       height,
+			VideoBitrate: parts[1],
+		}
+		if len(parts) == 3 {
+			rendition.AudioBitrate = parts[2]
+		}
+		ladder = append(ladder, rendition)
+	}
+
+	return ladder, nil
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Layout places template inputs on a canvas, one cell per input in input order
+type Layout struct {
+	Width  int          `yaml:"width"`  // Canvas size; the template's default canvas when zero
+	Height int          `yaml:"height"` // Set together with Width
+	Cells  []LayoutCell `yaml:"cells"`
+}
+
+// LayoutCell is the area one input is scaled into. Positions and sizes are
+// pixels, e.g. "640", or percentages of the canvas, e.g. "25%".
+type LayoutCell struct {
+	X      string `yaml:"x"`
+	Y      string `yaml:"y"`
+	Width  string `yaml:"width"`
+	Height string `yaml:"height"`
+	Z      int    `yaml:"z"` // Cells with a higher Z are drawn over lower ones, ties in input order
+}
+
+// LayoutRect is a cell resolved against the canvas, in pixels
+type LayoutRect struct {
+	X, Y, Width, Height int
+	Z                   int
+}
+
+// LoadLayout reads and validates a template layout file. JSON files are
+// accepted as well since JSON is a subset of YAML.
+func LoadLayout(path string) (*Layout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read layout")
+	}
+
+	layout := &Layout{}
+	if err := yaml.Unmarshal(data, layout); err != nil {
+		return nil, errors.Wrap(err, "failed to parse layout")
+	}
+
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+// Validate checks the layout for missing cells and malformed sizes
+func (l *Layout) Validate() error {
+	if len(l.Cells) == 0 {
+		return fmt.Errorf("layout has no cells")
+	}
+	if (l.Width == 0) != (l.Height == 0) || l.Width < 0 || l.Height < 0 {
+		return fmt.Errorf("layout canvas must set a positive width and height, or neither")
+	}
+
+	// Resolving against any canvas catches malformed values, the real canvas
+	// may only be known once the platform is
+	_, err := l.Resolve(VideoDimensions{Width: OutputWidth, Height: OutputHeight})
+	return err
+}
+
+// Canvas returns the layout's own canvas, or def when it doesn't set one
+func (l *Layout) Canvas(def VideoDimensions) VideoDimensions {
+	if l.Width > 0 {
+		return VideoDimensions{Width: l.Width, Height: l.Height}
+	}
+	return def
+}
+
+// Resolve returns the cells of the layout in pixels on canvas, or on the
+// layout's own canvas when it sets one. Sizes are rounded down to even
+// numbers for the encoders.
+func (l *Layout) Resolve(canvas VideoDimensions) ([]LayoutRect, error) {
+	canvas = l.Canvas(canvas)
+
+	rects := make([]LayoutRect, len(l.Cells))
+	for i, cell := range l.Cells {
+		values := []struct {
+			name  string
+			value string
+			of    int
+			dst   *int
+		}{
+			{"x", cell.X, canvas.Width, &rects[i].X},
+			{"y", cell.Y, canvas.Height, &rects[i].Y},
+			{"width", cell.Width, canvas.Width, &rects[i].Width},
+			{"height", cell.Height, canvas.Height, &rects[i].Height},
+		}
+		for _, v := range values {
+			n, err := layoutValue(v.value, v.of)
+			if err != nil {
+				return nil, fmt.Errorf("layout cell %d %s: %v", i+1, v.name, err)
+			}
+			*v.dst = n
+		}
+
+		r := &rects[i]
+		r.Z = cell.Z
+		r.Width &^= 1
+		r.Height &^= 1
+		if r.Width <= 0 || r.Height <= 0 {
+			return nil, fmt.Errorf("layout cell %d is empty", i+1)
+		}
+		if r.X+r.Width > canvas.Width || r.Y+r.Height > canvas.Height {
+			return nil, fmt.Errorf("layout cell %d (%dx%d at %d,%d) doesn't fit the %dx%d canvas",
+				i+1, r.Width, r.Height, r.X, r.Y, canvas.Width, canvas.Height)
+		}
+	}
+	return rects, nil
+}
+
+// layoutValue resolves a pixel count or a percentage of of. Empty means 0.
+func layoutValue(value string, of int) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil || p < 0 {
+			return 0, fmt.Errorf("invalid percentage %q", value)
+		}
+		return int(p * float64(of) / 100), nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid pixel value %q, expected e.g. '640' or '25%%'", value)
+	}
+	return n, nil
+}
@@ -0,0 +1,32 @@
+package config
+
+// Obscurify defaults
+const (
+	// Audio defaults, together almost a semitone higher at nearly the original speed
+	DefaultObscurifyPitch = 1.05
+	DefaultObscurifyTempo = 0.95
+
+	DefaultObscurifyStrength = 1.0 // The stock recipe
+)
+
+// ObscurifyEffects are the transforms obscurify can apply: a slight zoom, a
+// gamma, saturation and contrast shift, sharpening, a vignette, the audio
+// pitch shift, a horizontal mirror, a slight rotation, film grain, a tiny
+// speed change, a border crop and skipping the first few frames
+var ObscurifyEffects = []string{
+	"zoom", "color", "sharpen", "vignette", "pitch",
+	"mirror", "rotate", "grain", "speed", "crop", "offset",
+}
+
+// DefaultObscurifyEffects are the transforms of the stock recipe
+var DefaultObscurifyEffects = []string{"zoom", "color", "sharpen", "vignette", "pitch"}
+
+// ObscurifyOptions picks the transforms obscurify applies and how strongly
+type ObscurifyOptions struct {
+	ObscurifyEffects   []string // Transforms to apply out of ObscurifyEffects; DefaultObscurifyEffects when empty
+	ObscurifyStrength  float64  // Scales the video transforms, 1 being the stock recipe; zero means DefaultObscurifyStrength
+	ObscurifyJitter    float64  // Fraction, 0 to 1, each video transform's strength varies by at random, seeded by the template seed
+	ObscurifyPitch     float64  // Obscurify's pitch factor, which also speeds up the audio; zero means DefaultObscurifyPitch
+	ObscurifyTempo     float64  // Obscurify's tempo factor applied after the pitch shift; zero means DefaultObscurifyTempo
+	ObscurifyKeepAudio bool     // Leave the audio unchanged, like leaving out the pitch effect
+}
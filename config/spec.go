@@ -0,0 +1,224 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// PipelineSpec describes a sequence of processing steps loaded from a YAML or JSON file
+type PipelineSpec struct {
+	Inputs          []string                 `yaml:"inputs"`
+	OutputDir       string                   `yaml:"output"`
+	TargetPlatform  types.ProcessingPlatform `yaml:"platform"`
+	OutputFormat    string                   `yaml:"format"` // e.g. "mp4", "webm", "av1", "hevc", "prores", "mkv", "dash", "gif" or "webp"
+	Verbose         bool                     `yaml:"verbose"`
+	FontFile        string                   `yaml:"font_file"`         // Font of every text overlay; the system default when empty
+	Font            string                   `yaml:"font"`              // fontconfig name of an installed font, used without font_file
+	TextWrap        int                      `yaml:"text_wrap"`         // Characters per text overlay line before wrapping; none when zero
+	TextPosition    string                   `yaml:"text_position"`     // top-left, top-right, bottom-left, bottom-right or center
+	TextOffsetX     int                      `yaml:"text_offset_x"`     // Pixels right of text_position, left when negative
+	TextOffsetY     int                      `yaml:"text_offset_y"`     // Pixels below text_position, above when negative
+	TextAnimation   string                   `yaml:"overlay_animation"` // none, fade, scroll or typewriter
+	TextStart       float64                  `yaml:"overlay_start"`     // Seconds in the corner and recap text appear
+	TextEnd         float64                  `yaml:"overlay_end"`       // Seconds in the corner and recap text disappear; they stay when zero
+	EffectsFile     string                   `yaml:"effects_file"`      // Effect chain applied to the inputs of every split and template step
+	SmoothFPS       bool                     `yaml:"fps_smooth"`        // Interpolate motion when converting frame rates
+	InputFPS        float64                  `yaml:"input_fps"`         // Frame rate of image sequence inputs such as frames/%05d.png
+	Sharpen         string                   `yaml:"sharpen"`           // unsharp options of split and template outputs, e.g. "5:5:0.5"
+	BlurRegions     []string                 `yaml:"blur_regions"`      // x,y,w,h[,start,end] rectangles of split and template inputs to blur
+	BlurFaces       bool                     `yaml:"blur_faces"`        // Blur the faces face_detector finds in split and template inputs
+	FaceDetector    string                   `yaml:"face_detector"`     // Command finding faces, printing "start end x y w h" lines
+	Crop            string                   `yaml:"crop"`              // WxH+X+Y region of split and template inputs to keep
+	CropKeyframes   string                   `yaml:"crop_keyframes"`    // File of "seconds X Y" lines moving the crop
+	Stabilize       bool                     `yaml:"stabilize"`         // Steady shaky split and template inputs with vid.stab
+	Speed           float64                  `yaml:"speed"`             // Playback speed of split and template inputs; 1 when zero
+	Interpolate     bool                     `yaml:"interpolate"`       // Synthesize the frames slow motion lacks
+	SpeedAudio      string                   `yaml:"speed_audio"`       // tempo or mute
+	MinDuration     float64                  `yaml:"min_duration"`      // Loop shorter split and template inputs up to this many seconds
+	LoopMode        string                   `yaml:"min_duration_mode"` // loop or pingpong
+	Boomerang       bool                     `yaml:"boomerang"`         // Play split and template inputs forward then backward
+	BoomerangRepeat int                      `yaml:"boomerang_repeat"`  // DefaultBoomerangRepeat when zero
+	Denoise         string                   `yaml:"denoise"`           // Video denoise level of split and template inputs: light, medium or strong
+	LUTFile         string                   `yaml:"lut"`               // 3D LUT grading the inputs of every split and template step
+	Look            string                   `yaml:"look"`              // Built-in color grade, e.g. cinematic, applied after lut
+	Steps           []PipelineStep           `yaml:"steps"`
+
+	// OnProgress receives live progress from every encode when set
+	OnProgress func(types.ProgressEvent) `yaml:"-"`
+}
+
+// PipelineStep is a single operation in a pipeline spec. Exactly one field must be set.
+type PipelineStep struct {
+	Split     *SplitStep     `yaml:"split"`
+	Obscurify *ObscurifyStep `yaml:"obscurify"`
+	Template  *TemplateStep  `yaml:"template"`
+	Outro     *OutroStep     `yaml:"outro"`
+	Upload    *UploadStep    `yaml:"upload"`
+}
+
+// SplitStep splits every current file into chunks
+type SplitStep struct {
+	Duration int         `yaml:"duration"`
+	Skip     string      `yaml:"skip"`
+	Ladder   []Rendition `yaml:"ladder"`   // Optional bitrate ladder, every chunk is encoded once per rung
+	Timecode bool        `yaml:"timecode"` // Burn each chunk's timestamp in the source into it
+	FPS      int         `yaml:"fps"`      // Converts sources faster than the platform's maximum when zero
+}
+
+// ObscurifyStep applies obscurify effects to every current file
+type ObscurifyStep struct {
+	Pitch     float64  `yaml:"pitch"` // DefaultObscurifyPitch when zero
+	Tempo     float64  `yaml:"tempo"` // DefaultObscurifyTempo when zero
+	KeepAudio bool     `yaml:"keep_audio"`
+	Effects   []string `yaml:"effects"`  // zoom, color, sharpen, vignette and pitch; all of them when empty
+	Strength  float64  `yaml:"strength"` // DefaultObscurifyStrength when zero
+	Jitter    float64  `yaml:"jitter"`   // Random variation of each video transform's strength, 0 to 1
+	Seed      uint64   `yaml:"seed"`     // Makes the jitter reproducible when set
+}
+
+// TemplateStep arranges groups of current files into a template
+type TemplateStep struct {
+	Type              string    `yaml:"type"`
+	LandscapeText     string    `yaml:"landscape_text"`
+	PortraitText      string    `yaml:"portrait_text"`
+	InputTexts        []string  `yaml:"input_texts"` // index=text captions of single cells, inputs counted from 0
+	TextColor         string    `yaml:"text_color"`  // Color of the landscape and portrait text; picked from text_palette when empty
+	TextPalette       []string  `yaml:"text_palette"`
+	Seed              uint64    `yaml:"seed"`               // Makes the text color pick reproducible when set
+	Audio             string    `yaml:"audio"`              // mix, first, mute or index=N, see VideoTemplateOptions.TemplateAudio
+	AudioWeights      []float64 `yaml:"audio_weights"`      // Volume of each input when mixing
+	Duration          string    `yaml:"duration"`           // shortest, longest-loop or longest-freeze, see VideoTemplateOptions.DurationStrategy
+	FPS               int       `yaml:"fps"`                // The platform's frame rate when zero
+	Gap               int       `yaml:"gap"`                // Pixels between cells
+	Border            int       `yaml:"border"`             // Width of the border around every cell
+	BorderColor       string    `yaml:"border_color"`       // Color name or hex code
+	Background        string    `yaml:"background"`         // Canvas color behind gaps
+	BackgroundImage   string    `yaml:"background_image"`   // Image covering the canvas
+	BackgroundBlur    bool      `yaml:"background_blur"`    // Blurred copy of the first input behind the cells
+	KeyBackground     string    `yaml:"key_background"`     // Video or image greenscreen inputs are composited over
+	KeyColor          string    `yaml:"key_color"`          // Color keyed out, DefaultKeyColor when empty
+	KeySimilarity     float64   `yaml:"key_similarity"`     // 0.01 to 1, DefaultKeySimilarity when zero
+	KeyBlend          float64   `yaml:"key_blend"`          // 0 to 1, DefaultKeyBlend when zero
+	WatermarkImage    string    `yaml:"watermark_image"`    // Logo in the bottom right corner
+	WatermarkMotion   string    `yaml:"watermark_motion"`   // static, corners or drift
+	WatermarkInterval float64   `yaml:"watermark_interval"` // Seconds, DefaultWatermarkInterval when zero
+	Timecode          bool      `yaml:"timecode"`           // Burn the running timestamp into the output
+	Reframe           string    `yaml:"reframe"`            // center or smart, for platforms forcing portrait
+	ReframeDetector   string    `yaml:"reframe_detector"`   // Command finding the subject of smart reframes
+}
+
+// OutroStep appends an outro card to every current file
+type OutroStep struct {
+	Lines              []string `yaml:"lines"`
+	Transition         string   `yaml:"transition"`          // fade, dissolve or slide; a hard cut when empty
+	TransitionDuration float64  `yaml:"transition_duration"` // Seconds
+	Duration           int      `yaml:"duration"`            // Seconds, OutroDuration when zero
+	FontSize           int      `yaml:"font_size"`           // Scales with the video height when zero
+	TextColor          string   `yaml:"text_color"`          // Color name or hex code
+	Background         string   `yaml:"background"`          // Color name or hex code
+	FadeIn             float64  `yaml:"fade_in"`             // Seconds
+	FadeOut            float64  `yaml:"fade_out"`            // Seconds, no fade out when zero
+	Logo               string   `yaml:"logo"`                // Image centered above the lines
+	QR                 string   `yaml:"qr"`                  // URL shown as a QR code
+	QRSize             int      `yaml:"qr_size"`             // Pixels
+	QRPosition         string   `yaml:"qr_position"`         // bottom-right, bottom-left, top-right, top-left or center
+	Audio              string   `yaml:"audio"`               // Audio file played under the outro
+}
+
+// UploadStep runs a command once per current file. Arguments are Go templates
+// with .Path and .Name available, e.g. ["rclone", "copy", "{{.Path}}", "remote:videos"].
+type UploadStep struct {
+	Command []string `yaml:"command"`
+}
+
+// Name returns the operation name of the step
+func (s PipelineStep) Name() string {
+	switch {
+	case s.Split != nil:
+		return "split"
+	case s.Obscurify != nil:
+		return "obscurify"
+	case s.Template != nil:
+		return "template"
+	case s.Outro != nil:
+		return "outro"
+	case s.Upload != nil:
+		return "upload"
+	default:
+		return ""
+	}
+}
+
+func (s PipelineStep) count() int {
+	n := 0
+	for _, set := range []bool{s.Split != nil, s.Obscurify != nil, s.Template != nil, s.Outro != nil, s.Upload != nil} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// LoadPipelineSpec reads and validates a pipeline spec. JSON files are accepted
+// as well since JSON is a subset of YAML.
+func LoadPipelineSpec(path string) (*PipelineSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read pipeline spec")
+	}
+
+	spec := &PipelineSpec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, errors.Wrap(err, "failed to parse pipeline spec")
+	}
+
+	if spec.OutputFormat == "" {
+		spec.OutputFormat = "webm"
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// Validate checks the spec for missing or ambiguous settings
+func (s *PipelineSpec) Validate() error {
+	if len(s.Inputs) == 0 {
+		return fmt.Errorf("pipeline spec has no inputs")
+	}
+	if s.OutputDir == "" {
+		return fmt.Errorf("pipeline spec has no output directory")
+	}
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("pipeline spec has no steps")
+	}
+
+	for i, step := range s.Steps {
+		if step.count() != 1 {
+			return fmt.Errorf("step %d must set exactly one of split, obscurify, template, outro, upload", i+1)
+		}
+
+		switch {
+		case step.Split != nil && step.Split.Duration <= 0:
+			return fmt.Errorf("step %d: split duration must be positive", i+1)
+		case step.Template != nil && step.Template.Type == "":
+			return fmt.Errorf("step %d: template type is required", i+1)
+		case step.Outro != nil && len(step.Outro.Lines) == 0:
+			return fmt.Errorf("step %d: outro needs at least one line", i+1)
+		case step.Upload != nil && len(step.Upload.Command) == 0:
+			return fmt.Errorf("step %d: upload command is required", i+1)
+		}
+
+		if (step.Template != nil || step.Outro != nil) && s.TargetPlatform == "" {
+			return fmt.Errorf("step %d: %s requires a platform", i+1, step.Name())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestPipelineSpecValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string // Substring of the error; valid when empty
+	}{
+		{
+			name: "split only",
+			spec: `{inputs: [a.mp4], output: out, steps: [{split: {duration: 15}}]}`,
+		},
+		{
+			name: "full pipeline",
+			spec: `{inputs: [a.mp4, b.mp4], output: out, platform: tiktok, steps: [
+				{split: {duration: 15}}, {obscurify: {}}, {template: {type: 2x2}},
+				{outro: {lines: [bye]}}, {upload: {command: [cp, "{{.Path}}", dst]}}]}`,
+		},
+		{
+			name:    "no inputs",
+			spec:    `{output: out, steps: [{split: {duration: 15}}]}`,
+			wantErr: "no inputs",
+		},
+		{
+			name:    "no output",
+			spec:    `{inputs: [a.mp4], steps: [{split: {duration: 15}}]}`,
+			wantErr: "no output directory",
+		},
+		{
+			name:    "no steps",
+			spec:    `{inputs: [a.mp4], output: out}`,
+			wantErr: "no steps",
+		},
+		{
+			name:    "empty step",
+			spec:    `{inputs: [a.mp4], output: out, steps: [{}]}`,
+			wantErr: "step 1 must set exactly one",
+		},
+		{
+			name:    "two operations in one step",
+			spec:    `{inputs: [a.mp4], output: out, steps: [{split: {duration: 15}, obscurify: {}}]}`,
+			wantErr: "step 1 must set exactly one",
+		},
+		{
+			name:    "zero split duration",
+			spec:    `{inputs: [a.mp4], output: out, steps: [{split: {}}]}`,
+			wantErr: "step 1: split duration must be positive",
+		},
+		{
+			name:    "negative split duration",
+			spec:    `{inputs: [a.mp4], output: out, steps: [{obscurify: {}}, {split: {duration: -5}}]}`,
+			wantErr: "step 2: split duration must be positive",
+		},
+		{
+			name:    "template without type",
+			spec:    `{inputs: [a.mp4], output: out, platform: tiktok, steps: [{template: {}}]}`,
+			wantErr: "template type is required",
+		},
+		{
+			name:    "template without platform",
+			spec:    `{inputs: [a.mp4], output: out, steps: [{template: {type: 2x2}}]}`,
+			wantErr: "template requires a platform",
+		},
+		{
+			name:    "outro without lines",
+			spec:    `{inputs: [a.mp4], output: out, platform: tiktok, steps: [{outro: {}}]}`,
+			wantErr: "outro needs at least one line",
+		},
+		{
+			name:    "outro without platform",
+			spec:    `{inputs: [a.mp4], output: out, steps: [{outro: {lines: [bye]}}]}`,
+			wantErr: "outro requires a platform",
+		},
+		{
+			name:    "upload without command",
+			spec:    `{inputs: [a.mp4], output: out, steps: [{upload: {}}]}`,
+			wantErr: "upload command is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var spec PipelineSpec
+			if err := yaml.Unmarshal([]byte(tt.spec), &spec); err != nil {
+				t.Fatalf("invalid test spec: %v", err)
+			}
+
+			err := spec.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() failed: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("Validate() succeeded, want an error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("Validate() = %q, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadPipelineSpecDefaultsFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(path, []byte("inputs: [a.mp4]\noutput: out\nsteps:\n  - split: {duration: 15}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := LoadPipelineSpec(path)
+	if err != nil {
+		t.Fatalf("LoadPipelineSpec failed: %v", err)
+	}
+	if spec.OutputFormat != "webm" {
+		t.Errorf("OutputFormat = %q, want webm", spec.OutputFormat)
+	}
+}
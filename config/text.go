@@ -0,0 +1,19 @@
+package config
+
+// TextOptions picks the font, line wrapping, placement and animation of every
+// text overlay.
+// drawtext renders glyphs missing from the font as boxes, so emoji or CJK
+// captions need a font that has them, e.g. "Noto Sans CJK JP".
+type TextOptions struct {
+	FontFile string // Font file; the system default when empty
+	Font     string // fontconfig name or pattern of an installed font, used when no FontFile is set
+	Wrap     int    // Characters per line before overlay text wraps at a space; lines are kept as written when zero
+
+	Position string // Corner and recap text position: top-left, top-right, bottom-left, bottom-right or center; each text's own when empty
+	OffsetX  int    // Pixels the corner and recap text move right of their position, left when negative
+	OffsetY  int    // Pixels the corner and recap text move below their position, above when negative
+
+	Animation string  // Corner and recap text animation: none, fade, scroll or typewriter
+	ShowAt    float64 // Seconds into the video the corner and recap text appear
+	HideAt    float64 // Seconds into the video the corner and recap text disappear; they stay when zero
+}
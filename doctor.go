@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+
+	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that ffmpeg and its required features are installed",
+	Long: `Verify the environment: ffmpeg and ffprobe are on PATH, the encoders used by
+the supported platforms and formats are available, and the build supports text
+overlays and HDR tone mapping.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+// requiredEncoders are the encoders the platforms and output formats rely on
+var requiredEncoders = []struct {
+	name string
+	use  string
+}{
+	{"libx264", "mp4 output and most platforms"},
+	{"libvpx-vp9", "webm output"},
+	{"libopus", "webm audio"},
+	{"aac", "mp4 audio"},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	failed := 0
+	check := func(ok bool, msg, remedy string) {
+		if ok {
+			fmt.Printf("[OK]   %s\n", msg)
+			return
+		}
+		failed++
+		fmt.Printf("[FAIL] %s\n       -> %s\n", msg, remedy)
+	}
+
+	for _, bin := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			check(false, bin+" not found on PATH",
+				"install ffmpeg (e.g. 'apt install ffmpeg' or 'brew install ffmpeg') and make sure it is on PATH")
+			continue
+		}
+
+		version, err := ffmpegWrap.ToolVersion(bin)
+		if err != nil {
+			check(false, bin+" failed to run", err.Error()+"; reinstall ffmpeg")
+			continue
+		}
+		check(true, version, "")
+	}
+
+	// Without a working ffmpeg there is nothing more to check
+	if failed > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", failed)
+	}
+
+	caps, err := ffmpegWrap.DetectCapabilities()
+	if err != nil {
+		check(false, "could not query ffmpeg capabilities", err.Error())
+		return fmt.Errorf("doctor found %d problem(s)", failed)
+	}
+
+	for _, enc := range requiredEncoders {
+		check(caps.Encoders[enc.name], fmt.Sprintf("encoder %s (%s)", enc.name, enc.use),
+			fmt.Sprintf("install an ffmpeg build with %s enabled (e.g. a full static build from ffmpeg.org)", enc.name))
+	}
+
+	check(caps.Filters["drawtext"], "drawtext filter (text overlays, outros)",
+		"install an ffmpeg build configured with --enable-libfreetype")
+	check(caps.HasBuildFlag("--enable-libfontconfig"), "fontconfig support (font lookup for text overlays)",
+		"install an ffmpeg build configured with --enable-libfontconfig")
+	check(caps.HasBuildFlag("--enable-libfribidi") || caps.HasBuildFlag("--enable-libharfbuzz") || caps.Filters["ass"],
+		"text shaping (Arabic, Hebrew and other complex scripts in text overlays)",
+		"install an ffmpeg build configured with --enable-libfribidi or --enable-libass")
+	check(caps.Filters["zscale"] && caps.Filters["tonemap"], "zscale and tonemap filters (HDR to SDR conversion)",
+		"install an ffmpeg build configured with --enable-libzimg")
+	check(caps.Filters["vidstabdetect"] && caps.Filters["vidstabtransform"], "vidstab filters (--stabilize)",
+		"install an ffmpeg build configured with --enable-libvidstab")
+
+	if failed > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", failed)
+	}
+
+	fmt.Println("\nEverything looks good")
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var extractAudioCmd = &cobra.Command{
+	Use:   "extract-audio <input>",
+	Short: "Pull the audio track out of a video",
+	Long: `Write a video's audio track to an audio file, e.g. for podcasts or
+transcription. With --duration the audio is split on the same boundaries,
+and with the same file names, as the chunks of a split.
+
+Example:
+  video-processor extract-audio talk.mp4 -o audio -f opus -d 60`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExtractAudio,
+}
+
+func init() {
+	extractAudioCmd.Flags().StringP("output-dir", "o", "", "Output directory for the audio files")
+	extractAudioCmd.Flags().StringP("format", "f", "mp3",
+		fmt.Sprintf("Audio format (%s)", strings.Join(videoprocessor.AudioFormats(), ", ")))
+	extractAudioCmd.Flags().String("bitrate", "", "Audio bitrate, e.g. '128k'; the format's default when empty")
+	extractAudioCmd.Flags().IntP("duration", "d", 0, "Split the audio into chunks of this many seconds; 0 extracts one file")
+	extractAudioCmd.Flags().StringP("skip", "s", "", "Duration to skip from start (e.g., '1s', '10s', '1m')")
+	extractAudioCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
+	addProgressFlags(extractAudioCmd)
+	extractAudioCmd.MarkFlagRequired("output-dir")
+
+	rootCmd.AddCommand(extractAudioCmd)
+}
+
+func runExtractAudio(cmd *cobra.Command, args []string) error {
+	opts := &config.ExtractAudioOptions{
+		InputPath:  args[0],
+		OnProgress: progressFromFlags(cmd),
+	}
+	opts.OutputDir, _ = cmd.Flags().GetString("output-dir")
+	opts.Format, _ = cmd.Flags().GetString("format")
+	opts.Bitrate, _ = cmd.Flags().GetString("bitrate")
+	opts.ChunkDuration, _ = cmd.Flags().GetInt("duration")
+	opts.Skip, _ = cmd.Flags().GetString("skip")
+	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	clips, err := videoprocessor.ExtractAudioContext(ctx, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Fprintf(resultOutput(cmd), "processedClips %+v\n", clips)
+
+	return nil
+}
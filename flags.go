@@ -0,0 +1,423 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
+	"github.com/spf13/cobra"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// addCommonFlags registers the platform and encoding flags shared by every processing command
+func addCommonFlags(cmd *cobra.Command) {
+	var plats []string
+	for _, o := range videoprocessor.GetSupportedPlatforms() {
+		plats = append(plats, string(o))
+	}
+
+	cmd.Flags().StringP("target-platform", "t", "",
+		fmt.Sprintf("Target platform for optimization (%s)",
+			strings.Join(plats, ", ")))
+	cmd.Flags().StringP("format", "f", "webm",
+		fmt.Sprintf("Output format (%s)", strings.Join(videoprocessor.GetSupportedFormats(), ", ")))
+	cmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
+	cmd.Flags().String("cache-dir", "", "Directory to keep downloaded URL inputs in between runs")
+	cmd.Flags().Duration("encode-timeout", 0, "Fail when a single ffmpeg invocation runs longer than this (e.g., '30m'; 0 disables)")
+	cmd.Flags().String("ffmpeg-args", "",
+		"Extra ffmpeg output options as key=value pairs, e.g. 'preset=veryfast,tune=film'; overrides the tool's own")
+	cmd.Flags().Bool("preserve-hdr", false, "Keep 10-bit and HDR sources in 10-bit with their HDR metadata instead of converting to 8-bit SDR")
+	cmd.Flags().String("tonemap", videoprocessor.DefaultTonemapOperator,
+		fmt.Sprintf("Tone mapping operator for HDR sources converted to SDR (%s)", strings.Join(videoprocessor.TonemapOperators(), ", ")))
+	cmd.Flags().Bool("two-pass", false, "Encode platform outputs in two passes so they hit the target bitrate (x264 and VP9)")
+	cmd.Flags().Int("crf", 0,
+		fmt.Sprintf("Encode at constant quality, from %d (best) to %d (smallest), instead of the target bitrate; 0 disables", config.MinCRF, config.MaxCRF))
+	cmd.Flags().String("encode-preset", videoprocessor.DefaultEncodePreset,
+		fmt.Sprintf("Encoder speed/quality trade-off for the output format's codec (%s)", strings.Join(videoprocessor.EncodePresets(), ", ")))
+	cmd.Flags().Bool("quality-report", false, "Score outputs against their source with VMAF, SSIM and PSNR and write a JSON report")
+	cmd.Flags().Bool("fragmented", false, "Write fragmented MP4 (fMP4/CMAF) for streaming ingest instead of faststart MP4")
+	cmd.Flags().Int("anim-fps", config.DefaultAnimationFPS, "Frame rate of gif and webp outputs")
+	cmd.Flags().Int("anim-width", config.DefaultAnimationWidth, "Width in pixels of gif and webp outputs (never upscaled)")
+	cmd.Flags().Bool("no-audio", false, "Drop the audio stream from every output, e.g. for silent loops")
+	cmd.Flags().String("font-file", "", "TrueType or OpenType font file for every text overlay instead of the system default")
+	cmd.Flags().String("font", "", "Installed font for every text overlay by fontconfig name, e.g. 'Noto Sans CJK JP' for CJK captions or 'Noto Emoji' for emoji")
+	cmd.Flags().String("text-position", "", "Position of the corner and recap text: top-left, top-right, bottom-left, bottom-right or center (default: bottom-right for corner text, top-left for recap text)")
+	cmd.Flags().Int("text-offset-x", 0, "Pixels to move the corner and recap text right of their position (negative moves left)")
+	cmd.Flags().Int("text-offset-y", 0, "Pixels to move the corner and recap text below their position (negative moves up)")
+	cmd.Flags().String("overlay-animation", "none", "Animation of the corner and recap text: none, fade, scroll (credit roll) or typewriter")
+	cmd.Flags().Float64("overlay-start", 0, "Seconds into the video the corner and recap text appear")
+	cmd.Flags().Float64("overlay-end", 0, "Seconds into the video the corner and recap text disappear (0 keeps them to the end)")
+	cmd.Flags().Int("text-wrap", 0, "Wrap text overlays at a space after this many characters per line (0 keeps lines as written; '\\n' starts a new line either way)")
+	cmd.Flags().Bool("burn-timecode", false, "Draw the running timestamp on the video, counted in the source for split chunks, e.g. for review copies")
+	cmd.Flags().Bool("audio-copy", false, "Copy AAC or Opus audio already at or below the target bitrate instead of re-encoding it")
+	cmd.Flags().String("audio-codec", "", "Audio encoder replacing the platform's or format's, e.g. 'aac' or 'libopus'")
+	cmd.Flags().String("audio-bitrate", "", "Audio bitrate replacing the platform's or encoder's default, e.g. '320k'")
+	cmd.Flags().String("effects", "", "YAML or JSON file listing ffmpeg filters (e.g. eq, unsharp, vignette or raw filter graphs) applied to every input first")
+	cmd.Flags().StringArray("blur-region", []string{}, "Rectangle of every input to blur as x,y,w,h in pixels, optionally only from start to end seconds as x,y,w,h,start,end, e.g. 40,600,300,80,2.5,8 (can be specified multiple times)")
+	cmd.Flags().Bool("blur-faces", false, "Blur the faces --face-detector finds in every input, e.g. bystanders")
+	cmd.Flags().String("face-detector", "", "Command finding faces for --blur-faces, given the input path and printing 'start end x y w h' lines in seconds and pixels")
+	cmd.Flags().String("crop", "", "Region of every input to keep before any other processing, as WxH+X+Y in pixels, e.g. 1080x1080+420+0")
+	cmd.Flags().String("crop-keyframes", "", "File of 'seconds X Y' lines moving the --crop region over time, interpolated between them")
+	cmd.Flags().Bool("stabilize", false, "Steady shaky handheld footage with vid.stab before any other effect (two extra passes over every input)")
+	cmd.Flags().Float64("speed", 1, "Playback speed of every input, e.g. 0.5 for half speed slow motion or 4 for a 4x timelapse (0.25 to 100)")
+	cmd.Flags().Bool("interpolate", false, "Synthesize the in-between frames of slow motion with minterpolate instead of repeating frames; much slower")
+	cmd.Flags().String("speed-audio", config.SpeedAudioTempo, "Audio of --speed changes: tempo (retimed, keeping its pitch) or mute")
+	cmd.Flags().Float64("min-duration", 0, "Loop inputs shorter than this many seconds up to it, e.g. 3 for platforms rejecting shorter videos")
+	cmd.Flags().String("min-duration-mode", config.LoopForward, "How --min-duration loops short inputs: loop, or pingpong (forward then backward, audio padded with silence)")
+	cmd.Flags().Bool("boomerang", false, "Turn every input into a boomerang, playing forward then backward in a loop without sound")
+	cmd.Flags().Int("boomerang-repeat", config.DefaultBoomerangRepeat, "How many times a --boomerang plays forward and backward")
+	cmd.Flags().String("denoise", "", "Denoise the video before encoding, e.g. of phone footage, so less bitrate goes to noise: light, medium or strong (slow)")
+	cmd.Flags().Int("fps", 0, "Frame rate every output is converted to (default: the source's, brought down to the platform's when it's faster than the platform plays)")
+	cmd.Flags().Bool("fps-smooth", false, "Convert frame rates with motion interpolation (minterpolate) instead of dropping or repeating frames; much slower")
+	cmd.Flags().String("sharpen", "", "unsharp options sharpening the video after it's scaled, e.g. '"+config.DefaultSharpen+"' for a mild sharpen")
+	cmd.Flags().String("lut", "", "3D LUT file (.cube, .3dl, .dat, .m3d or .csp) color grading every input before the effects")
+	cmd.Flags().String("look", "", "Built-in color grade applied after the LUT: "+strings.Join(config.LookNames(), ", "))
+	cmd.Flags().String("audio-file", "", "Replace the audio of every output with this file, trimmed or looped to the video's length")
+	cmd.Flags().String("music", "", "Background music mixed under the audio of every output, ducked while the audio is loud")
+	cmd.Flags().Float64("music-volume", config.DefaultMusicVolume, "Linear gain of the background music, e.g. 0.3 for about -10dB")
+	cmd.Flags().Float64("duck-threshold", config.DefaultDuckThreshold, "Audio level, from 0 to 1, above which the background music ducks")
+	cmd.Flags().Bool("denoise-audio", false, "Reduce background noise in the audio, e.g. of phone recordings")
+	cmd.Flags().Float64("denoise-strength", config.DefaultDenoiseStrength, "How aggressively --denoise-audio reduces noise, from 0 to 1")
+	cmd.Flags().String("denoise-model", "", "RNNoise model file for --denoise-audio; uses ffmpeg's arnndn instead of afftdn")
+	cmd.Flags().Bool("voice-boost", false, "Even out speech with a highpass, compressor and loudness normalization")
+	addProgressFlags(cmd)
+}
+
+// addProgressFlags registers the flags read by progressFromFlags
+func addProgressFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("progress", true, "Show live encoding progress instead of ffmpeg's log")
+	cmd.Flags().String("progress-format", "text",
+		"Progress output: text (redrawn line on stderr) or json (newline-delimited events on stdout, results go to stderr)")
+}
+
+// progressFromFlags returns the progress renderer selected by the flags, or nil
+// when progress is disabled
+func progressFromFlags(cmd *cobra.Command) func(types.ProgressEvent) {
+	if show, _ := cmd.Flags().GetBool("progress"); !show {
+		return nil
+	}
+
+	if format, _ := cmd.Flags().GetString("progress-format"); format == "json" {
+		return jsonProgress(os.Stdout)
+	}
+	return textProgress(os.Stderr)
+}
+
+// resultOutput returns where a command prints its results: stderr in json
+// progress mode, leaving stdout to the progress records for whatever parses
+// them, and stdout otherwise
+func resultOutput(cmd *cobra.Command) io.Writer {
+	if format, _ := cmd.Flags().GetString("progress-format"); format == "json" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// addSplitFlags registers the chunking flags shared by split-based commands
+func addSplitFlags(cmd *cobra.Command) {
+	cmd.Flags().IntP("duration", "d", 15, "Duration of each chunk in seconds")
+	cmd.Flags().StringP("skip", "s", "", "Duration to skip from start (e.g., '1s', '10s', '1m')")
+	cmd.Flags().Bool("stream-copy", false, "Cut chunks without re-encoding when no target platform is set (cuts snap to keyframes)")
+	cmd.Flags().String("intro-clip", "", "Video clip to prepend to every chunk")
+	cmd.Flags().String("outro-clip", "", "Video clip to append to every chunk")
+	cmd.Flags().Int("recap", 0, "Seconds of the previous chunk to replay at the start of each chunk")
+	cmd.Flags().String("recap-text", "", "Text overlay shown during the recap (e.g., 'Previously...')")
+	cmd.Flags().Bool("allow-copy", false, "Cut chunks without re-encoding when the source already meets the target platform's specs (cuts snap to keyframes)")
+	cmd.Flags().Float64("input-fps", 0, fmt.Sprintf("Frame rate of an image sequence input such as frames/%%05d.png (default %g)", config.DefaultInputFPS))
+	cmd.Flags().IntSlice("audio-stream", nil, "Audio track of the input to keep, counting from 0, e.g. of OBS recordings; several, e.g. '0,1', are mixed into one")
+	cmd.Flags().String("ladder", "", "Encode every chunk at each rung of a bitrate ladder, e.g. '1080p:5M,720p:3M:128k' or 'default'")
+}
+
+// addTemplateFlags registers the layout flags shared by template-based commands
+func addTemplateFlags(cmd *cobra.Command) {
+	cmd.Flags().String("video-template", "", "Template grid as <columns>x<rows>, e.g. 1x1, 2x2, 3x1, 2x1 (side by side) or 1x2 (top and bottom), a 1080x1920 vertical stack of <videos>v, e.g. 2v or 3v, or greenscreen to key the input over --key-background")
+	cmd.Flags().String("layout", "", "YAML or JSON file placing each input on the canvas, used instead of --video-template")
+	cmd.Flags().Int("template-gap", 0, "Pixels between template cells, filled with the background color")
+	cmd.Flags().Int("template-border", 0, "Width in pixels of a border around every template cell")
+	cmd.Flags().String("template-border-color", config.DefaultTemplateBorderColor, "Color of template cell borders, e.g. 'white' or '#ff3366'")
+	cmd.Flags().String("template-background", config.DefaultTemplateBackground, "Color of the template canvas behind gaps and layout cells")
+	cmd.Flags().String("template-background-image", "", "Image behind the template cells, which keep their aspect ratio over it")
+	cmd.Flags().Bool("template-background-blur", false, "Put a blurred copy of the first input behind the template cells, which keep their aspect ratio over it")
+	cmd.Flags().Float64("image-duration", config.DefaultImageDuration, "Seconds still image inputs play for")
+	cmd.Flags().Bool("no-ken-burns", false, "Show still image inputs still instead of slowly zooming in and out")
+	cmd.Flags().String("key-background", "", "Video or image the input of a greenscreen template is composited over")
+	cmd.Flags().String("key-color", config.DefaultKeyColor, "Color keyed out of greenscreen templates, e.g. 'green', 'blue' or '#00ff00'")
+	cmd.Flags().Float64("key-similarity", config.DefaultKeySimilarity, "How close to the key color, 0.01 to 1, is keyed out; raise it for unevenly lit screens")
+	cmd.Flags().Float64("key-blend", config.DefaultKeyBlend, "How far past the similarity, 0 to 1, edges fade out instead of cutting hard")
+	cmd.Flags().Bool("obscurify", false, "Apply obscurify effects to input videos")
+	cmd.Flags().Float64("obscurify-pitch", config.DefaultObscurifyPitch, "Pitch factor of the obscurify audio effect, which also speeds it up")
+	cmd.Flags().Float64("obscurify-tempo", config.DefaultObscurifyTempo, "Tempo factor of the obscurify audio effect, applied after the pitch shift")
+	cmd.Flags().Bool("obscurify-keep-audio", false, "Leave the audio unchanged when obscurifying")
+	cmd.Flags().StringSlice("obscurify-effects", nil, fmt.Sprintf("Obscurify transforms to apply (%s) (default: all of them)", strings.Join(config.ObscurifyEffects, ", ")))
+	cmd.Flags().Float64("obscurify-strength", config.DefaultObscurifyStrength, "Strength of the obscurify video transforms, 1 being the stock recipe, up to 4")
+	cmd.Flags().Float64("obscurify-jitter", 0, "Fraction, 0 to 1, each obscurify video transform's strength varies by at random (reproducible with --seed)")
+	cmd.Flags().String("landscape-bottom-right-text", "", "Add text overlay to bottom right of video if landscape")
+	cmd.Flags().String("text-color", "", "Color of the bottom right text, e.g. 'white' or '#ff3366' (default: picked from the text palette)")
+	cmd.Flags().StringSlice("text-palette", nil, "Colors the bottom right text color is picked from, e.g. 'yellow,#ff3366' (default: a built-in vibrant palette)")
+	cmd.Flags().Uint64("seed", 0, "Seed for the text color pick and obscurify jitter so renders are reproducible (0 picks a random seed)")
+	cmd.Flags().StringArray("input-text", []string{}, "Caption drawn on one input's cell as index=text, inputs counted from 0, e.g. 0='@alice' (can be specified multiple times)")
+	cmd.Flags().String("portrait-bottom-right-text", "", "Add text overlay to bottom right of video if portrait")
+	cmd.Flags().String("watermark-image", "", "Logo overlaid in the bottom right corner of the video")
+	cmd.Flags().String("watermark-motion", "static", "Move the watermark image and bottom right text to make them harder to crop out: static, corners or drift")
+	cmd.Flags().Float64("watermark-interval", config.DefaultWatermarkInterval, "Seconds a moving watermark stays in each corner, or takes to drift across the video")
+	cmd.Flags().StringArray("intro-text", []string{}, "Lines of text to display on a title card before the video (can be specified multiple times)")
+	cmd.Flags().Int("intro-duration", config.DefaultIntroDuration, "Seconds the title card is shown")
+	cmd.Flags().StringArray("outro-text", []string{}, "Lines of text to display in the outro (can be specified multiple times)")
+	cmd.Flags().Int("outro-duration", config.OutroDuration, "Seconds the outro is shown")
+	cmd.Flags().Int("outro-font-size", 0, "Font size of the outro text (default: scaled with the video height)")
+	cmd.Flags().String("outro-text-color", config.OutroTextColor, "Color of the outro text, e.g. 'white' or '#ff3366'")
+	cmd.Flags().String("outro-background", config.DefaultOutroBackground, "Background color of the outro")
+	cmd.Flags().Float64("outro-fade-in", config.DefaultOutroFadeIn, "Seconds the outro text and logo fade in over")
+	cmd.Flags().Float64("outro-fade-out", 0, "Seconds the outro fades out to its background at the end")
+	cmd.Flags().String("outro-logo", "", "Image centered above the outro text")
+	cmd.Flags().String("outro-qr", "", "URL shown as a QR code on the outro")
+	cmd.Flags().Int("outro-qr-size", 0, "Side of the outro QR code in pixels (default: a third of the video's smaller side)")
+	cmd.Flags().String("outro-audio", "", "Audio file played under the outro, faded out at its end")
+	cmd.Flags().String("outro-qr-position", "bottom-right", "Position of the outro QR code: bottom-right, bottom-left, top-right, top-left or center")
+	cmd.Flags().String("transition", "", "Crossfade into the outro instead of cutting to it: fade, dissolve or slide")
+	cmd.Flags().Float64("transition-duration", config.DefaultTransitionDuration, "Length of the outro transition in seconds")
+	cmd.Flags().String("reframe", "center", "How landscape inputs are cropped to portrait for portrait platforms: center, or smart to follow the subject")
+	cmd.Flags().String("reframe-detector", "", "Command finding the subject for --reframe smart, given the input path and printing 'seconds center' lines with center from 0 to 1 (default: built-in edge detection)")
+	cmd.Flags().String("template-audio", "mix", "Audio of 2x2 and 3x1 templates: mix, first, mute or index=N to keep input N's")
+	cmd.Flags().Float64Slice("template-audio-weights", nil, "Volume of each input when mixing template audio, in input order, e.g. '1,0.5,0.5'")
+	cmd.Flags().Int("template-fps", 0, "Frame rate every template input is normalized to (default: the target platform's)")
+	cmd.Flags().String("duration-strategy", "longest-freeze", "How template inputs of different lengths play: shortest trims to the shortest input, longest-loop loops the shorter ones and longest-freeze holds their last frame")
+}
+
+// splitOptionsFromFlags reads the flags registered by addCommonFlags and addSplitFlags
+func splitOptionsFromFlags(cmd *cobra.Command) *config.VideoSplitterOptions {
+	opts := &config.VideoSplitterOptions{}
+
+	opts.ChunkDuration, _ = cmd.Flags().GetInt("duration")
+	opts.Skip, _ = cmd.Flags().GetString("skip")
+
+	targetPlat, _ := cmd.Flags().GetString("target-platform")
+	opts.TargetPlatform = types.ProcessingPlatform(targetPlat)
+
+	opts.OutputFormat, _ = cmd.Flags().GetString("format")
+	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+	opts.StreamCopy, _ = cmd.Flags().GetBool("stream-copy")
+	opts.AllowCopy, _ = cmd.Flags().GetBool("allow-copy")
+	opts.AudioTracks, _ = cmd.Flags().GetIntSlice("audio-stream")
+	opts.InputFPS, _ = cmd.Flags().GetFloat64("input-fps")
+	opts.IntroClipPath, _ = cmd.Flags().GetString("intro-clip")
+	opts.OutroClipPath, _ = cmd.Flags().GetString("outro-clip")
+	opts.RecapSeconds, _ = cmd.Flags().GetInt("recap")
+	opts.RecapText, _ = cmd.Flags().GetString("recap-text")
+	opts.CacheDir, _ = cmd.Flags().GetString("cache-dir")
+	opts.EncodeTimeout, _ = cmd.Flags().GetDuration("encode-timeout")
+	opts.ExtraOutputArgs = ffmpegArgsFromFlags(cmd)
+	opts.PreserveHDR, _ = cmd.Flags().GetBool("preserve-hdr")
+	opts.TonemapOperator, _ = cmd.Flags().GetString("tonemap")
+	opts.TwoPass, _ = cmd.Flags().GetBool("two-pass")
+	opts.Quality, _ = cmd.Flags().GetInt("crf")
+	opts.EncodePreset, _ = cmd.Flags().GetString("encode-preset")
+	opts.QualityReport, _ = cmd.Flags().GetBool("quality-report")
+	opts.Fragmented, _ = cmd.Flags().GetBool("fragmented")
+	opts.AnimationFPS, _ = cmd.Flags().GetInt("anim-fps")
+	opts.AnimationWidth, _ = cmd.Flags().GetInt("anim-width")
+	opts.NoAudio, _ = cmd.Flags().GetBool("no-audio")
+	opts.BurnTimecode, _ = cmd.Flags().GetBool("burn-timecode")
+	opts.FontFile, _ = cmd.Flags().GetString("font-file")
+	opts.Font, _ = cmd.Flags().GetString("font")
+	opts.Wrap, _ = cmd.Flags().GetInt("text-wrap")
+	opts.Position, _ = cmd.Flags().GetString("text-position")
+	opts.OffsetX, _ = cmd.Flags().GetInt("text-offset-x")
+	opts.OffsetY, _ = cmd.Flags().GetInt("text-offset-y")
+	opts.Animation, _ = cmd.Flags().GetString("overlay-animation")
+	opts.ShowAt, _ = cmd.Flags().GetFloat64("overlay-start")
+	opts.HideAt, _ = cmd.Flags().GetFloat64("overlay-end")
+	opts.AudioCopy, _ = cmd.Flags().GetBool("audio-copy")
+	opts.AudioCodec, _ = cmd.Flags().GetString("audio-codec")
+	opts.AudioBitrate, _ = cmd.Flags().GetString("audio-bitrate")
+	opts.EffectsFile, _ = cmd.Flags().GetString("effects")
+	opts.FrameRate, _ = cmd.Flags().GetInt("fps")
+	opts.SmoothFrameRate, _ = cmd.Flags().GetBool("fps-smooth")
+	opts.Sharpen, _ = cmd.Flags().GetString("sharpen")
+	opts.BlurRegions, _ = cmd.Flags().GetStringArray("blur-region")
+	opts.BlurFaces, _ = cmd.Flags().GetBool("blur-faces")
+	opts.FaceDetector, _ = cmd.Flags().GetString("face-detector")
+	opts.Crop, _ = cmd.Flags().GetString("crop")
+	opts.CropKeyframes, _ = cmd.Flags().GetString("crop-keyframes")
+	opts.Stabilize, _ = cmd.Flags().GetBool("stabilize")
+	opts.Speed, _ = cmd.Flags().GetFloat64("speed")
+	opts.Interpolate, _ = cmd.Flags().GetBool("interpolate")
+	opts.SpeedAudio, _ = cmd.Flags().GetString("speed-audio")
+	opts.MinDuration, _ = cmd.Flags().GetFloat64("min-duration")
+	opts.LoopMode, _ = cmd.Flags().GetString("min-duration-mode")
+	opts.Boomerang, _ = cmd.Flags().GetBool("boomerang")
+	opts.BoomerangRepeat, _ = cmd.Flags().GetInt("boomerang-repeat")
+	opts.Denoise, _ = cmd.Flags().GetString("denoise")
+	opts.LUTFile, _ = cmd.Flags().GetString("lut")
+	opts.Look, _ = cmd.Flags().GetString("look")
+	opts.AudioFile, _ = cmd.Flags().GetString("audio-file")
+	opts.MusicFile, _ = cmd.Flags().GetString("music")
+	opts.MusicVolume, _ = cmd.Flags().GetFloat64("music-volume")
+	opts.DuckThreshold, _ = cmd.Flags().GetFloat64("duck-threshold")
+	opts.DenoiseAudio, _ = cmd.Flags().GetBool("denoise-audio")
+	opts.DenoiseStrength, _ = cmd.Flags().GetFloat64("denoise-strength")
+	opts.DenoiseModel, _ = cmd.Flags().GetString("denoise-model")
+	opts.VoiceBoost, _ = cmd.Flags().GetBool("voice-boost")
+	if ladder, _ := cmd.Flags().GetString("ladder"); ladder != "" {
+		// Validated in the root command's PersistentPreRunE
+		opts.Renditions, _ = config.ParseLadder(ladder)
+	}
+	opts.OnProgress = progressFromFlags(cmd)
+
+	return opts
+}
+
+// templateOptionsFromFlags reads the flags registered by addCommonFlags and addTemplateFlags
+func templateOptionsFromFlags(cmd *cobra.Command) *config.VideoTemplateOptions {
+	opts := &config.VideoTemplateOptions{}
+
+	opts.TemplateType, _ = cmd.Flags().GetString("video-template")
+	if layout, _ := cmd.Flags().GetString("layout"); layout != "" {
+		// Validated in the root command's PersistentPreRunE
+		opts.Layout, _ = config.LoadLayout(layout)
+		opts.TemplateType = "custom"
+	}
+	opts.TemplateGap, _ = cmd.Flags().GetInt("template-gap")
+	opts.TemplateBorder, _ = cmd.Flags().GetInt("template-border")
+	opts.TemplateBorderColor, _ = cmd.Flags().GetString("template-border-color")
+	opts.TemplateBackground, _ = cmd.Flags().GetString("template-background")
+	opts.TemplateBackgroundImage, _ = cmd.Flags().GetString("template-background-image")
+	opts.TemplateBackgroundBlur, _ = cmd.Flags().GetBool("template-background-blur")
+	opts.ImageDuration, _ = cmd.Flags().GetFloat64("image-duration")
+	opts.NoKenBurns, _ = cmd.Flags().GetBool("no-ken-burns")
+	opts.KeyBackground, _ = cmd.Flags().GetString("key-background")
+	opts.KeyColor, _ = cmd.Flags().GetString("key-color")
+	opts.KeySimilarity, _ = cmd.Flags().GetFloat64("key-similarity")
+	opts.KeyBlend, _ = cmd.Flags().GetFloat64("key-blend")
+	opts.TemplateAudio, _ = cmd.Flags().GetString("template-audio")
+	opts.TemplateAudioWeights, _ = cmd.Flags().GetFloat64Slice("template-audio-weights")
+	opts.DurationStrategy, _ = cmd.Flags().GetString("duration-strategy")
+	opts.Reframe, _ = cmd.Flags().GetString("reframe")
+	opts.ReframeDetector, _ = cmd.Flags().GetString("reframe-detector")
+	opts.FrameRate, _ = cmd.Flags().GetInt("template-fps")
+	if opts.FrameRate == 0 {
+		opts.FrameRate, _ = cmd.Flags().GetInt("fps")
+	}
+	opts.OutputFormat, _ = cmd.Flags().GetString("format")
+	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+	opts.Obscurify, _ = cmd.Flags().GetBool("obscurify")
+	opts.ObscurifyPitch, _ = cmd.Flags().GetFloat64("obscurify-pitch")
+	opts.ObscurifyTempo, _ = cmd.Flags().GetFloat64("obscurify-tempo")
+	opts.ObscurifyKeepAudio, _ = cmd.Flags().GetBool("obscurify-keep-audio")
+	opts.ObscurifyEffects, _ = cmd.Flags().GetStringSlice("obscurify-effects")
+	opts.ObscurifyStrength, _ = cmd.Flags().GetFloat64("obscurify-strength")
+	opts.ObscurifyJitter, _ = cmd.Flags().GetFloat64("obscurify-jitter")
+	opts.LandscapeBottomRightText, _ = cmd.Flags().GetString("landscape-bottom-right-text")
+	opts.PortraitBottomRightText, _ = cmd.Flags().GetString("portrait-bottom-right-text")
+	if opts.PortraitBottomRightText == "" {
+		opts.PortraitBottomRightText = opts.LandscapeBottomRightText
+	}
+	opts.InputTexts, _ = cmd.Flags().GetStringArray("input-text")
+	opts.TextColor, _ = cmd.Flags().GetString("text-color")
+	opts.TextPalette, _ = cmd.Flags().GetStringSlice("text-palette")
+	opts.Seed, _ = cmd.Flags().GetUint64("seed")
+	opts.WatermarkImage, _ = cmd.Flags().GetString("watermark-image")
+	opts.WatermarkMotion, _ = cmd.Flags().GetString("watermark-motion")
+	opts.WatermarkInterval, _ = cmd.Flags().GetFloat64("watermark-interval")
+
+	tarPlat, _ := cmd.Flags().GetString("target-platform")
+	opts.TargetPlatform = types.ProcessingPlatform(tarPlat)
+
+	opts.IntroLines, _ = cmd.Flags().GetStringArray("intro-text")
+	opts.IntroDuration, _ = cmd.Flags().GetInt("intro-duration")
+	outroText, _ := cmd.Flags().GetStringArray("outro-text")
+	opts.OutroLines = outroText
+	opts.OutroDuration, _ = cmd.Flags().GetInt("outro-duration")
+	opts.OutroFontSize, _ = cmd.Flags().GetInt("outro-font-size")
+	opts.OutroTextColor, _ = cmd.Flags().GetString("outro-text-color")
+	opts.OutroBackground, _ = cmd.Flags().GetString("outro-background")
+	opts.OutroFadeIn, _ = cmd.Flags().GetFloat64("outro-fade-in")
+	opts.OutroFadeOut, _ = cmd.Flags().GetFloat64("outro-fade-out")
+	opts.OutroLogo, _ = cmd.Flags().GetString("outro-logo")
+	opts.OutroQR, _ = cmd.Flags().GetString("outro-qr")
+	opts.OutroQRSize, _ = cmd.Flags().GetInt("outro-qr-size")
+	opts.OutroQRPosition, _ = cmd.Flags().GetString("outro-qr-position")
+	opts.OutroAudio, _ = cmd.Flags().GetString("outro-audio")
+	opts.Transition, _ = cmd.Flags().GetString("transition")
+	opts.TransitionDuration, _ = cmd.Flags().GetFloat64("transition-duration")
+	opts.CacheDir, _ = cmd.Flags().GetString("cache-dir")
+	opts.EncodeTimeout, _ = cmd.Flags().GetDuration("encode-timeout")
+	opts.ExtraOutputArgs = ffmpegArgsFromFlags(cmd)
+	opts.PreserveHDR, _ = cmd.Flags().GetBool("preserve-hdr")
+	opts.TonemapOperator, _ = cmd.Flags().GetString("tonemap")
+	opts.TwoPass, _ = cmd.Flags().GetBool("two-pass")
+	opts.Quality, _ = cmd.Flags().GetInt("crf")
+	opts.EncodePreset, _ = cmd.Flags().GetString("encode-preset")
+	opts.QualityReport, _ = cmd.Flags().GetBool("quality-report")
+	opts.Fragmented, _ = cmd.Flags().GetBool("fragmented")
+	opts.AnimationFPS, _ = cmd.Flags().GetInt("anim-fps")
+	opts.AnimationWidth, _ = cmd.Flags().GetInt("anim-width")
+	opts.NoAudio, _ = cmd.Flags().GetBool("no-audio")
+	opts.BurnTimecode, _ = cmd.Flags().GetBool("burn-timecode")
+	opts.FontFile, _ = cmd.Flags().GetString("font-file")
+	opts.Font, _ = cmd.Flags().GetString("font")
+	opts.Wrap, _ = cmd.Flags().GetInt("text-wrap")
+	opts.Position, _ = cmd.Flags().GetString("text-position")
+	opts.OffsetX, _ = cmd.Flags().GetInt("text-offset-x")
+	opts.OffsetY, _ = cmd.Flags().GetInt("text-offset-y")
+	opts.Animation, _ = cmd.Flags().GetString("overlay-animation")
+	opts.ShowAt, _ = cmd.Flags().GetFloat64("overlay-start")
+	opts.HideAt, _ = cmd.Flags().GetFloat64("overlay-end")
+	opts.AudioCopy, _ = cmd.Flags().GetBool("audio-copy")
+	opts.AudioCodec, _ = cmd.Flags().GetString("audio-codec")
+	opts.AudioBitrate, _ = cmd.Flags().GetString("audio-bitrate")
+	opts.EffectsFile, _ = cmd.Flags().GetString("effects")
+	opts.SmoothFrameRate, _ = cmd.Flags().GetBool("fps-smooth")
+	opts.Sharpen, _ = cmd.Flags().GetString("sharpen")
+	opts.BlurRegions, _ = cmd.Flags().GetStringArray("blur-region")
+	opts.BlurFaces, _ = cmd.Flags().GetBool("blur-faces")
+	opts.FaceDetector, _ = cmd.Flags().GetString("face-detector")
+	opts.Crop, _ = cmd.Flags().GetString("crop")
+	opts.CropKeyframes, _ = cmd.Flags().GetString("crop-keyframes")
+	opts.Stabilize, _ = cmd.Flags().GetBool("stabilize")
+	opts.Speed, _ = cmd.Flags().GetFloat64("speed")
+	opts.Interpolate, _ = cmd.Flags().GetBool("interpolate")
+	opts.SpeedAudio, _ = cmd.Flags().GetString("speed-audio")
+	opts.MinDuration, _ = cmd.Flags().GetFloat64("min-duration")
+	opts.LoopMode, _ = cmd.Flags().GetString("min-duration-mode")
+	opts.Boomerang, _ = cmd.Flags().GetBool("boomerang")
+	opts.BoomerangRepeat, _ = cmd.Flags().GetInt("boomerang-repeat")
+	opts.Denoise, _ = cmd.Flags().GetString("denoise")
+	opts.LUTFile, _ = cmd.Flags().GetString("lut")
+	opts.Look, _ = cmd.Flags().GetString("look")
+	opts.AudioFile, _ = cmd.Flags().GetString("audio-file")
+	opts.MusicFile, _ = cmd.Flags().GetString("music")
+	opts.MusicVolume, _ = cmd.Flags().GetFloat64("music-volume")
+	opts.DuckThreshold, _ = cmd.Flags().GetFloat64("duck-threshold")
+	opts.DenoiseAudio, _ = cmd.Flags().GetBool("denoise-audio")
+	opts.DenoiseStrength, _ = cmd.Flags().GetFloat64("denoise-strength")
+	opts.DenoiseModel, _ = cmd.Flags().GetString("denoise-model")
+	opts.VoiceBoost, _ = cmd.Flags().GetBool("voice-boost")
+	opts.OnProgress = progressFromFlags(cmd)
+
+	return opts
+}
+
+// ffmpegArgsFromFlags parses --ffmpeg-args into output kwargs. A key without a
+// value becomes a bare option.
+func ffmpegArgsFromFlags(cmd *cobra.Command) ffmpeg.KwArgs {
+	raw, _ := cmd.Flags().GetString("ffmpeg-args")
+	if raw == "" {
+		return nil
+	}
+
+	args := ffmpeg.KwArgs{}
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		key = strings.TrimLeft(key, "-")
+		if key == "" {
+			continue
+		}
+		args[key] = value
+	}
+	return args
+}
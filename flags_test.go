@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+func TestFFmpegArgsFromFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want ffmpeg.KwArgs
+	}{
+		{"unset", "", nil},
+		{"pairs", "preset=veryfast,tune=film", ffmpeg.KwArgs{"preset": "veryfast", "tune": "film"}},
+		{"dashes and spaces", " -preset=slow , --crf=18 ", ffmpeg.KwArgs{"preset": "slow", "crf": "18"}},
+		{"bare option", "shortest,movflags=+faststart", ffmpeg.KwArgs{"shortest": "", "movflags": "+faststart"}},
+		{"value with equals", "metadata=title=My Clip", ffmpeg.KwArgs{"metadata": "title=My Clip"}},
+		{"empty pairs", ",,=x,", ffmpeg.KwArgs{}},
+		{"last wins", "crf=20,crf=28", ffmpeg.KwArgs{"crf": "28"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addCommonFlags(cmd)
+			if err := cmd.Flags().Set("ffmpeg-args", tt.raw); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := ffmpegArgsFromFlags(cmd); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ffmpegArgsFromFlags(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
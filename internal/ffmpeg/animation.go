@@ -0,0 +1,86 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// IsAnimation reports whether the output format is an animated image rather
+// than a video, which has no audio and is built by ProcessAnimation
+func IsAnimation(outputFormat string) bool {
+	switch codecPresets[outputFormat].ContainerFormat {
+	case "gif", "webp":
+		return true
+	}
+	return false
+}
+
+// SetAnimation sets the frame rate and width of animated GIF and WebP
+// outputs. Zero means config.DefaultAnimationFPS and config.DefaultAnimationWidth.
+func (p *Processor) SetAnimation(fps, width int) {
+	p.animationFPS = fps
+	p.animationWidth = width
+}
+
+// ProcessAnimation converts a segment of the input into an animated GIF or
+// WebP, dropping the audio. GIFs go through palettegen and paletteuse, so
+// the 256 colours are picked from the segment instead of a fixed palette.
+func (p *Processor) ProcessAnimation(inputPath, outputPath, outputFormat string, startTime float64, duration int) error {
+	fps := p.animationFPS
+	if fps == 0 {
+		fps = config.DefaultAnimationFPS
+	}
+	width := p.animationWidth
+	if width == 0 {
+		width = config.DefaultAnimationWidth
+	}
+
+	inputKwargs := ffmpeg.KwArgs{
+		"ss": startTime,
+	}
+	if duration > 0 {
+		inputKwargs["t"] = duration
+	}
+
+	// Only needed for HDR handling, so a failed probe isn't fatal here
+	source, _ := GetVideoMetadata(inputPath)
+
+	video := p.timecodeStream(p.tonemapStream(ffmpeg.Input(inputPath, inputKwargs).Video(), source), startTime).
+		Filter("fps", ffmpeg.Args{strconv.Itoa(fps)})
+	// Never upscale, an animation that big is already too heavy
+	if source == nil || source.Width > width {
+		video = video.Filter("scale", ffmpeg.Args{fmt.Sprintf("%d:-2", width)}, ffmpeg.KwArgs{"flags": "lanczos"})
+	}
+	video = p.sharpenStream(video)
+
+	settings := GetCodecSettings(outputFormat)
+	if settings.ContainerFormat == "gif" {
+		copies := video.Split()
+		palette := copies.Get("0").Filter("palettegen", ffmpeg.Args{})
+		video = ffmpeg.Filter([]*ffmpeg.Stream{copies.Get("1"), palette}, "paletteuse", ffmpeg.Args{})
+	}
+
+	outputKwargs := ffmpeg.KwArgs{
+		"c:v":  settings.VideoCodec,
+		"loop": 0,
+		"an":   "",
+	}
+	for k, v := range p.EncoderPreset(settings) {
+		outputKwargs[k] = v
+	}
+
+	if p.verbose {
+		log.Printf("Exporting %s animation at %dfps, %dpx wide\n", outputFormat, fps, width)
+	}
+
+	err := p.Run(video.Output(outputPath, p.OutputArgs(outputKwargs, source)), float64(duration))
+	if err != nil {
+		return fmt.Errorf("failed to export animation: %v", err)
+	}
+
+	return nil
+}
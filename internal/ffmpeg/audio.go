@@ -0,0 +1,160 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// SetAudio sets the audio processing ProcessAudio applies
+func (p *Processor) SetAudio(opts config.AudioOptions) {
+	p.audio = opts
+}
+
+// ProcessAudio rewrites the audio of the video at path in place with the
+// audio options applied, encoding it with audioCodec at audioBitrate (empty
+// for the encoder's default) unless overridden. The video stream is copied.
+func (p *Processor) ProcessAudio(path, audioCodec, audioBitrate string) error {
+	if !p.audio.ProcessesAudio() {
+		return nil
+	}
+
+	metadata, err := GetVideoMetadata(path)
+	if err != nil {
+		return fmt.Errorf("error probing video: %v", err)
+	}
+
+	input := ffmpeg.Input(path)
+	var audio *ffmpeg.Stream
+	switch {
+	case p.audio.AudioFile != "":
+		// Looped endlessly, the output duration trims it to the video
+		audio = ffmpeg.Input(p.audio.AudioFile, ffmpeg.KwArgs{"stream_loop": -1}).Audio()
+		if p.verbose {
+			log.Printf("Replacing the audio of %s with %s\n", path, p.audio.AudioFile)
+		}
+	case metadata.HasAudio:
+		audio = input.Audio()
+	}
+
+	if audio != nil {
+		audio = p.cleanAudio(audio)
+	}
+
+	if p.audio.MusicFile != "" {
+		audio = p.mixMusic(audio)
+		if p.verbose {
+			log.Printf("Mixing %s under the audio of %s\n", p.audio.MusicFile, path)
+		}
+	}
+	if audio == nil {
+		if p.verbose {
+			log.Printf("%s has no audio, skipping the audio processing\n", path)
+		}
+		return nil
+	}
+
+	kwargs := ffmpeg.KwArgs{
+		"c:v": "copy",
+		"c:a": audioCodec,
+		"t":   metadata.Duration,
+	}
+	if audioBitrate != "" {
+		kwargs["b:a"] = audioBitrate
+	}
+	p.audioEncodingArgs(kwargs)
+	kwargs["c:a"] = ResolveEncoder(kwargs["c:a"].(string))
+	ext := filepath.Ext(path)
+	switch strings.ToLower(ext) {
+	case ".mp4", ".mov", ".m4v":
+		kwargs["movflags"] = "+faststart"
+		p.MuxArgs(kwargs)
+	}
+
+	// Written next to the original, then moved over it
+	tmpPath := strings.TrimSuffix(path, ext) + "_audio" + ext
+	stream := ffmpeg.Output([]*ffmpeg.Stream{input.Video(), audio}, tmpPath, kwargs)
+	if err := p.Run(stream, metadata.Duration); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to process audio: %v", err)
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// cleanAudio applies the denoise and voice boost filters to audio
+func (p *Processor) cleanAudio(audio *ffmpeg.Stream) *ffmpeg.Stream {
+	if p.audio.DenoiseAudio {
+		strength := p.audio.DenoiseStrength
+		if strength == 0 {
+			strength = config.DefaultDenoiseStrength
+		}
+		if p.audio.DenoiseModel != "" {
+			// mix blends the denoised audio with the original
+			audio = audio.Filter("arnndn", ffmpeg.Args{}, ffmpeg.KwArgs{
+				"m":   p.audio.DenoiseModel,
+				"mix": fmt.Sprintf("%g", strength),
+			})
+		} else {
+			// Full strength reduces the noise by 40dB
+			audio = audio.Filter("afftdn", ffmpeg.Args{}, ffmpeg.KwArgs{
+				"nr": fmt.Sprintf("%g", max(strength*40, 1)),
+				"tn": 1,
+			})
+		}
+	}
+
+	if p.audio.VoiceBoost {
+		// Rumble below the voice only eats into the compressor's headroom
+		audio = audio.Filter("highpass", ffmpeg.Args{}, ffmpeg.KwArgs{"f": 80}).
+			Filter("acompressor", ffmpeg.Args{}, ffmpeg.KwArgs{
+				"threshold": 0.1,
+				"ratio":     4,
+				"attack":    5,
+				"release":   100,
+			}).
+			Filter("loudnorm", ffmpeg.Args{}, ffmpeg.KwArgs{"I": -16, "TP": -1.5, "LRA": 11}).
+			// loudnorm upsamples to 192kHz
+			Filter("aresample", ffmpeg.Args{"48000"})
+	}
+	return audio
+}
+
+// mixMusic mixes the looped music file under audio, ducking it with a
+// sidechain compressor whenever audio is louder than the duck threshold so
+// speech stays audible. Without audio the music plays on its own.
+func (p *Processor) mixMusic(audio *ffmpeg.Stream) *ffmpeg.Stream {
+	volume := p.audio.MusicVolume
+	if volume == 0 {
+		volume = config.DefaultMusicVolume
+	}
+	threshold := p.audio.DuckThreshold
+	if threshold == 0 {
+		threshold = config.DefaultDuckThreshold
+	}
+
+	music := ffmpeg.Input(p.audio.MusicFile, ffmpeg.KwArgs{"stream_loop": -1}).Audio().
+		Filter("volume", ffmpeg.Args{fmt.Sprintf("%g", volume)})
+	if audio == nil {
+		return music
+	}
+
+	// One copy keys the compressor, the other is mixed over the ducked music
+	copies := audio.ASplit()
+	ducked := ffmpeg.Filter([]*ffmpeg.Stream{music, copies.Get("0")}, "sidechaincompress", ffmpeg.Args{}, ffmpeg.KwArgs{
+		"threshold": fmt.Sprintf("%g", threshold),
+		"ratio":     8,
+		"attack":    20,
+		"release":   400,
+	})
+	return ffmpeg.Filter([]*ffmpeg.Stream{copies.Get("1"), ducked}, "amix", ffmpeg.Args{}, ffmpeg.KwArgs{
+		"inputs":    2,
+		"duration":  "first",
+		"normalize": 0,
+	})
+}
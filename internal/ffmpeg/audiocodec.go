@@ -0,0 +1,54 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// containerAudioEncoders maps containers to the audio encoders whose codecs
+// they can hold. Matroska holds them all.
+var containerAudioEncoders = map[string][]string{
+	"webm": {"libopus", "libvorbis"},
+	"mp4":  {"aac", "libmp3lame", "libopus", "flac", "ac3", "eac3", "alac"},
+	"mov":  {"aac", "pcm_s16le", "pcm_s24le", "alac", "libmp3lame", "ac3"},
+	"dash": {"aac", "libopus"},
+}
+
+// CheckAudioEncoder returns an error when the container of outputFormat can't
+// hold the audio encoder's codec
+func CheckAudioEncoder(outputFormat, encoder string) error {
+	container := GetCodecSettings(outputFormat).ContainerFormat
+	encoders, ok := containerAudioEncoders[container]
+	if !ok || slices.Contains(encoders, encoder) {
+		return nil
+	}
+	return fmt.Errorf("%s output can't hold %s audio (supported: %s)",
+		outputFormat, encoder, strings.Join(encoders, ", "))
+}
+
+// ValidBitrate reports whether bitrate is a positive bitrate ffmpeg accepts,
+// e.g. "320k" or "1.5M"
+func ValidBitrate(bitrate string) bool {
+	return bitsPerSecond(bitrate) > 0
+}
+
+// SetAudioEncoding overrides the audio encoder and bitrate of every encode.
+// Empty values keep the platform's or output format's.
+func (p *Processor) SetAudioEncoding(encoder, bitrate string) {
+	p.audioEncoder = encoder
+	p.audioBitrate = bitrate
+}
+
+// audioEncodingArgs applies the audio encoder and bitrate overrides to
+// kwargs. kwargs is modified in place.
+func (p *Processor) audioEncodingArgs(kwargs ffmpeg.KwArgs) {
+	if p.audioEncoder != "" {
+		kwargs["c:a"] = p.audioEncoder
+	}
+	if p.audioBitrate != "" {
+		kwargs["b:a"] = p.audioBitrate
+	}
+}
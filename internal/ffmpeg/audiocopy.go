@@ -0,0 +1,46 @@
+package ffmpeg
+
+import (
+	"log"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// SetAudioCopy makes encodes of a single input copy its audio instead of
+// re-encoding it when it's already in the target codec and bitrate
+func (p *Processor) SetAudioCopy(audioCopy bool) {
+	p.audioCopy = audioCopy
+}
+
+// audioCopyArgs switches kwargs to copying the audio of source when audio copy
+// is enabled, the source audio is AAC or Opus in the codec kwargs encodes to,
+// and its bitrate is known to be within the target. Filtered audio has to be
+// re-encoded, so kwargs with an audio filter are left alone. kwargs is
+// modified in place.
+func (p *Processor) audioCopyArgs(kwargs ffmpeg.KwArgs, source *VideoMetadata) {
+	if !p.audioCopy || p.noAudio || source == nil || !source.HasAudio {
+		return
+	}
+	if _, ok := kwargs["af"]; ok {
+		return
+	}
+
+	encoder, _ := kwargs["c:a"].(string)
+	switch codec := encoderCodecs[encoder]; {
+	case codec != "aac" && codec != "opus":
+		return
+	case source.AudioCodec != codec:
+		return
+	}
+	if target, ok := kwargs["b:a"].(string); ok {
+		if source.AudioBitrate == 0 || source.AudioBitrate > bitsPerSecond(target) {
+			return
+		}
+	}
+
+	if p.verbose {
+		log.Printf("Copying %s audio at %d bps instead of re-encoding it\n", source.AudioCodec, source.AudioBitrate)
+	}
+	kwargs["c:a"] = "copy"
+	delete(kwargs, "b:a")
+}
@@ -0,0 +1,74 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// AudioTrackCount returns the number of audio streams in inputPath
+func AudioTrackCount(inputPath string) (int, error) {
+	probe, err := ffmpeg.Probe(inputPath)
+	if err != nil {
+		return 0, fmt.Errorf("error probing video: %v", err)
+	}
+	var data struct {
+		Streams []mediaStream `json:"streams"`
+	}
+	if err := json.Unmarshal([]byte(probe), &data); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	count := 0
+	for _, s := range data.Streams {
+		if s.CodecType == "audio" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// SelectAudioTracks writes the video of inputPath with only the audio tracks
+// at the given audio stream indexes to outputPath, a Matroska file. One track
+// is copied as is, several are mixed into one track encoded losslessly. The
+// video is always copied.
+func (p *Processor) SelectAudioTracks(inputPath, outputPath string, tracks []int) error {
+	metadata, err := GetVideoMetadata(inputPath)
+	if err != nil {
+		return err
+	}
+
+	input := ffmpeg.Input(inputPath)
+	kwargs := ffmpeg.KwArgs{"c:v": "copy", "f": "matroska"}
+
+	var audio *ffmpeg.Stream
+	if len(tracks) == 1 {
+		audio = input.Get("a:" + strconv.Itoa(tracks[0]))
+		kwargs["c:a"] = "copy"
+	} else {
+		streams := make([]*ffmpeg.Stream, len(tracks))
+		for i, track := range tracks {
+			streams[i] = input.Get("a:" + strconv.Itoa(track))
+		}
+		audio = ffmpeg.Filter(streams, "amix", ffmpeg.Args{}, ffmpeg.KwArgs{
+			"inputs":   len(streams),
+			"duration": "longest",
+		})
+		// Lossless, the chunks encode it again
+		kwargs["c:a"] = "flac"
+	}
+
+	if p.verbose {
+		log.Printf("Keeping audio tracks %v of %s\n", tracks, inputPath)
+	}
+
+	stream := ffmpeg.Output([]*ffmpeg.Stream{input.Get("V"), audio}, outputPath, kwargs)
+	if err := p.Run(stream, metadata.Duration); err != nil {
+		return fmt.Errorf("failed to select audio tracks: %v", err)
+	}
+	return nil
+}
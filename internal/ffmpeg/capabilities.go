@@ -0,0 +1,201 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// encoderFallbacks maps encoders to the one used instead when ffmpeg lacks them
+var encoderFallbacks = map[string]string{
+	"libvpx-vp9": "libx264",
+	"libopus":    "aac",
+	"libsvtav1":  "libaom-av1",
+	"libx265":    "libx264",
+	// Older builds only have the still image encoder, which also animates
+	"libwebp_anim": "libwebp",
+}
+
+// formatFallbacks maps output formats to the one used instead when ffmpeg lacks
+// their encoders, since a fallback encoder may not fit the original container
+var formatFallbacks = map[string]string{
+	"webm": "mp4",
+	"av1":  "mp4",
+	"hevc": "mp4",
+}
+
+var (
+	installedOnce sync.Once
+	installed     *Capabilities
+	warned        sync.Map
+)
+
+// Capabilities describes what the installed ffmpeg build supports
+type Capabilities struct {
+	Version     string
+	BuildConfig string
+	Encoders    map[string]bool
+	Filters     map[string]bool
+}
+
+// ToolVersion returns the first line of `<bin> -version`, e.g. "ffmpeg version 6.1.1"
+func ToolVersion(bin string) (string, error) {
+	out, err := exec.Command(bin, "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s: %v", bin, err)
+	}
+
+	line, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+// DetectCapabilities queries the ffmpeg on PATH for its version, build flags,
+// encoders and filters
+func DetectCapabilities() (*Capabilities, error) {
+	version, err := exec.Command("ffmpeg", "-hide_banner", "-version").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ffmpeg: %v", err)
+	}
+
+	caps := &Capabilities{
+		Encoders: make(map[string]bool),
+		Filters:  make(map[string]bool),
+	}
+	for _, line := range strings.Split(string(version), "\n") {
+		switch {
+		case strings.HasPrefix(line, "ffmpeg version"):
+			caps.Version = strings.TrimSpace(line)
+		case strings.HasPrefix(line, "configuration:"):
+			caps.BuildConfig = strings.TrimSpace(strings.TrimPrefix(line, "configuration:"))
+		}
+	}
+
+	encoders, err := exec.Command("ffmpeg", "-hide_banner", "-encoders").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ffmpeg encoders: %v", err)
+	}
+	parseCapabilityList(encoders, caps.Encoders)
+
+	filters, err := exec.Command("ffmpeg", "-hide_banner", "-filters").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ffmpeg filters: %v", err)
+	}
+	parseCapabilityList(filters, caps.Filters)
+
+	return caps, nil
+}
+
+// installedCapabilities returns the capabilities of the ffmpeg on PATH, probed
+// once per process. It returns nil when ffmpeg can't be queried, in which case
+// callers assume everything is available and let ffmpeg report the problem.
+func installedCapabilities() *Capabilities {
+	installedOnce.Do(func() {
+		caps, err := DetectCapabilities()
+		if err != nil {
+			log.Printf("Warning: could not detect ffmpeg encoders: %v\n", err)
+			return
+		}
+		installed = caps
+	})
+	return installed
+}
+
+// HasEncoder reports whether the installed ffmpeg provides encoder
+func HasEncoder(encoder string) bool {
+	caps := installedCapabilities()
+	return caps == nil || caps.Encoders[encoder]
+}
+
+// HasFilter reports whether the installed ffmpeg provides filter
+func HasFilter(filter string) bool {
+	caps := installedCapabilities()
+	return caps == nil || caps.Filters[filter]
+}
+
+// CanShapeText reports whether the installed ffmpeg's drawtext shapes text,
+// joining Arabic letters and laying out right to left scripts, which takes
+// libfribidi or libharfbuzz
+func CanShapeText() bool {
+	caps := installedCapabilities()
+	return caps == nil || caps.HasBuildFlag("--enable-libfribidi") || caps.HasBuildFlag("--enable-libharfbuzz")
+}
+
+// ResolveEncoder returns encoder, or its fallback with a warning when the
+// installed ffmpeg lacks it and the fallback is available
+func ResolveEncoder(encoder string) string {
+	fallback, ok := encoderFallbacks[encoder]
+	if !ok || HasEncoder(encoder) || !HasEncoder(fallback) {
+		return encoder
+	}
+
+	warnOnce(encoder, "Warning: ffmpeg has no %s encoder, using %s instead\n", encoder, fallback)
+	return fallback
+}
+
+// ResolveOutputFormat returns format, or its fallback format with a warning
+// when the installed ffmpeg lacks the format's encoders
+func ResolveOutputFormat(format string) string {
+	fallback, ok := formatFallbacks[format]
+	if !ok {
+		return format
+	}
+
+	settings := GetCodecSettings(format)
+	if hasEncoderOrFallback(settings.VideoCodec) && hasEncoderOrFallback(settings.AudioCodec) {
+		return format
+	}
+
+	warnOnce("format:"+format, "Warning: ffmpeg lacks the %s/%s encoders for %s output, writing %s instead\n",
+		settings.VideoCodec, settings.AudioCodec, format, fallback)
+	return fallback
+}
+
+// hasEncoderOrFallback reports whether encoder or a fallback that produces the
+// same codec is available
+func hasEncoderOrFallback(encoder string) bool {
+	if HasEncoder(encoder) {
+		return true
+	}
+	fallback, ok := encoderFallbacks[encoder]
+	return ok && sameCodec[encoder] == fallback && HasEncoder(fallback)
+}
+
+// sameCodec lists the fallbacks that encode the same codec, so switching to
+// them doesn't change what the container has to hold
+var sameCodec = map[string]string{
+	"libsvtav1": "libaom-av1",
+}
+
+func warnOnce(key, format string, args ...interface{}) {
+	if _, loaded := warned.LoadOrStore(key, true); !loaded {
+		log.Printf(format, args...)
+	}
+}
+
+// HasBuildFlag reports whether ffmpeg was configured with flag, e.g. "--enable-libfontconfig"
+func (c *Capabilities) HasBuildFlag(flag string) bool {
+	for _, f := range strings.Fields(c.BuildConfig) {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCapabilityList collects the names from `ffmpeg -encoders` or `-filters`
+// output, whose entries look like " V..... libx264  description". Legend lines
+// ("V..... = Video") are skipped.
+func parseCapabilityList(out []byte, names map[string]bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[1] == "=" {
+			continue
+		}
+		names[fields[1]] = true
+	}
+}
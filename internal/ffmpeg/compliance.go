@@ -0,0 +1,71 @@
+package ffmpeg
+
+import (
+	"fmt"
+
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// encoderCodecs maps encoders to the ffprobe name of the codec they produce
+var encoderCodecs = map[string]string{
+	"libx264":    "h264",
+	"libx265":    "hevc",
+	"libvpx-vp9": "vp9",
+	"libsvtav1":  "av1",
+	"libaom-av1": "av1",
+	"aac":        "aac",
+	"libopus":    "opus",
+}
+
+// CopyCompliant reports whether inputPath already meets plat's codecs,
+// dimensions and bitrate, so cuts of it can be stream copied instead of
+// re-encoded. When it doesn't, reason says why.
+func CopyCompliant(inputPath string, plat platform.Platform) (ok bool, reason string, err error) {
+	metadata, err := GetVideoMetadata(inputPath)
+	if err != nil {
+		return false, "", err
+	}
+	probe, err := ffmpeg.Probe(inputPath)
+	if err != nil {
+		return false, "", fmt.Errorf("error probing video: %v", err)
+	}
+
+	if want := encoderCodecs[plat.GetVideoCodec()]; metadata.Codec != want {
+		return false, fmt.Sprintf("video codec is %s, %s wants %s", metadata.Codec, plat.GetName(), want), nil
+	}
+	if want := encoderCodecs[plat.GetAudioCodec()]; metadata.HasAudio && metadata.AudioCodec != want {
+		return false, fmt.Sprintf("audio codec is %s, %s wants %s", metadata.AudioCodec, plat.GetName(), want), nil
+	}
+	// Every encode writes 8-bit 4:2:0, which is also all most players decode
+	if metadata.PixelFormat != "" && metadata.PixelFormat != "yuv420p" {
+		return false, fmt.Sprintf("pixel format is %s, not yuv420p", metadata.PixelFormat), nil
+	}
+
+	isPortrait := metadata.Height > metadata.Width
+	if plat.ForcePortrait() && !isPortrait {
+		return false, fmt.Sprintf("%s needs landscape video cropped to portrait", plat.GetName()), nil
+	}
+	maxWidth, maxHeight := plat.GetMaxDimensions()
+	if isPortrait != (maxHeight > maxWidth) {
+		maxWidth, maxHeight = maxHeight, maxWidth
+	}
+	if metadata.Width > maxWidth || metadata.Height > maxHeight {
+		return false, fmt.Sprintf("%dx%d exceeds %s's %dx%d", metadata.Width, metadata.Height, plat.GetName(), maxWidth, maxHeight), nil
+	}
+
+	if metadata.FrameRate > float64(plat.GetMaxFrameRate()) {
+		return false, fmt.Sprintf("%.3g fps exceeds %s's %d", metadata.FrameRate, plat.GetName(), plat.GetMaxFrameRate()), nil
+	}
+
+	maxBitrate := int64(bitsPerSecond(plat.GetVideoBitrate()) + bitsPerSecond(plat.GetAudioBitrate()))
+	bitrate, err := getBitrate(metadata, probe)
+	if err != nil {
+		return false, fmt.Sprintf("bitrate unknown: %v", err), nil
+	}
+	if bitrate > maxBitrate {
+		return false, fmt.Sprintf("bitrate of %dk exceeds %s's %dk", bitrate/1000, plat.GetName(), maxBitrate/1000), nil
+	}
+
+	return true, "", nil
+}
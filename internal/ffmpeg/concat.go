@@ -0,0 +1,107 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Segment is a single clip in a concatenated output
+type Segment struct {
+	Path string
+	// VideoFilter is applied to the segment's video before concatenation when set
+	VideoFilter func(*ffmpeg.Stream) *ffmpeg.Stream
+}
+
+// ConcatSegments joins the given segments in order and writes the result to
+// outputPath. Every segment is scaled and padded to width x height so the concat
+// filter accepts them, and the result is re-encoded with the platform's codec
+// settings, or the output format's when plat is nil.
+func (p *Processor) ConcatSegments(segments []Segment, outputPath string, width, height int, plat platform.Platform, outputFormat string) error {
+	if len(segments) == 0 {
+		return errors.New("no segments to concatenate")
+	}
+
+	var totalDuration float64
+	var source *VideoMetadata
+	metadatas := make([]*VideoMetadata, len(segments))
+	hasAudio := false
+	for i, segment := range segments {
+		// Only used for progress, audio and HDR handling, so a failed probe isn't fatal here
+		metadata, err := GetVideoMetadata(segment.Path)
+		if err != nil {
+			// Assume the segment has audio, as before the probe
+			hasAudio = !p.noAudio
+			continue
+		}
+		metadatas[i] = metadata
+		totalDuration += metadata.Duration
+		hasAudio = hasAudio || (metadata.HasAudio && !p.noAudio)
+		// The output takes the color properties of the first HDR segment, if any
+		if source == nil || (metadata.IsHDR() && !source.IsHDR()) {
+			source = metadata
+		}
+	}
+
+	streams := make([]*ffmpeg.Stream, 0, len(segments)*2)
+	for i, segment := range segments {
+		metadata := metadatas[i]
+		input := ffmpeg.Input(segment.Path)
+		video := p.tonemapStream(input.Video(), metadata).
+			Filter("scale", ffmpeg.Args{fmt.Sprintf("%d:%d", width, height)},
+				ffmpeg.KwArgs{"force_original_aspect_ratio": "decrease"}).
+			Filter("pad", ffmpeg.Args{fmt.Sprintf("%d:%d:(ow-iw)/2:(oh-ih)/2:black", width, height)}).
+			Filter("setsar", ffmpeg.Args{"1"})
+		if segment.VideoFilter != nil {
+			video = segment.VideoFilter(video)
+		}
+		streams = append(streams, video)
+		if !hasAudio {
+			continue
+		}
+
+		// The concat filter needs audio from every segment once any has it
+		audio := input.Audio()
+		if metadata != nil && !metadata.HasAudio {
+			if p.verbose {
+				log.Printf("%s has no audio, filling it with silence\n", segment.Path)
+			}
+			audio = ffmpeg.Input("anullsrc=channel_layout=stereo:sample_rate=48000",
+				ffmpeg.KwArgs{"f": "lavfi", "t": metadata.Duration}).Audio()
+		}
+		streams = append(streams, audio.Filter("aresample", ffmpeg.Args{"48000"}))
+	}
+
+	outputs := []*ffmpeg.Stream{}
+	if hasAudio {
+		joined := ffmpeg.Concat(streams, ffmpeg.KwArgs{"v": 1, "a": 1}).Node
+		outputs = append(outputs, joined.Get("0"), joined.Get("1"))
+	} else {
+		if p.verbose && !p.noAudio {
+			log.Printf("No segment has audio, concatenating video only\n")
+		}
+		joined := ffmpeg.Concat(streams, ffmpeg.KwArgs{"v": 1, "a": 0}).Node
+		outputs = append(outputs, joined.Get("0"))
+	}
+
+	outputKwargs := p.OutputArgs(PlatformOutputArgs(plat, outputFormat), source)
+
+	if p.verbose {
+		log.Printf("Concatenating %d segments into %s\n", len(segments), outputPath)
+	}
+
+	var maxSize int64
+	if plat != nil {
+		maxSize = plat.GetMaxFileSize()
+	}
+
+	err := p.EncodeWithinSize(outputs, outputPath, outputKwargs, totalDuration, maxSize)
+	if err != nil {
+		return fmt.Errorf("failed to concatenate segments: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// dashSegmentSeconds is the target length of DASH media segments
+const dashSegmentSeconds = 4
+
+// dashMuxerOptions are the kwargs only the dash muxer understands
+var dashMuxerOptions = []string{
+	"seg_duration", "use_template", "use_timeline",
+	"init_seg_name", "media_seg_name", "adaptation_sets",
+}
+
+// IsDASH reports whether the output format is packaged as MPEG-DASH, an MPD
+// manifest with its media segments written next to it
+func IsDASH(outputFormat string) bool {
+	return codecPresets[outputFormat].ContainerFormat == "dash"
+}
+
+// dashArgs switches kwargs to the dash muxer writing manifestPath. Segment
+// names start with the manifest's name so several manifests can share a
+// directory, and keyframes are forced on segment boundaries so players can
+// switch representations at any segment. kwargs is modified in place.
+func dashArgs(kwargs ffmpeg.KwArgs, manifestPath string) {
+	name := strings.TrimSuffix(filepath.Base(manifestPath), filepath.Ext(manifestPath))
+
+	delete(kwargs, "movflags")
+	kwargs["f"] = "dash"
+	kwargs["seg_duration"] = dashSegmentSeconds
+	kwargs["use_template"] = 1
+	kwargs["use_timeline"] = 1
+	kwargs["init_seg_name"] = name + "_init_$RepresentationID$.$ext$"
+	kwargs["media_seg_name"] = name + "_$RepresentationID$_$Number%05d$.$ext$"
+	kwargs["force_key_frames"] = fmt.Sprintf("expr:gte(t,n_forced*%d)", dashSegmentSeconds)
+}
+
+// DASHSegments returns the init and media segment files dashArgs names after
+// manifestPath
+func DASHSegments(manifestPath string) ([]string, error) {
+	prefix := strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath))
+	return filepath.Glob(prefix + "_*.m4s")
+}
+
+// ProcessDASHLadder cuts a segment and packages it as a single DASH manifest
+// with a video representation per ladder rung, scaled and encoded like
+// ProcessRendition. The rungs share one audio representation at the highest
+// audio bitrate among them.
+func (p *Processor) ProcessDASHLadder(inputPath, manifestPath, outputFormat string, startTime float64, duration int, ladder []config.Rendition) error {
+	source, err := GetVideoMetadata(inputPath)
+	if err != nil {
+		return fmt.Errorf("error probing video: %v", err)
+	}
+
+	inputKwargs := ffmpeg.KwArgs{
+		"ss": startTime,
+	}
+	if duration > 0 {
+		inputKwargs["t"] = duration
+	}
+	input := ffmpeg.Input(inputPath, inputKwargs)
+
+	outputKwargs := GenericOutputArgs(outputFormat)
+	for k, v := range p.EncoderPreset(GetCodecSettings(outputFormat)) {
+		outputKwargs[k] = v
+	}
+	delete(outputKwargs, "crf")
+	outputKwargs = p.OutputArgs(outputKwargs, source)
+	dashArgs(outputKwargs, manifestPath)
+
+	videos := p.timecodeStream(p.tonemapStream(p.frameRateStream(input.Video(), source), source), startTime).Split()
+	streams := make([]*ffmpeg.Stream, 0, len(ladder)+1)
+	var audioBitrate string
+	for i, rung := range ladder {
+		scale := fmt.Sprintf("-2:%d", rung.Height)
+		if source.Height > source.Width {
+			scale = fmt.Sprintf("%d:-2", rung.Height)
+		}
+		streams = append(streams, p.sharpenStream(videos.Get(strconv.Itoa(i)).Filter("scale", ffmpeg.Args{scale})))
+
+		outputKwargs[fmt.Sprintf("b:v:%d", i)] = rung.VideoBitrate
+		outputKwargs[fmt.Sprintf("maxrate:v:%d", i)] = rung.VideoBitrate
+		outputKwargs[fmt.Sprintf("bufsize:v:%d", i)] = fmt.Sprintf("%dk", 2*bitsPerSecond(rung.VideoBitrate)/1000)
+		if bitsPerSecond(rung.AudioBitrate) > bitsPerSecond(audioBitrate) {
+			audioBitrate = rung.AudioBitrate
+		}
+	}
+
+	// Every video rung goes in one adaptation set, which is what lets players switch between them
+	adaptationSets := "id=0,streams=v"
+	if source.HasAudio && !p.noAudio {
+		streams = append(streams, input.Audio())
+		adaptationSets += " id=1,streams=a"
+		if audioBitrate != "" {
+			outputKwargs["b:a"] = audioBitrate
+		}
+	}
+	outputKwargs["adaptation_sets"] = adaptationSets
+
+	if p.verbose {
+		log.Printf("Packaging %d renditions as DASH: %s\n", len(ladder), manifestPath)
+	}
+
+	if err := p.Encode(streams, manifestPath, outputKwargs, float64(duration)); err != nil {
+		return fmt.Errorf("failed to package DASH renditions: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package ffmpeg
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// SetSharpen sharpens every encode with these unsharp options after it's
+// scaled, or not at all when empty
+func (p *Processor) SetSharpen(sharpen string) {
+	p.sharpen = sharpen
+}
+
+// withSharpen returns filter with the unsharp filter appended when sharpening
+func (p *Processor) withSharpen(filter string) string {
+	switch {
+	case p.sharpen == "":
+		return filter
+	case filter == "":
+		return "unsharp=" + p.sharpen
+	}
+	return filter + ",unsharp=" + p.sharpen
+}
+
+// sharpenStream runs the unsharp filter on a video stream when sharpening
+func (p *Processor) sharpenStream(video *ffmpeg.Stream) *ffmpeg.Stream {
+	if p.sharpen == "" {
+		return video
+	}
+	return video.Filter("unsharp", ffmpeg.Args{p.sharpen})
+}
+
+// LUTFilter returns the lut3d filter grading video with the 3D LUT at path
+func LUTFilter(path string) string {
+	return "lut3d=file=" + quoteOption(path)
+}
+
+// ApplyEffects encodes inputPath through the video filter graph to
+// outputPath, a near lossless Matroska intermediate the later encodes start
+// from, with the stabilizing and speed change of opts. Stabilizing runs a
+// vidstabdetect pass first, keeping its transforms next to outputPath, and
+// steadies the video before the filter graph. Boomerangs and inputs shorter
+// than the minimum duration loop, up to it for the latter. The audio is
+// copied unless the speed changes or the input ping-pongs.
+func (p *Processor) ApplyEffects(inputPath, outputPath, filter string, opts config.EffectOptions) error {
+	metadata, err := GetVideoMetadata(inputPath)
+	if err != nil {
+		return err
+	}
+
+	var filters []string
+	if opts.Stabilize {
+		transforms := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".trf"
+		if p.verbose {
+			log.Printf("Detecting camera shake in %s\n", inputPath)
+		}
+		detect := ffmpeg.Input(inputPath).Output(os.DevNull, ffmpeg.KwArgs{
+			"vf": "vidstabdetect=result=" + quoteOption(transforms),
+			"an": "",
+			"f":  "null",
+		})
+		if err := p.Run(detect, metadata.Duration); err != nil {
+			return fmt.Errorf("failed to detect camera shake: %v", err)
+		}
+
+		// Zooming in just enough each frame hides the borders the motion leaves
+		filters = append(filters, "vidstabtransform=input="+quoteOption(transforms)+":optzoom=2")
+	}
+	if filter != "" {
+		filters = append(filters, filter)
+	}
+
+	kwargs := ffmpeg.KwArgs{
+		"c:v":    "libx264",
+		"crf":    10,
+		"preset": "veryfast",
+		"c:a":    "copy",
+		"f":      "matroska",
+	}
+
+	// Filtered audio can't be copied, flac keeps it lossless
+	audio := metadata.HasAudio
+	var audioFilters []string
+
+	speed := cmp.Or(opts.Speed, 1)
+	fps := cmp.Or(metadata.FrameRate, 30)
+	if speed != 1 {
+		filters = append(filters, fmt.Sprintf("setpts=PTS/%g", speed))
+		switch {
+		case speed > 1:
+			// Sped up, the frames crowd together; a timelapse only keeps
+			// as many as the source's frame rate
+			filters = append(filters, fmt.Sprintf("fps=%g", fps))
+		case opts.Interpolate:
+			// Slowed down, the frames are spread out; interpolating fills
+			// the gaps back up to the source's frame rate
+			filters = append(filters, fmt.Sprintf("minterpolate=fps=%g:mi_mode=mci", fps))
+		}
+
+		if opts.SpeedAudio == config.SpeedAudioMute {
+			audio = false
+		} else {
+			audioFilters = append(audioFilters, atempoChain(speed))
+		}
+	}
+
+	// A boomerang plays forward then backward, repeatedly, without sound
+	duration := metadata.Duration / speed
+	pingPong, loops := false, 0
+	if opts.Boomerang {
+		pingPong, loops = true, cmp.Or(opts.BoomerangRepeat, config.DefaultBoomerangRepeat)-1
+		duration *= float64(2 * (loops + 1))
+		audio = false
+	}
+
+	// Short inputs loop up to the minimum duration, where the output is cut
+	inputKwargs := ffmpeg.KwArgs{}
+	if duration < opts.MinDuration {
+		duration = opts.MinDuration
+		kwargs["t"] = fmt.Sprintf("%.3f", duration)
+		switch {
+		case pingPong:
+			loops = -1
+		case opts.LoopMode == config.LoopPingPong:
+			pingPong, loops = true, -1
+			audioFilters = append(audioFilters, "apad")
+		default:
+			inputKwargs["stream_loop"] = -1
+		}
+	}
+
+	if pingPong {
+		// Playing backward needs the frames of the whole input, so they
+		// loop in the filter graph, which also keeps them, rather than in
+		// the input. The timestamps then restart from the frame count.
+		rate := fps
+		if speed < 1 && !opts.Interpolate {
+			rate *= speed
+		}
+		filters = append(filters, "split[forward][backward];[backward]reverse[reversed];[forward][reversed]concat")
+		if loops != 0 {
+			filters = append(filters, fmt.Sprintf("loop=loop=%d:size=32767", loops))
+		}
+		filters = append(filters, fmt.Sprintf("setpts=N/(%g*TB)", rate))
+	}
+
+	kwargs["vf"] = cmp.Or(strings.Join(filters, ","), "null")
+	switch {
+	case !audio:
+		delete(kwargs, "c:a")
+		kwargs["an"] = ""
+	case len(audioFilters) > 0:
+		kwargs["af"] = strings.Join(audioFilters, ",")
+		kwargs["c:a"] = "flac"
+	}
+
+	if p.verbose {
+		log.Printf("Applying effects to %s: %s\n", inputPath, kwargs["vf"])
+	}
+
+	if err := p.Run(ffmpeg.Input(inputPath, inputKwargs).Output(outputPath, kwargs), duration); err != nil {
+		return fmt.Errorf("failed to apply effects: %v", err)
+	}
+	return nil
+}
+
+// atempoChain returns atempo filters changing the audio's speed without
+// changing its pitch, chained since each one only covers 0.5 to 2, e.g.
+// "atempo=2,atempo=2,atempo=2" for 8x
+func atempoChain(speed float64) string {
+	var filters []string
+	for speed < 0.5 {
+		filters = append(filters, "atempo=0.5")
+		speed /= 0.5
+	}
+	for speed > 2 {
+		filters = append(filters, "atempo=2")
+		speed /= 2
+	}
+	return strings.Join(append(filters, fmt.Sprintf("atempo=%g", speed)), ",")
+}
@@ -0,0 +1,88 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// audioFormat is an audio-only output of ExtractAudio
+type audioFormat struct {
+	encoder   string
+	muxer     string
+	extension string
+	bitrate   string // Empty for lossless encoders
+}
+
+// audioFormats holds the formats ExtractAudio writes
+var audioFormats = map[string]audioFormat{
+	"mp3":  {encoder: "libmp3lame", muxer: "mp3", extension: ".mp3", bitrate: "192k"},
+	"aac":  {encoder: "aac", muxer: "ipod", extension: ".m4a", bitrate: "192k"},
+	"opus": {encoder: "libopus", muxer: "ogg", extension: ".opus", bitrate: "128k"},
+	"wav":  {encoder: "pcm_s16le", muxer: "wav", extension: ".wav"},
+}
+
+// AudioFormats returns the formats ExtractAudio writes, sorted
+func AudioFormats() []string {
+	formats := make([]string, 0, len(audioFormats))
+	for format := range audioFormats {
+		formats = append(formats, format)
+	}
+	slices.Sort(formats)
+	return formats
+}
+
+// IsAudioFormat reports whether ExtractAudio writes format
+func IsAudioFormat(format string) bool {
+	_, ok := audioFormats[format]
+	return ok
+}
+
+// AudioFileExtension returns the file extension, with the dot, of the audio format
+func AudioFileExtension(format string) string {
+	return audioFormats[format].extension
+}
+
+// ExtractAudio writes duration seconds of inputPath's audio from startTime to
+// outputPath in format, at bitrate or the format's default when empty. A zero
+// duration extracts to the end.
+func (p *Processor) ExtractAudio(inputPath, outputPath, format string, startTime float64, duration int, bitrate string) error {
+	af, ok := audioFormats[format]
+	if !ok {
+		return fmt.Errorf("unsupported audio format: %s", format)
+	}
+
+	metadata, err := GetVideoMetadata(inputPath)
+	if err != nil {
+		return err
+	}
+
+	inputKwargs := ffmpeg.KwArgs{"ss": startTime}
+	if duration > 0 {
+		inputKwargs["t"] = duration
+	}
+
+	// libopus has no fallback that fits ogg, so it isn't resolved
+	kwargs := ffmpeg.KwArgs{
+		"c:a": af.encoder,
+		"f":   af.muxer,
+	}
+	if af.bitrate != "" {
+		if bitrate == "" {
+			bitrate = af.bitrate
+		}
+		kwargs["b:a"] = bitrate
+	}
+	if af.muxer == "ipod" {
+		kwargs["movflags"] = "+faststart"
+	}
+
+	if p.verbose {
+		log.Printf("Extracting %s audio from %s at %.2fs\n", format, inputPath, startTime)
+	}
+
+	stream := ffmpeg.Input(inputPath, inputKwargs).Audio().Output(outputPath, kwargs)
+	return p.Run(stream, expectedDuration(metadata, startTime, duration))
+}
@@ -0,0 +1,82 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// FaceBox is a face found over a stretch of a video, in pixels of its frames
+type FaceBox struct {
+	Start, End float64 // Seconds into the video the face is there
+	X, Y, W, H int
+}
+
+// DetectFaces runs command to find the faces in inputPath. ffmpeg has no face
+// detection of its own, so it's left to a command plugging in a real model.
+// The command gets the input path as its last argument and prints a
+// "start end x y w h" line per face, a face that moves being printed once
+// per stretch it stays roughly in place.
+func (p *Processor) DetectFaces(command, inputPath string) ([]FaceBox, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("face detector command is empty")
+	}
+
+	// Detection gets the same timeout as an encode
+	ctx := p.ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(p.ctx, p.timeout)
+		defer cancel()
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], inputPath)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if p.ctx.Err() != nil {
+			return nil, p.ctx.Err()
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("face detector %s did not finish within the %s encode timeout", args[0], p.timeout)
+		}
+		return nil, fmt.Errorf("face detector %s failed: %v\n%s", args[0], err, lastLines(stderr.String(), stderrTailLines))
+	}
+
+	var faces []FaceBox
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("face detector printed %q, expected \"start end x y w h\"", scanner.Text())
+		}
+
+		var face FaceBox
+		var errs [6]error
+		face.Start, errs[0] = strconv.ParseFloat(fields[0], 64)
+		face.End, errs[1] = strconv.ParseFloat(fields[1], 64)
+		face.X, errs[2] = strconv.Atoi(fields[2])
+		face.Y, errs[3] = strconv.Atoi(fields[3])
+		face.W, errs[4] = strconv.Atoi(fields[4])
+		face.H, errs[5] = strconv.Atoi(fields[5])
+		for _, err := range errs {
+			if err != nil {
+				return nil, fmt.Errorf("face detector printed %q, expected \"start end x y w h\": %v", scanner.Text(), err)
+			}
+		}
+		if face.End <= face.Start || face.W <= 0 || face.H <= 0 {
+			return nil, fmt.Errorf("face detector printed an empty face %q", scanner.Text())
+		}
+		faces = append(faces, face)
+	}
+	return faces, nil
+}
@@ -1,14 +1,18 @@
 package ffmpeg
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math"
 	"os"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ZacxDev/video-splitter/config"
 	"github.com/ZacxDev/video-splitter/internal/platform"
@@ -25,6 +29,12 @@ type CodecSettings struct {
 	EncoderPresets  map[string]ffmpeg.KwArgs
 }
 
+// DefaultEncodePreset is the EncoderPresets tier used when none is chosen
+const DefaultEncodePreset = "balanced"
+
+// EncodePresets are the EncoderPresets tiers every format defines, fastest first
+var EncodePresets = []string{"fast", "balanced", "best"}
+
 var codecPresets = map[string]CodecSettings{
 	"webm": {
 		VideoCodec:      "libvpx-vp9",
@@ -33,7 +43,21 @@ var codecPresets = map[string]CodecSettings{
 		ContainerFormat: "webm",
 		FileExtension:   ".webm",
 		EncoderPresets: map[string]ffmpeg.KwArgs{
-			"high_quality": {
+			"fast": {
+				"deadline":     "good",
+				"cpu-used":     5,
+				"row-mt":       1,
+				"tile-columns": 2,
+			},
+			"balanced": {
+				"deadline":      "good",
+				"cpu-used":      3,
+				"row-mt":        1,
+				"tile-columns":  2,
+				"auto-alt-ref":  1,
+				"lag-in-frames": 25,
+			},
+			"best": {
 				"quality":        "best",
 				"cpu-used":       2,
 				"row-mt":         1,
@@ -51,7 +75,17 @@ var codecPresets = map[string]CodecSettings{
 		ContainerFormat: "mp4",
 		FileExtension:   ".mp4",
 		EncoderPresets: map[string]ffmpeg.KwArgs{
-			"high_quality": {
+			"fast": {
+				"preset":    "veryfast",
+				"profile:v": "high",
+				"movflags":  "+faststart",
+			},
+			"balanced": {
+				"preset":    "medium",
+				"profile:v": "high",
+				"movflags":  "+faststart",
+			},
+			"best": {
 				"preset":       "slower",
 				"profile:v":    "high",
 				"level":        "5.2",
@@ -66,6 +100,151 @@ var codecPresets = map[string]CodecSettings{
 			},
 		},
 	},
+	"av1": {
+		VideoCodec:      "libsvtav1",
+		AudioCodec:      "libopus",
+		DefaultCRF:      35,
+		ContainerFormat: "mp4",
+		FileExtension:   ".mp4",
+		EncoderPresets: map[string]ffmpeg.KwArgs{
+			"fast": {
+				"preset":   10,
+				"movflags": "+faststart",
+			},
+			"balanced": {
+				"preset":   8,
+				"movflags": "+faststart",
+			},
+			"best": {
+				"preset":        4,
+				"svtav1-params": "tune=0:enable-overlays=1",
+				"movflags":      "+faststart",
+			},
+		},
+	},
+	"prores": {
+		// Intra-frame mezzanine for editing, the tiers pick the prores_ks
+		// profile: LT, standard or HQ
+		VideoCodec:      "prores_ks",
+		AudioCodec:      "pcm_s16le",
+		ContainerFormat: "mov",
+		FileExtension:   ".mov",
+		EncoderPresets: map[string]ffmpeg.KwArgs{
+			"fast": {
+				"profile:v": 1,
+			},
+			"balanced": {
+				"profile:v": 2,
+			},
+			"best": {
+				"profile:v": 3,
+			},
+		},
+	},
+	"gif": {
+		// Quality comes from the palette ProcessAnimation generates, so the
+		// tiers have nothing to tune
+		VideoCodec:      "gif",
+		ContainerFormat: "gif",
+		FileExtension:   ".gif",
+		EncoderPresets: map[string]ffmpeg.KwArgs{
+			"fast":     {},
+			"balanced": {},
+			"best":     {},
+		},
+	},
+	"webp": {
+		VideoCodec:      "libwebp_anim",
+		ContainerFormat: "webp",
+		FileExtension:   ".webp",
+		EncoderPresets: map[string]ffmpeg.KwArgs{
+			"fast": {
+				"quality":           70,
+				"compression_level": 2,
+			},
+			"balanced": {
+				"quality":           75,
+				"compression_level": 4,
+			},
+			"best": {
+				"quality":           85,
+				"compression_level": 6,
+			},
+		},
+	},
+	"mkv": {
+		// Matroska holds nearly any codec, so it's the archival and intermediate
+		// container: H.264 for playback everywhere, lossless FLAC for the audio
+		VideoCodec:      "libx264",
+		AudioCodec:      "flac",
+		DefaultCRF:      0,
+		ContainerFormat: "matroska",
+		FileExtension:   ".mkv",
+		EncoderPresets: map[string]ffmpeg.KwArgs{
+			"fast": {
+				"preset":    "veryfast",
+				"profile:v": "high",
+			},
+			"balanced": {
+				"preset":    "medium",
+				"profile:v": "high",
+			},
+			"best": {
+				"preset":       "slower",
+				"profile:v":    "high",
+				"bf":           3,
+				"refs":         4,
+				"rc-lookahead": 60,
+			},
+		},
+	},
+	"dash": {
+		VideoCodec:      "libx264",
+		AudioCodec:      "aac",
+		DefaultCRF:      0,
+		ContainerFormat: "dash",
+		FileExtension:   ".mpd",
+		EncoderPresets: map[string]ffmpeg.KwArgs{
+			"fast": {
+				"preset":    "veryfast",
+				"profile:v": "high",
+			},
+			"balanced": {
+				"preset":    "medium",
+				"profile:v": "high",
+			},
+			"best": {
+				"preset":       "slower",
+				"profile:v":    "high",
+				"bf":           3,
+				"refs":         4,
+				"rc-lookahead": 60,
+			},
+		},
+	},
+	"hevc": {
+		VideoCodec:      "libx265",
+		AudioCodec:      "aac",
+		DefaultCRF:      28,
+		ContainerFormat: "mp4",
+		FileExtension:   ".mp4",
+		EncoderPresets: map[string]ffmpeg.KwArgs{
+			"fast": {
+				"preset":   "veryfast",
+				"movflags": "+faststart",
+			},
+			"balanced": {
+				"preset":   "medium",
+				"movflags": "+faststart",
+			},
+			"best": {
+				"preset":      "slow",
+				"profile:v":   "main",
+				"movflags":    "+faststart",
+				"x265-params": "aq-mode=3",
+			},
+		},
+	},
 }
 
 func GetCodecSettings(outputFormat string) CodecSettings {
@@ -76,12 +255,54 @@ func GetCodecSettings(outputFormat string) CodecSettings {
 	return codecPresets["webm"]
 }
 
+// SupportedFormats returns the output formats with codec presets, sorted by name
+func SupportedFormats() []string {
+	formats := make([]string, 0, len(codecPresets))
+	for format := range codecPresets {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// IsSupportedFormat reports whether format has codec presets
+func IsSupportedFormat(format string) bool {
+	_, ok := codecPresets[format]
+	return ok
+}
+
+// FileExtension returns the file extension, with the dot, for files of the
+// output format. Formats named after a codec use their container's extension.
+func FileExtension(outputFormat string) string {
+	if settings, ok := codecPresets[outputFormat]; ok {
+		return settings.FileExtension
+	}
+	return "." + outputFormat
+}
+
 // VideoMetadata contains metadata about a video file
 type VideoMetadata struct {
-	Duration float64
-	Width    int
-	Height   int
-	Codec    string
+	Duration       float64
+	Width          int
+	Height         int
+	Codec          string
+	FrameRate      float64 // Zero when unknown
+	Rotation       int     // Degrees players rotate the video clockwise for display, 0, 90, 180 or 270
+	HasAudio       bool
+	AudioCodec     string
+	AudioBitrate   int // In bits per second, zero when unknown
+	AudioRate      int // Sample rate in Hz, zero when unknown
+	AudioChannels  int // Zero when unknown
+	PixelFormat    string
+	BitDepth       int
+	ColorPrimaries string
+	ColorTransfer  string
+	ColorSpace     string
+	ColorRange     string
+
+	// HDR10 static metadata in x265's syntax, empty when the source has none
+	MasteringDisplay string
+	MaxCLL           string
 }
 
 // VideoDimensions represents width and height of a video
@@ -92,16 +313,174 @@ type VideoDimensions struct {
 
 // Processor wraps FFmpeg functionality
 type Processor struct {
-	verbose bool
+	verbose         bool
+	ctx             context.Context
+	timeout         time.Duration
+	extraArgs       ffmpeg.KwArgs
+	preserveHDR     bool
+	tonemapOperator string
+	twoPass         bool
+	quality         int
+	encodePreset    string
+	fragmented      bool
+	animationFPS    int
+	animationWidth  int
+	noAudio         bool
+	burnTimecode    bool
+	sharpen         string
+	frameRate       int
+	smoothFrameRate bool
+	reframe         string
+	subjectDetector SubjectDetector
+	text            config.TextOptions
+	audioCopy       bool
+	audioEncoder    string
+	audioBitrate    string
+	audio           config.AudioOptions
+	onProgress      func(Progress)
 }
 
 // NewProcessor creates a new FFmpeg processor
 func NewProcessor(verbose bool) *Processor {
 	return &Processor{
 		verbose: verbose,
+		ctx:     context.Background(),
 	}
 }
 
+// SetTimeout limits how long a single ffmpeg invocation may run. Zero means no limit.
+func (p *Processor) SetTimeout(timeout time.Duration) {
+	p.timeout = timeout
+}
+
+// SetExtraOutputArgs adds output arguments to every encode, overriding the
+// ones the processor would pass for the same keys
+func (p *Processor) SetExtraOutputArgs(args ffmpeg.KwArgs) {
+	p.extraArgs = args
+}
+
+// OutputArgs returns kwargs with missing encoders swapped for their fallbacks,
+// the audio encoding overrides and quality setting applied, the pixel format and color tagging adjusted for
+// source, the MP4 flags switched to fragmented output when enabled, audio
+// dropped when disabled, and the extra output arguments on top. source may be nil.
+func (p *Processor) OutputArgs(kwargs ffmpeg.KwArgs, source *VideoMetadata) ffmpeg.KwArgs {
+	merged := make(ffmpeg.KwArgs, len(kwargs)+len(p.extraArgs))
+	for k, v := range kwargs {
+		merged[k] = v
+	}
+	p.audioEncodingArgs(merged)
+	for _, key := range []string{"c:v", "c:a"} {
+		if encoder, ok := merged[key].(string); ok && encoder != "copy" {
+			merged[key] = ResolveEncoder(encoder)
+		}
+	}
+	// Apple players and most platforms only accept HEVC in MP4/MOV with the hvc1 tag
+	if merged["c:v"] == "libx265" {
+		if _, ok := merged["tag:v"]; !ok {
+			merged["tag:v"] = "hvc1"
+		}
+	}
+	p.qualityArgs(merged)
+	p.colorArgs(merged, source)
+	p.MuxArgs(merged)
+	p.audioArgs(merged)
+	for k, v := range p.extraArgs {
+		merged[k] = v
+	}
+	return merged
+}
+
+// fragmentedMovflags makes the mp4 muxer write a CMAF-compatible fragmented
+// file: a fragment per keyframe after an empty moov, each fragment self-contained
+const fragmentedMovflags = "+frag_keyframe+empty_moov+default_base_moof"
+
+// SetFragmented makes MP4 outputs fragmented (fMP4) instead of progressive
+// with the index moved to the front
+func (p *Processor) SetFragmented(fragmented bool) {
+	p.fragmented = fragmented
+}
+
+// MuxArgs switches MP4 kwargs, the ones setting movflags, to fragmented output
+// when enabled. kwargs is modified in place.
+func (p *Processor) MuxArgs(kwargs ffmpeg.KwArgs) {
+	if _, ok := kwargs["movflags"]; ok && p.fragmented {
+		kwargs["movflags"] = fragmentedMovflags
+	}
+}
+
+// SetNoAudio makes every output leave out the audio stream
+func (p *Processor) SetNoAudio(noAudio bool) {
+	p.noAudio = noAudio
+}
+
+// audioArgs drops the audio settings from kwargs and disables audio output
+// when audio is disabled. kwargs is modified in place.
+func (p *Processor) audioArgs(kwargs ffmpeg.KwArgs) {
+	if !p.noAudio {
+		return
+	}
+	for _, key := range []string{"c:a", "b:a", "af"} {
+		delete(kwargs, key)
+	}
+	kwargs["an"] = ""
+}
+
+// SetQuality makes every encode use constant quality at crf, on x264's scale,
+// in place of its target bitrate. Zero keeps the bitrate.
+func (p *Processor) SetQuality(crf int) {
+	p.quality = crf
+}
+
+// fixedQualityEncoders have no rate control to switch to constant quality:
+// ProRes' profile sets its quality, the animation encoders have their own
+var fixedQualityEncoders = map[string]bool{
+	"prores_ks":    true,
+	"gif":          true,
+	"libwebp_anim": true,
+	"libwebp":      true,
+}
+
+// qualityArgs switches kwargs to constant quality when a quality is set. VP9
+// and AV1 use a 0-63 scale, so the x264-scale CRF is mapped onto it.
+func (p *Processor) qualityArgs(kwargs ffmpeg.KwArgs) {
+	encoder, _ := kwargs["c:v"].(string)
+	if p.quality == 0 || encoder == "" || encoder == "copy" || fixedQualityEncoders[encoder] {
+		return
+	}
+
+	delete(kwargs, "maxrate")
+	delete(kwargs, "bufsize")
+	switch encoder {
+	case "libvpx-vp9", "libsvtav1", "libaom-av1":
+		kwargs["crf"] = p.quality * 63 / 51
+		// A zero bitrate turns off the bitrate cap, leaving pure constant quality
+		kwargs["b:v"] = "0"
+	default:
+		kwargs["crf"] = p.quality
+		delete(kwargs, "b:v")
+	}
+}
+
+// SetEncodePreset selects the EncoderPresets tier used by format-based encodes.
+// Empty means DefaultEncodePreset.
+func (p *Processor) SetEncodePreset(preset string) {
+	p.encodePreset = preset
+}
+
+// EncoderPreset returns the encoder settings of the selected tier for settings
+func (p *Processor) EncoderPreset(settings CodecSettings) ffmpeg.KwArgs {
+	preset := p.encodePreset
+	if preset == "" {
+		preset = DefaultEncodePreset
+	}
+	return settings.EncoderPresets[preset]
+}
+
+// SetContext makes every following encode stop, killing ffmpeg, once ctx is done
+func (p *Processor) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
 // GetVideoMetadata retrieves metadata about a video file
 func GetVideoMetadata(inputPath string) (*VideoMetadata, error) {
 	probe, err := ffmpeg.Probe(inputPath)
@@ -120,11 +499,18 @@ func GetVideoMetadata(inputPath string) (*VideoMetadata, error) {
 	}
 
 	var videoStream map[string]interface{}
+	var audioStream map[string]interface{}
 	for _, stream := range streams {
 		s := stream.(map[string]interface{})
-		if s["codec_type"].(string) == "video" {
-			videoStream = s
-			break
+		switch s["codec_type"].(string) {
+		case "video":
+			if videoStream == nil {
+				videoStream = s
+			}
+		case "audio":
+			if audioStream == nil {
+				audioStream = s
+			}
 		}
 	}
 
@@ -156,17 +542,7 @@ func GetVideoMetadata(inputPath string) (*VideoMetadata, error) {
 	if duration == 0 {
 		if nbFrames, ok := videoStream["nb_frames"].(string); ok {
 			if frames, err := strconv.ParseFloat(nbFrames, 64); err == nil {
-				var frameRate float64
-				if rFrameRate, ok := videoStream["r_frame_rate"].(string); ok {
-					if nums := strings.Split(rFrameRate, "/"); len(nums) == 2 {
-						num, err1 := strconv.ParseFloat(nums[0], 64)
-						den, err2 := strconv.ParseFloat(nums[1], 64)
-						if err1 == nil && err2 == nil && den != 0 {
-							frameRate = num / den
-						}
-					}
-				}
-				if frameRate > 0 {
+				if frameRate := parseFrameRate(videoStream); frameRate > 0 {
 					duration = frames / frameRate
 				}
 			}
@@ -181,12 +557,49 @@ func GetVideoMetadata(inputPath string) (*VideoMetadata, error) {
 	height := int(videoStream["height"].(float64))
 	codec := videoStream["codec_name"].(string)
 
-	return &VideoMetadata{
-		Duration: duration,
-		Width:    width,
-		Height:   height,
-		Codec:    codec,
-	}, nil
+	metadata := &VideoMetadata{
+		Duration:  duration,
+		Width:     width,
+		Height:    height,
+		Codec:     codec,
+		FrameRate: parseFrameRate(videoStream),
+		Rotation:  parseRotation(videoStream),
+		HasAudio:  audioStream != nil,
+	}
+	if audioStream != nil {
+		metadata.AudioCodec, _ = audioStream["codec_name"].(string)
+		if bitrate, ok := audioStream["bit_rate"].(string); ok {
+			metadata.AudioBitrate, _ = strconv.Atoi(bitrate)
+		}
+		if rate, ok := audioStream["sample_rate"].(string); ok {
+			metadata.AudioRate, _ = strconv.Atoi(rate)
+		}
+		if channels, ok := audioStream["channels"].(float64); ok {
+			metadata.AudioChannels = int(channels)
+		}
+	}
+	parseColorInfo(videoStream, metadata)
+
+	return metadata, nil
+}
+
+// parseFrameRate returns the frame rate of a probed video stream, zero when
+// ffprobe didn't report it
+func parseFrameRate(videoStream map[string]interface{}) float64 {
+	rFrameRate, ok := videoStream["r_frame_rate"].(string)
+	if !ok {
+		return 0
+	}
+	nums := strings.Split(rFrameRate, "/")
+	if len(nums) != 2 {
+		return 0
+	}
+	num, err1 := strconv.ParseFloat(nums[0], 64)
+	den, err2 := strconv.ParseFloat(nums[1], 64)
+	if err1 != nil || err2 != nil || den == 0 {
+		return 0
+	}
+	return num / den
 }
 
 func (p *Processor) ProcessForPlatform(inputPath, outputPath string, plat platform.Platform, startTime float64, duration int) error {
@@ -204,6 +617,163 @@ func (p *Processor) ProcessForPlatform(inputPath, outputPath string, plat platfo
 	return p.processNormalVideo(inputPath, outputPath, plat, startTime, duration, metadata, probe)
 }
 
+// ProcessGeneric cuts a segment without any platform constraints. The segment is
+// transcoded with the output format's codec settings, or stream copied when
+// streamCopy is set (cuts then snap to the nearest keyframe).
+func (p *Processor) ProcessGeneric(inputPath, outputPath, outputFormat string, startTime float64, duration int, streamCopy bool) error {
+	inputKwargs := ffmpeg.KwArgs{
+		"ss": startTime,
+	}
+	if duration > 0 {
+		inputKwargs["t"] = duration
+	}
+
+	if IsAnimation(outputFormat) && !streamCopy {
+		return p.ProcessAnimation(inputPath, outputPath, outputFormat, startTime, duration)
+	}
+
+	outputKwargs := ffmpeg.KwArgs{
+		"c": "copy",
+	}
+	p.audioArgs(outputKwargs)
+	if !streamCopy {
+		outputKwargs = GenericOutputArgs(outputFormat)
+		for k, v := range p.EncoderPreset(GetCodecSettings(outputFormat)) {
+			outputKwargs[k] = v
+		}
+
+		// Only needed for HDR handling, so a failed probe isn't fatal here
+		source, _ := GetVideoMetadata(inputPath)
+		outputKwargs = p.OutputArgs(outputKwargs, source)
+		p.audioCopyArgs(outputKwargs, source)
+		if filter := p.WithTonemap(p.withTimecode(p.withSharpen(p.withFrameRate("", source, nil)), startTime, nil), source); filter != "" {
+			outputKwargs["vf"] = filter
+		}
+		if IsDASH(outputFormat) {
+			dashArgs(outputKwargs, outputPath)
+		}
+	}
+
+	if p.verbose {
+		log.Printf("Processing video without a target platform (format=%s, copy=%t)\n", outputFormat, streamCopy)
+	}
+
+	err := p.Run(ffmpeg.Input(inputPath, inputKwargs).Output(outputPath, outputKwargs), float64(duration))
+	if err != nil {
+		return fmt.Errorf("failed to process video: %v", err)
+	}
+
+	return nil
+}
+
+// ProcessRendition cuts a segment scaled so its short side is height, keeping
+// the aspect ratio, and encodes it at videoBitrate with the output format's
+// codecs. audioBitrate may be empty to use the encoder's default.
+func (p *Processor) ProcessRendition(inputPath, outputPath, outputFormat string, startTime float64, duration int, height int, videoBitrate, audioBitrate string) error {
+	inputKwargs := ffmpeg.KwArgs{
+		"ss": startTime,
+	}
+	if duration > 0 {
+		inputKwargs["t"] = duration
+	}
+
+	outputKwargs := GenericOutputArgs(outputFormat)
+	for k, v := range p.EncoderPreset(GetCodecSettings(outputFormat)) {
+		outputKwargs[k] = v
+	}
+
+	// Each rung has to stay near its bitrate for players to switch between them
+	delete(outputKwargs, "crf")
+	outputKwargs["b:v"] = videoBitrate
+	outputKwargs["maxrate"] = videoBitrate
+	outputKwargs["bufsize"] = fmt.Sprintf("%dk", 2*bitsPerSecond(videoBitrate)/1000)
+	if audioBitrate != "" {
+		outputKwargs["b:a"] = audioBitrate
+	}
+
+	// Only needed for HDR handling, so a failed probe isn't fatal here
+	source, _ := GetVideoMetadata(inputPath)
+	outputKwargs = p.OutputArgs(outputKwargs, source)
+	p.audioCopyArgs(outputKwargs, source)
+	scale := fmt.Sprintf("scale=-2:%d", height)
+	if source != nil && source.Height > source.Width {
+		scale = fmt.Sprintf("scale=%d:-2", height)
+	}
+	outputKwargs["vf"] = p.WithTonemap(p.withTimecode(p.withSharpen(p.withFrameRate(scale, source, nil)), startTime, nil), source)
+
+	if p.verbose {
+		log.Printf("Encoding %dp rendition at %s (format=%s)\n", height, videoBitrate, outputFormat)
+	}
+
+	input := ffmpeg.Input(inputPath, inputKwargs)
+	err := p.Encode([]*ffmpeg.Stream{input}, outputPath, outputKwargs, float64(duration))
+	if err != nil {
+		return fmt.Errorf("failed to encode rendition: %v", err)
+	}
+
+	return nil
+}
+
+// GenericOutputArgs returns constant-quality encoder settings for the output format
+func GenericOutputArgs(outputFormat string) ffmpeg.KwArgs {
+	codecSettings := GetCodecSettings(outputFormat)
+
+	// A zero CRF means lossless for x264, which is never what a generic cut wants
+	crf := codecSettings.DefaultCRF
+	if crf == 0 {
+		crf = config.MinCRF
+	}
+
+	outputKwargs := ffmpeg.KwArgs{
+		"c:v":     codecSettings.VideoCodec,
+		"c:a":     codecSettings.AudioCodec,
+		"crf":     crf,
+		"pix_fmt": "yuv420p",
+		"threads": GetOptimalThreadCount(),
+	}
+	switch codecSettings.VideoCodec {
+	case "libvpx-vp9":
+		// VP9 only runs in constant quality mode with a zero target bitrate
+		outputKwargs["b:v"] = "0"
+	case "libsvtav1":
+		// Same for libaom-av1, which replaces SVT-AV1 when it's missing. The
+		// default preset favours speed over the compression AV1 is chosen for.
+		outputKwargs["b:v"] = "0"
+		outputKwargs["preset"] = 8
+	case "prores_ks":
+		// ProRes has no CRF, the profile sets the quality, and is 4:2:2 10-bit.
+		// The Apple vendor ID keeps picky NLEs from rejecting the file.
+		delete(outputKwargs, "crf")
+		outputKwargs["pix_fmt"] = "yuv422p10le"
+		outputKwargs["vendor"] = "apl0"
+	}
+	if codecSettings.ContainerFormat == "mp4" || codecSettings.ContainerFormat == "mov" {
+		outputKwargs["movflags"] = "+faststart"
+	}
+
+	return outputKwargs
+}
+
+// PlatformOutputArgs returns the encoder settings for re-encoding assembled
+// outputs, falling back to the output format's settings when plat is nil
+func PlatformOutputArgs(plat platform.Platform, outputFormat string) ffmpeg.KwArgs {
+	if plat == nil {
+		return GenericOutputArgs(outputFormat)
+	}
+
+	return ffmpeg.KwArgs{
+		"c:v":        plat.GetVideoCodec(),
+		"c:a":        plat.GetAudioCodec(),
+		"b:v":        plat.GetVideoBitrate(),
+		"b:a":        plat.GetAudioBitrate(),
+		"pix_fmt":    "yuv420p",
+		"threads":    GetOptimalThreadCount(),
+		"movflags":   "+faststart",
+		"g":          60,
+		"keyint_min": 30,
+	}
+}
+
 func (p *Processor) processNormalVideo(
 	inputPath,
 	outputPath string,
@@ -249,7 +819,7 @@ func (p *Processor) processNormalVideo(
 	scaleHeight = scaleHeight - (scaleHeight % 2)
 
 	// Determine platform bitrate
-	platformBitrate := extractBitrateValue(plat.GetVideoBitrate()) * 1000000 // Convert to bps
+	platformBitrate := bitsPerSecond(plat.GetVideoBitrate())
 	targetBitrate := platformBitrate
 
 	// If we have the input bitrate, use it
@@ -311,6 +881,7 @@ func (p *Processor) processNormalVideo(
 		"keyint_min": 30,
 	}
 
+	filterComplex = p.WithTonemap(p.withTimecode(p.withSharpen(p.withFrameRate(filterComplex, metadata, plat)), startTime, plat), metadata)
 	if filterComplex != "" {
 		outputKwargs["filter_complex"] = filterComplex
 	}
@@ -347,10 +918,9 @@ func (p *Processor) processNormalVideo(
 		log.Printf("Filter complex: %s\n", filterComplex)
 	}
 
-	err = stream.Output(outputPath, outputKwargs).
-		OverWriteOutput().
-		ErrorToStdOut().
-		Run()
+	outputKwargs = p.OutputArgs(outputKwargs, metadata)
+	p.audioCopyArgs(outputKwargs, metadata)
+	err = p.EncodeWithinSize([]*ffmpeg.Stream{stream}, outputPath, outputKwargs, expectedDuration(metadata, startTime, duration), plat.GetMaxFileSize())
 
 	if err != nil {
 		return fmt.Errorf("failed to process video: %v", err)
@@ -373,6 +943,16 @@ func (p *Processor) processNormalVideo(
 
 // Helper functions
 
+// expectedDuration returns the length of the segment starting at startTime,
+// where a zero duration means the rest of the input
+func expectedDuration(metadata *VideoMetadata, startTime float64, duration int) float64 {
+	remaining := metadata.Duration - startTime
+	if duration > 0 && float64(duration) < remaining {
+		return float64(duration)
+	}
+	return math.Max(0, remaining)
+}
+
 func (p *Processor) calculateOptimalDimensions(srcWidth, srcHeight int, targetDims VideoDimensions) VideoDimensions {
 	// Determine if source is portrait or landscape
 	srcIsPortrait := srcHeight > srcWidth
@@ -429,34 +1009,39 @@ func GetOptimalThreadCount() int {
 	return int(math.Max(1, float64(cpuCount)*0.75))
 }
 
-func extractBitrateValue(bitrate string) int {
-	// Remove the 'M' or 'k' suffix and convert to number
-	value := strings.TrimRight(bitrate, "Mk")
-	number, err := strconv.Atoi(value)
-	if err != nil {
-		return 2 // Default to 2M if parsing fails
-	}
-
-	if strings.HasSuffix(bitrate, "M") {
-		return number
-	} else if strings.HasSuffix(bitrate, "k") {
-		return number / 1024
-	}
+// minSizeTargetBitrate is the lowest video bitrate, in bits per second, used to
+// hit a target size before the result stops being watchable
+const minSizeTargetBitrate = 200000
 
-	return number
+// sizeTargetBitrate returns the video bitrate, in bits per second, that fits
+// duration seconds of video plus audio at audioBitrate into targetSize bytes
+func sizeTargetBitrate(targetSize int64, duration float64, audioBitrate int) int {
+	// Keep a few percent back for the container overhead
+	totalBits := float64(targetSize) * 8 * 0.97
+	return int(totalBits/duration) - audioBitrate
 }
 
-func reduceBitrate(originalBitrate string) string {
-	value := extractBitrateValue(originalBitrate)
-	reducedValue := int(float64(value) * 0.75) // Reduce by 25%
+// bitsPerSecond parses an ffmpeg bitrate such as "128k" or "2M"
+func bitsPerSecond(bitrate string) int {
+	multiplier := 1
+	switch {
+	case strings.HasSuffix(bitrate, "M"):
+		multiplier = 1000000
+	case strings.HasSuffix(bitrate, "k"):
+		multiplier = 1000
+	}
 
-	if strings.HasSuffix(originalBitrate, "M") {
-		return fmt.Sprintf("%dM", reducedValue)
-	} else if strings.HasSuffix(originalBitrate, "k") {
-		return fmt.Sprintf("%dk", reducedValue)
+	value, err := strconv.ParseFloat(strings.TrimRight(bitrate, "Mk"), 64)
+	if err != nil {
+		return 0
 	}
+	return int(value * float64(multiplier))
+}
 
-	return fmt.Sprintf("%d", reducedValue)
+// reduceBitrate lowers an ffmpeg bitrate such as "2M" by 25%
+func reduceBitrate(originalBitrate string) string {
+	reduced := bitsPerSecond(originalBitrate) * 3 / 4
+	return fmt.Sprintf("%dk", reduced/1000)
 }
 
 // CreateConcatFilter creates a filter for concatenating multiple video streams
@@ -539,6 +1124,7 @@ func (p *Processor) OptimizeVideo(
 	targetSize int64,
 	plat platform.Platform,
 	outputFormat string,
+	fps int,
 ) error {
 	metadata, err := GetVideoMetadata(inputPath)
 	if err != nil {
@@ -575,7 +1161,7 @@ func (p *Processor) OptimizeVideo(
 	scaleHeight = scaleHeight - (scaleHeight % 2)
 	// Calculate target bitrate based on size and duration
 
-	platformBitrate := extractBitrateValue(plat.GetVideoBitrate()) * 1000000 // Convert to bps
+	platformBitrate := bitsPerSecond(plat.GetVideoBitrate())
 	targetBitrate := platformBitrate
 
 	probe, err := ffmpeg.Probe(inputPath)
@@ -603,14 +1189,30 @@ func (p *Processor) OptimizeVideo(
 		*/
 	}
 
-	// Convert targetBitrate to ffmpeg format
-	var bitrateStr string
-	if targetBitrate >= 1000000 {
-		bitrateStr = fmt.Sprintf("%dM", targetBitrate/1000000)
-	} else {
-		bitrateStr = fmt.Sprintf("%dk", targetBitrate/1000)
+	// Fit the video into targetSize, leaving room for the audio
+	audioBitrate := bitsPerSecond(plat.GetAudioBitrate())
+	sizeLimited := false
+	if targetSize > 0 && metadata.Duration > 0 {
+		sizeBitrate := sizeTargetBitrate(targetSize, metadata.Duration, audioBitrate)
+		if sizeBitrate < minSizeTargetBitrate {
+			log.Printf("Warning: %s can't fit in %d bytes at a usable bitrate, encoding at %d bps\n",
+				inputPath, targetSize, minSizeTargetBitrate)
+			sizeBitrate = minSizeTargetBitrate
+		}
+		if sizeBitrate < targetBitrate {
+			if p.verbose {
+				log.Printf("Lowering target bitrate from %d to %d bps to fit %d bytes\n",
+					targetBitrate, sizeBitrate, targetSize)
+			}
+			targetBitrate = sizeBitrate
+			sizeLimited = true
+		}
 	}
 
+	// Convert targetBitrate to ffmpeg format, in k so a size limited 2.9M
+	// doesn't round down to 2M and undershoot the target by a third
+	bitrateStr := fmt.Sprintf("%dk", targetBitrate/1000)
+
 	// Build filter string
 	var filterComplex string
 	if scaleWidth == maxWidth && scaleHeight == maxHeight {
@@ -638,20 +1240,36 @@ func (p *Processor) OptimizeVideo(
 		"keyint_min": 30,
 	}
 
+	outputKwargs["b:a"] = plat.GetAudioBitrate()
+	if sizeLimited {
+		// Cap the peaks too, an average alone still overshoots on short clips
+		outputKwargs["maxrate"] = bitrateStr
+		outputKwargs["bufsize"] = bitrateStr
+	}
+
+	// Cells of one template share a frame rate so they don't judder or drift
+	// apart once stacked
+	if fps > 0 {
+		if p.verbose && metadata.FrameRate > 0 {
+			log.Printf("Normalizing %s from %.3g to %d fps\n", inputPath, metadata.FrameRate, fps)
+		} else if p.verbose {
+			log.Printf("Normalizing %s to %d fps\n", inputPath, fps)
+		}
+		filterComplex = p.FrameRateFilter(fps)
+	}
+
+	filterComplex = p.WithTonemap(filterComplex, metadata)
 	if filterComplex != "" {
 		outputKwargs["filter_complex"] = filterComplex
 	}
 
 	// Apply format-specific encoder settings
-	for k, v := range codecSettings.EncoderPresets["balanced"] {
+	for k, v := range p.EncoderPreset(codecSettings) {
 		outputKwargs[k] = v
 	}
 
 	stream := ffmpeg.Input(inputPath)
-	err = stream.Output(outputPath, outputKwargs).
-		OverWriteOutput().
-		ErrorToStdOut().
-		Run()
+	err = p.Encode([]*ffmpeg.Stream{stream}, outputPath, p.OutputArgs(outputKwargs, metadata), metadata.Duration)
 
 	if err != nil {
 		return errors.Wrap(err, "failed to optimize video")
@@ -660,7 +1278,7 @@ func (p *Processor) OptimizeVideo(
 	return nil
 }
 
-func ApplyPlatformCrop(
+func (p *Processor) ApplyPlatformCrop(
 	inputPath,
 	outputPath string,
 	plat platform.Platform,
@@ -670,11 +1288,19 @@ func ApplyPlatformCrop(
 	maxWidth int,
 	maxHeight int,
 	probe string,
-	verbose bool,
 ) error {
+	verbose := p.verbose
 	// For landscape videos that need to be portrait, we'll center crop
+	// unless smart reframing follows the subject
 	cropWidth := (metadata.Height * 9) / 16 // Assuming 9:16 aspect ratio for portrait
-	cropX := (metadata.Width - cropWidth) / 2
+	cropX := strconv.Itoa((metadata.Width - cropWidth) / 2)
+	if p.reframe == ReframeSmart {
+		x, err := p.smartCropX(inputPath, metadata, cropWidth)
+		if err != nil {
+			return errors.Wrap(err, "failed to reframe video")
+		}
+		cropX = "'" + x + "'"
+	}
 
 	// Build the filter chain - crop first, then scale
 	/*
@@ -686,14 +1312,16 @@ func ApplyPlatformCrop(
 		)
 	*/
 	filterComplex := fmt.Sprintf(
-		"crop=%d:%d:%d:0",
+		"crop=%d:%d:%s:0",
 		cropWidth, metadata.Height, // crop dimensions
 		cropX, // crop position
 	)
 
+	filterComplex = p.WithTonemap(filterComplex, metadata)
+
 	if verbose {
-		log.Printf("Forcing portrait mode. Cropping %dx%d from center of %dx%d video\n",
-			cropWidth, metadata.Height, metadata.Width, metadata.Height)
+		log.Printf("Forcing portrait mode. Cropping %dx%d from %dx%d video (%s reframe)\n",
+			cropWidth, metadata.Height, metadata.Width, metadata.Height, cmp.Or(p.reframe, ReframeCenter))
 	}
 
 	inputBitrate, err := getBitrate(metadata, probe)
@@ -702,7 +1330,7 @@ func ApplyPlatformCrop(
 	}
 
 	// Determine platform bitrate
-	platformBitrate := extractBitrateValue(plat.GetVideoBitrate()) * 1000000 // Convert to bps
+	platformBitrate := bitsPerSecond(plat.GetVideoBitrate())
 	targetBitrate := platformBitrate
 
 	// If we have the input bitrate, use it as a ceiling
@@ -765,10 +1393,7 @@ func ApplyPlatformCrop(
 		outputKwargs["lag-in-frames"] = 25
 	}
 
-	err = stream.Output(outputPath, outputKwargs).
-		OverWriteOutput().
-		ErrorToStdOut().
-		Run()
+	err = p.Encode([]*ffmpeg.Stream{stream}, outputPath, p.OutputArgs(outputKwargs, metadata), expectedDuration(metadata, startTime, duration))
 
 	if err != nil {
 		return fmt.Errorf("failed to process video: %v", err)
@@ -0,0 +1,56 @@
+package ffmpeg
+
+import "testing"
+
+func TestSizeTargetBitrate(t *testing.T) {
+	tests := []struct {
+		name         string
+		targetSize   int64
+		duration     float64
+		audioBitrate int
+		want         int
+	}{
+		{"10MB minute", 10000000, 60, 128000, 1165333},
+		{"no audio", 10000000, 60, 0, 1293333},
+		{"8MB at 2.9M", 8000000, 21.4, 0, 2900934},
+		{"audio takes it all", 100000, 60, 128000, -115067},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sizeTargetBitrate(tt.targetSize, tt.duration, tt.audioBitrate)
+			if got != tt.want {
+				t.Errorf("sizeTargetBitrate(%d, %g, %d) = %d, want %d",
+					tt.targetSize, tt.duration, tt.audioBitrate, got, tt.want)
+			}
+
+			// Whatever fits must also fit once the container overhead is added
+			if got > 0 {
+				size := float64(got+tt.audioBitrate) * tt.duration / 8
+				if size > float64(tt.targetSize) {
+					t.Errorf("%d bps for %gs comes to %.0f bytes, over %d", got, tt.duration, size, tt.targetSize)
+				}
+			}
+		})
+	}
+}
+
+func TestBitsPerSecond(t *testing.T) {
+	tests := []struct {
+		bitrate string
+		want    int
+	}{
+		{"128k", 128000},
+		{"2M", 2000000},
+		{"2.5M", 2500000},
+		{"96000", 96000},
+		{"", 0},
+		{"fast", 0},
+	}
+
+	for _, tt := range tests {
+		if got := bitsPerSecond(tt.bitrate); got != tt.want {
+			t.Errorf("bitsPerSecond(%q) = %d, want %d", tt.bitrate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package ffmpeg
+
+import (
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+)
+
+// SetText sets the font of the text the processor draws
+func (p *Processor) SetText(opts config.TextOptions) {
+	p.text = opts
+}
+
+// FontOption returns the drawtext option selecting the font of opts, starting
+// with its separator, or "" for the default font
+func FontOption(opts config.TextOptions) string {
+	switch {
+	case opts.FontFile != "":
+		return ":fontfile=" + quoteOption(opts.FontFile)
+	case opts.Font != "":
+		return ":font=" + quoteOption(opts.Font)
+	}
+	return ""
+}
+
+// TextFileOption returns the drawtext options drawing the contents of the
+// file at path verbatim. Unlike text=, nothing in the file needs escaping, so
+// any Unicode text, quotes, colons or percent signs come out as written.
+func TextFileOption(path string) string {
+	return "textfile=" + quoteOption(path) + ":expansion=none"
+}
+
+// quoteOption quotes a filter option value so separators in it are taken
+// literally
+func quoteOption(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// ASSOption returns the ass filter options rendering the script at path,
+// looking for fonts in fontsDir too when it's set
+func ASSOption(path, fontsDir string) string {
+	option := "filename=" + quoteOption(path)
+	if fontsDir != "" {
+		option += ":fontsdir=" + quoteOption(fontsDir)
+	}
+	return option
+}
@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// SetFrameRate converts every encode to fps frames per second, or only
+// sources faster than the platform plays back when zero. Smooth conversions
+// interpolate the motion between frames instead of dropping or repeating
+// them, which is much slower.
+func (p *Processor) SetFrameRate(fps int, smooth bool) {
+	p.frameRate = fps
+	p.smoothFrameRate = smooth
+}
+
+// targetFrameRate returns the frame rate a source is converted to, or zero to
+// keep its own
+func (p *Processor) targetFrameRate(source *VideoMetadata, plat platform.Platform) int {
+	if p.frameRate > 0 {
+		return p.frameRate
+	}
+	if plat != nil && source != nil && source.FrameRate > float64(plat.GetMaxFrameRate()) {
+		return plat.GetFrameRate()
+	}
+	return 0
+}
+
+// FrameRateFilter returns the filter converting video to fps frames per
+// second, interpolating when the conversion is smooth
+func (p *Processor) FrameRateFilter(fps int) string {
+	if p.smoothFrameRate {
+		return fmt.Sprintf("minterpolate=fps=%d:mi_mode=mci", fps)
+	}
+	return fmt.Sprintf("fps=%d", fps)
+}
+
+// withFrameRate returns filter with the frame rate conversion of source
+// prepended when it needs one, so fewer frames go through the rest
+func (p *Processor) withFrameRate(filter string, source *VideoMetadata, plat platform.Platform) string {
+	fps := p.targetFrameRate(source, plat)
+	if fps == 0 {
+		return filter
+	}
+
+	if p.verbose && source != nil && source.FrameRate > 0 {
+		log.Printf("Converting from %.3g to %d fps\n", source.FrameRate, fps)
+	}
+	if filter == "" {
+		return p.FrameRateFilter(fps)
+	}
+	return p.FrameRateFilter(fps) + "," + filter
+}
+
+// frameRateStream converts a video stream of source to the target frame rate
+// when it needs one
+func (p *Processor) frameRateStream(video *ffmpeg.Stream, source *VideoMetadata) *ffmpeg.Stream {
+	fps := p.targetFrameRate(source, nil)
+	if fps == 0 {
+		return video
+	}
+	name, args, _ := strings.Cut(p.FrameRateFilter(fps), "=")
+	return video.Filter(name, ffmpeg.Args{args})
+}
@@ -0,0 +1,252 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// DefaultTonemapOperator is used when converting HDR to SDR without an operator set
+const DefaultTonemapOperator = "hable"
+
+// TonemapOperators are the operators accepted by ffmpeg's tonemap filter
+var TonemapOperators = []string{"none", "clip", "linear", "gamma", "reinhard", "hable", "mobius"}
+
+// IsTonemapOperator reports whether op is one of TonemapOperators
+func IsTonemapOperator(op string) bool {
+	return slices.Contains(TonemapOperators, op)
+}
+
+// SetPreserveHDR makes encodes of 10-bit sources keep a 10-bit pixel format and
+// carry over the source's color tagging and HDR mastering metadata
+func (p *Processor) SetPreserveHDR(preserve bool) {
+	p.preserveHDR = preserve
+}
+
+// SetTonemapOperator sets the operator used to convert HDR sources to SDR.
+// Empty means DefaultTonemapOperator.
+func (p *Processor) SetTonemapOperator(op string) {
+	p.tonemapOperator = op
+}
+
+// IsHDR reports whether the video uses an HDR transfer function (PQ or HLG)
+func (m *VideoMetadata) IsHDR() bool {
+	return m.ColorTransfer == "smpte2084" || m.ColorTransfer == "arib-std-b67"
+}
+
+// IsHighBitDepth reports whether the video has more than 8 bits per sample
+func (m *VideoMetadata) IsHighBitDepth() bool {
+	return m.BitDepth > 8
+}
+
+// colorArgs tags kwargs with the output's color properties: BT.709 when source
+// is tone mapped to SDR, or the source's own with a 10-bit pixel format when
+// preserving HDR. kwargs is modified in place.
+func (p *Processor) colorArgs(kwargs ffmpeg.KwArgs, source *VideoMetadata) {
+	if p.tonemaps(source) {
+		// The tonemap chain outputs BT.709, tag it so players don't guess
+		kwargs["color_primaries"] = "bt709"
+		kwargs["color_trc"] = "bt709"
+		kwargs["colorspace"] = "bt709"
+		return
+	}
+	if !p.preserveHDR || source == nil || (!source.IsHighBitDepth() && !source.IsHDR()) {
+		return
+	}
+
+	if kwargs["pix_fmt"] == "yuv420p" {
+		kwargs["pix_fmt"] = "yuv420p10le"
+	}
+
+	for key, value := range map[string]string{
+		"color_primaries": source.ColorPrimaries,
+		"color_trc":       source.ColorTransfer,
+		"colorspace":      source.ColorSpace,
+		"color_range":     source.ColorRange,
+	} {
+		if value != "" && value != "unknown" {
+			kwargs[key] = value
+		}
+	}
+
+	switch kwargs["c:v"] {
+	case "libx264":
+		// The high profile is 8-bit only
+		if _, ok := kwargs["profile:v"]; ok {
+			kwargs["profile:v"] = "high10"
+		}
+	case "libx265":
+		if _, ok := kwargs["profile:v"]; ok {
+			kwargs["profile:v"] = "main10"
+		}
+		// Only x265 writes the mastering display and light level SEI from
+		// encoder options; the other encoders keep just the color tags
+		if source.IsHDR() {
+			params := []string{"hdr10-opt=1", "repeat-headers=1"}
+			if source.MasteringDisplay != "" {
+				params = append(params, "master-display="+source.MasteringDisplay)
+			}
+			if source.MaxCLL != "" {
+				params = append(params, "max-cll="+source.MaxCLL)
+			}
+			kwargs["x265-params"] = strings.Join(params, ":")
+		}
+	}
+}
+
+// tonemaps reports whether encodes of source convert it from HDR to SDR
+func (p *Processor) tonemaps(source *VideoMetadata) bool {
+	return source != nil && source.IsHDR() && !p.preserveHDR
+}
+
+// TonemapFilter returns the filter chain that converts source from HDR to
+// 8-bit SDR BT.709, or "" when source isn't HDR or HDR is being preserved.
+// Without it the plain conversion to yuv420p comes out grey and washed out.
+func (p *Processor) TonemapFilter(source *VideoMetadata) string {
+	if !p.tonemaps(source) {
+		return ""
+	}
+
+	op := p.tonemapOperator
+	if op == "" {
+		op = DefaultTonemapOperator
+	}
+
+	// Tone mapping works on linear light, so linearize, convert the primaries
+	// and map the range, then go back to BT.709's transfer and matrix
+	return strings.Join([]string{
+		"zscale=t=linear:npl=100",
+		"format=gbrpf32le",
+		"zscale=p=bt709",
+		"tonemap=tonemap=" + op + ":desat=0",
+		"zscale=t=bt709:m=bt709:r=tv",
+		"format=yuv420p",
+	}, ",")
+}
+
+// WithTonemap returns filter with the tonemap chain for source, if any,
+// placed in front of it
+func (p *Processor) WithTonemap(filter string, source *VideoMetadata) string {
+	tonemap := p.TonemapFilter(source)
+	switch {
+	case tonemap == "":
+		return filter
+	case filter == "":
+		return tonemap
+	}
+	return tonemap + "," + filter
+}
+
+// tonemapStream applies the tonemap chain for source, if any, to a video stream
+func (p *Processor) tonemapStream(video *ffmpeg.Stream, source *VideoMetadata) *ffmpeg.Stream {
+	tonemap := p.TonemapFilter(source)
+	if tonemap == "" {
+		return video
+	}
+
+	for _, filter := range strings.Split(tonemap, ",") {
+		name, args, _ := strings.Cut(filter, "=")
+		video = video.Filter(name, ffmpeg.Args{args})
+	}
+	return video
+}
+
+// parseColorInfo fills the pixel format, bit depth, color properties and HDR
+// mastering metadata of metadata from an ffprobe video stream
+func parseColorInfo(stream map[string]interface{}, metadata *VideoMetadata) {
+	str := func(key string) string {
+		s, _ := stream[key].(string)
+		return s
+	}
+
+	metadata.PixelFormat = str("pix_fmt")
+	metadata.ColorPrimaries = str("color_primaries")
+	metadata.ColorTransfer = str("color_transfer")
+	metadata.ColorSpace = str("color_space")
+	metadata.ColorRange = str("color_range")
+
+	metadata.BitDepth, _ = strconv.Atoi(str("bits_per_raw_sample"))
+	if metadata.BitDepth == 0 {
+		metadata.BitDepth = pixelFormatBitDepth(metadata.PixelFormat)
+	}
+
+	sideData, _ := stream["side_data_list"].([]interface{})
+	for _, entry := range sideData {
+		data, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch data["side_data_type"] {
+		case "Mastering display metadata":
+			metadata.MasteringDisplay = masterDisplayParam(data)
+		case "Content light level metadata":
+			maxContent, ok1 := data["max_content"].(float64)
+			maxAverage, ok2 := data["max_average"].(float64)
+			if ok1 && ok2 {
+				metadata.MaxCLL = fmt.Sprintf("%d,%d", int(maxContent), int(maxAverage))
+			}
+		}
+	}
+}
+
+// pixelFormatBitDepth guesses the bit depth from a pixel format name such as
+// yuv420p10le or p010le, defaulting to 8
+func pixelFormatBitDepth(pixFmt string) int {
+	switch {
+	case pixFmt == "":
+		return 0
+	case strings.Contains(pixFmt, "p10"), strings.HasPrefix(pixFmt, "p010"), strings.HasPrefix(pixFmt, "x2"):
+		return 10
+	case strings.Contains(pixFmt, "p12"), strings.HasPrefix(pixFmt, "p012"):
+		return 12
+	case strings.Contains(pixFmt, "p16"), strings.HasPrefix(pixFmt, "p016"):
+		return 16
+	}
+	return 8
+}
+
+// masterDisplayParam converts ffprobe's mastering display side data into
+// x265's master-display syntax, in units of 0.00002 for the chromaticity
+// coordinates and 0.0001 cd/m2 for the luminance. Returns "" when a value is missing.
+func masterDisplayParam(data map[string]interface{}) string {
+	scaled := func(key string, unit float64) (int, bool) {
+		s, ok := data[key].(string)
+		if !ok {
+			return 0, false
+		}
+		num, den, ok := strings.Cut(s, "/")
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := strconv.ParseFloat(den, 64)
+		if !ok || err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return int(n/d*unit + 0.5), true
+	}
+
+	var values []int
+	for _, key := range []string{
+		"green_x", "green_y", "blue_x", "blue_y", "red_x", "red_y",
+		"white_point_x", "white_point_y",
+	} {
+		v, ok := scaled(key, 50000)
+		if !ok {
+			return ""
+		}
+		values = append(values, v)
+	}
+	for _, key := range []string{"max_luminance", "min_luminance"} {
+		v, ok := scaled(key, 10000)
+		if !ok {
+			return ""
+		}
+		values = append(values, v)
+	}
+
+	return fmt.Sprintf("G(%d,%d)B(%d,%d)R(%d,%d)WP(%d,%d)L(%d,%d)",
+		values[0], values[1], values[2], values[3], values[4], values[5],
+		values[6], values[7], values[8], values[9])
+}
@@ -0,0 +1,188 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// stderrTailLines is how much of ffmpeg's log is kept for error messages
+// when progress is reported instead of printing it
+const stderrTailLines = 20
+
+// Progress is a snapshot of a running encode, parsed from ffmpeg's -progress output
+type Progress struct {
+	OutTime time.Duration // Position reached in the output
+	Percent float64       // 0-100, or 0 when the output duration is unknown
+	FPS     float64
+	Speed   float64 // Encoding speed as a multiple of real time
+	ETA     time.Duration
+	Done    bool
+}
+
+// SetProgressHandler makes every encode report its progress to fn instead of
+// printing ffmpeg's log. A nil fn restores the log output.
+func (p *Processor) SetProgressHandler(fn func(Progress)) {
+	p.onProgress = fn
+}
+
+// Run executes an ffmpeg output stream, overwriting existing outputs.
+// duration is the expected output length in seconds, used to work out the
+// percentage and ETA; pass 0 when it isn't known.
+func (p *Processor) Run(stream *ffmpeg.Stream, duration float64) error {
+	ctx := p.ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(p.ctx, p.timeout)
+		defer cancel()
+	}
+
+	if p.onProgress == nil {
+		stream.Context = ctx
+		return p.runErr(ctx, stream.OverWriteOutput().ErrorToStdOut().Run())
+	}
+
+	pr, pw := io.Pipe()
+	stderr := &tailBuffer{max: stderrTailLines}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		parseProgress(pr, duration, p.onProgress)
+		// Keep draining so ffmpeg never blocks on a full pipe
+		io.Copy(io.Discard, pr)
+	}()
+
+	stream = stream.GlobalArgs("-progress", "pipe:1", "-nostats").Silent(!p.verbose)
+	stream.Context = ctx
+	err := p.runErr(ctx, stream.OverWriteOutput().WithOutput(pw, stderr).Run())
+	pw.Close()
+	wg.Wait()
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if tail := stderr.String(); tail != "" {
+			return fmt.Errorf("%v\n%s", err, tail)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// runErr reports why an encode was killed in place of the bare kill signal:
+// the processor's context ending, or the encode running into its timeout
+func (p *Processor) runErr(ctx context.Context, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case p.ctx.Err() != nil:
+		return p.ctx.Err()
+	case ctx.Err() != nil:
+		return fmt.Errorf("ffmpeg did not finish within the %s encode timeout, the encoder may have stalled", p.timeout)
+	}
+	return err
+}
+
+// parseProgress reads key=value blocks from ffmpeg's -progress output and
+// reports one Progress per block
+func parseProgress(r io.Reader, duration float64, fn func(Progress)) {
+	var cur Progress
+	start := time.Now()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "out_time_us", "out_time_ms":
+			// Both are in microseconds
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				cur.OutTime = time.Duration(us) * time.Microsecond
+			}
+		case "fps":
+			cur.FPS, _ = strconv.ParseFloat(value, 64)
+		case "speed":
+			cur.Speed, _ = strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+		case "progress":
+			cur.Done = value == "end"
+			if duration > 0 {
+				cur.Percent = min(100, cur.OutTime.Seconds()/duration*100)
+				if cur.Done {
+					cur.Percent = 100
+				}
+
+				remaining := duration - cur.OutTime.Seconds()
+				switch {
+				case cur.Done || remaining <= 0:
+					cur.ETA = 0
+				case cur.Speed > 0:
+					cur.ETA = time.Duration(remaining / cur.Speed * float64(time.Second))
+				case cur.OutTime > 0:
+					// ffmpeg reports N/A speed early on, estimate from wall time instead
+					elapsed := time.Since(start).Seconds()
+					cur.ETA = time.Duration(elapsed / cur.OutTime.Seconds() * remaining * float64(time.Second))
+				}
+			}
+			fn(cur)
+		}
+	}
+}
+
+// tailBuffer keeps the last max lines written to it
+type tailBuffer struct {
+	mu    sync.Mutex
+	max   int
+	lines []string
+	part  bytes.Buffer
+}
+
+func (b *tailBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.part.Write(data)
+	for {
+		line, err := b.part.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			b.part.Reset()
+			b.part.WriteString(line)
+			break
+		}
+		b.lines = append(b.lines, strings.TrimRight(line, "\r\n"))
+		if len(b.lines) > b.max {
+			b.lines = b.lines[len(b.lines)-b.max:]
+		}
+	}
+
+	return len(data), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lines := b.lines
+	if b.part.Len() > 0 {
+		lines = append(lines[:len(lines):len(lines)], b.part.String())
+		if len(lines) > b.max {
+			lines = lines[len(lines)-b.max:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		duration float64
+		want     []Progress
+	}{
+		{
+			name:     "blocks",
+			duration: 10,
+			input: "frame=50\nfps=25.0\nout_time_us=2000000\nspeed=2.0x\nprogress=continue\n" +
+				"out_time_us=10000000\nspeed=2.5x\nprogress=end\n",
+			want: []Progress{
+				{OutTime: 2 * time.Second, Percent: 20, FPS: 25, Speed: 2, ETA: 4 * time.Second},
+				{OutTime: 10 * time.Second, Percent: 100, FPS: 25, Speed: 2.5, Done: true},
+			},
+		},
+		{
+			name:     "out_time_ms is in microseconds",
+			duration: 4,
+			input:    "out_time_ms=1000000\nspeed=1x\nprogress=continue\n",
+			want:     []Progress{{OutTime: time.Second, Percent: 25, Speed: 1, ETA: 3 * time.Second}},
+		},
+		{
+			name:  "unknown duration",
+			input: "out_time_us=3000000\nspeed=1.5x\nprogress=continue\nprogress=end\n",
+			want:  []Progress{{OutTime: 3 * time.Second, Speed: 1.5}, {OutTime: 3 * time.Second, Speed: 1.5, Done: true}},
+		},
+		{
+			name:     "past the duration",
+			duration: 2,
+			input:    "out_time_us=3000000\nspeed=1x\nprogress=continue\n",
+			want:     []Progress{{OutTime: 3 * time.Second, Percent: 100, Speed: 1}},
+		},
+		{
+			name:     "N/A values and noise",
+			duration: 10,
+			input:    "out_time_us=N/A\nout_time_us=-5\nspeed=N/A\nfps=N/A\nnot a key\n  progress=continue  \n",
+			want:     []Progress{{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Progress
+			parseProgress(strings.NewReader(tt.input), tt.duration, func(p Progress) {
+				got = append(got, p)
+			})
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseProgress reported %d updates, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("update %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    int
+		writes []string
+		want   string
+	}{
+		{"empty", 3, nil, ""},
+		{"under max", 3, []string{"a\nb\n"}, "a\nb"},
+		{"keeps the last lines", 2, []string{"a\nb\nc\nd\n"}, "c\nd"},
+		{"lines split across writes", 3, []string{"fir", "st\nsec", "ond\n"}, "first\nsecond"},
+		{"incomplete last line", 2, []string{"a\nb\nlast"}, "b\nlast"},
+		{"carriage returns", 3, []string{"a\r\nb\r\n"}, "a\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &tailBuffer{max: tt.max}
+			for _, w := range tt.writes {
+				n, err := b.Write([]byte(w))
+				if n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Score lines ffmpeg's metric filters log when they finish
+var (
+	vmafScoreRe = regexp.MustCompile(`VMAF score[:=]\s*([\d.]+)`)
+	ssimScoreRe = regexp.MustCompile(`SSIM .*All:([\d.]+)`)
+	psnrScoreRe = regexp.MustCompile(`PSNR .*average:([\d.]+|inf)`)
+)
+
+// maxPSNR stands in for the infinite PSNR of identical frames, which JSON can't hold
+const maxPSNR = 100
+
+// MeasureQuality scores the video of encodedPath against duration seconds of
+// sourcePath from startTime, scaling the source to the encode's size first.
+// A zero duration compares the whole source. VMAF is left at zero when ffmpeg
+// was built without libvmaf.
+func (p *Processor) MeasureQuality(encodedPath, sourcePath string, startTime, duration float64) (*types.QualityScores, error) {
+	encoded, err := GetVideoMetadata(encodedPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get encoded video metadata")
+	}
+
+	inputKwargs := ffmpeg.KwArgs{"ss": startTime}
+	if duration > 0 {
+		inputKwargs["t"] = duration
+	}
+
+	distorted := ffmpeg.Input(encodedPath).Video().
+		Filter("setpts", ffmpeg.Args{"PTS-STARTPTS"})
+	reference := ffmpeg.Input(sourcePath, inputKwargs).Video().
+		Filter("scale", ffmpeg.Args{fmt.Sprintf("%d:%d", encoded.Width, encoded.Height)}, ffmpeg.KwArgs{"flags": "bicubic"}).
+		Filter("setpts", ffmpeg.Args{"PTS-STARTPTS"})
+
+	metrics := []string{"ssim", "psnr"}
+	if HasFilter("libvmaf") {
+		metrics = append(metrics, "libvmaf")
+	} else {
+		warnOnce("libvmaf", "Warning: ffmpeg was built without libvmaf, quality reports only include SSIM and PSNR\n")
+	}
+
+	// The metric filters pass the encode through, so they're chained and only
+	// the source needs a copy per metric
+	referenceCopies := reference.Split()
+	scored := distorted
+	for i, metric := range metrics {
+		scored = ffmpeg.Filter(
+			[]*ffmpeg.Stream{scored, referenceCopies.Get(strconv.Itoa(i))},
+			metric, ffmpeg.Args{},
+		)
+	}
+
+	ctx := p.ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(p.ctx, p.timeout)
+		defer cancel()
+	}
+
+	var stderr bytes.Buffer
+	stream := scored.Output(os.DevNull, ffmpeg.KwArgs{"f": "null"}).
+		GlobalArgs("-nostats").Silent(!p.verbose)
+	stream.Context = ctx
+	if err := p.runErr(ctx, stream.WithErrorOutput(&stderr).Run()); err != nil {
+		return nil, fmt.Errorf("quality analysis failed: %v\n%s", err, lastLines(stderr.String(), stderrTailLines))
+	}
+
+	return parseQualityScores(stderr.String()), nil
+}
+
+// parseQualityScores picks the metric filters' final scores out of ffmpeg's log
+func parseQualityScores(log string) *types.QualityScores {
+	score := func(re *regexp.Regexp) float64 {
+		match := re.FindStringSubmatch(log)
+		if match == nil {
+			return 0
+		}
+		value, _ := strconv.ParseFloat(match[1], 64)
+		if math.IsInf(value, 0) {
+			return maxPSNR
+		}
+		return value
+	}
+
+	return &types.QualityScores{
+		VMAF: score(vmafScoreRe),
+		SSIM: score(ssimScoreRe),
+		PSNR: score(psnrScoreRe),
+	}
+}
+
+// lastLines returns the last n lines of s
+func lastLines(s string, n int) string {
+	tail := &tailBuffer{max: n}
+	tail.Write([]byte(s))
+	return tail.String()
+}
@@ -0,0 +1,242 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Reframe modes of landscape video cropped to portrait: center always crops
+// the middle, smart follows the subject
+const (
+	ReframeCenter = "center"
+	ReframeSmart  = "smart"
+)
+
+// SubjectSample is where the subject of a video is at a point in time
+type SubjectSample struct {
+	Time   float64 // Seconds into the video
+	Center float64 // Horizontal center of the subject as a share of the width, 0 to 1
+}
+
+// SubjectDetector finds the subject of a video over time, for reframing it
+// to a narrower aspect ratio. cropWidth is the width of the crop window in
+// pixels of the video. Detection stops once ctx is done.
+type SubjectDetector interface {
+	DetectSubject(ctx context.Context, inputPath string, metadata *VideoMetadata, cropWidth int) ([]SubjectSample, error)
+}
+
+// SetReframe sets how landscape video is cropped to portrait, with detector
+// finding the subject of smart reframes. A nil detector uses the built-in
+// edge energy heuristic.
+func (p *Processor) SetReframe(mode string, detector SubjectDetector) {
+	p.reframe = mode
+	p.subjectDetector = detector
+}
+
+// Smart reframing samples the video at reframeSampleRate frames a second,
+// scaled down to reframeAnalysisWidth, and averages the subject's position
+// over reframeSmoothing samples so the crop glides rather than jitters
+const (
+	reframeSampleRate    = 2
+	reframeAnalysisWidth = 160
+	reframeSmoothing     = 5
+)
+
+// maxReframeKeyframes bounds the crop path, which ends up in a single filter
+// option
+const maxReframeKeyframes = 300
+
+// edgeDetector finds the subject as the crop window's worth of columns with
+// the most edges, which is where the detail, and usually the subject, is
+type edgeDetector struct {
+	p *Processor
+}
+
+func (d edgeDetector) DetectSubject(ctx context.Context, inputPath string, metadata *VideoMetadata, cropWidth int) ([]SubjectSample, error) {
+	width := reframeAnalysisWidth
+	height := max(int(math.Round(float64(width*metadata.Height)/float64(metadata.Width)/2))*2, 2)
+	window := max(min(cropWidth*width/metadata.Width, width), 1)
+
+	var stdout, stderr bytes.Buffer
+	stream := ffmpeg.Input(inputPath).
+		Filter("fps", ffmpeg.Args{strconv.Itoa(reframeSampleRate)}).
+		Filter("scale", ffmpeg.Args{fmt.Sprintf("%d:%d", width, height)}).
+		Filter("edgedetect", ffmpeg.Args{}).
+		Output("pipe:", ffmpeg.KwArgs{"f": "rawvideo", "pix_fmt": "gray"}).
+		GlobalArgs("-nostats").Silent(!d.p.verbose)
+	stream.Context = ctx
+	if err := d.p.runErr(ctx, stream.WithOutput(&stdout, &stderr).Run()); err != nil {
+		return nil, fmt.Errorf("subject detection failed: %v\n%s", err, lastLines(stderr.String(), stderrTailLines))
+	}
+
+	frames := stdout.Bytes()
+	frameSize := width * height
+	samples := make([]SubjectSample, 0, len(frames)/frameSize)
+	columns := make([]int, width)
+	for i := 0; (i+1)*frameSize <= len(frames); i++ {
+		frame := frames[i*frameSize : (i+1)*frameSize]
+		clear(columns)
+		for j, v := range frame {
+			columns[j%width] += int(v)
+		}
+
+		// Slide the window across, keeping the position with the most edges
+		energy := 0
+		for x := 0; x < window; x++ {
+			energy += columns[x]
+		}
+		best, bestX := energy, 0
+		for x := window; x < width; x++ {
+			energy += columns[x] - columns[x-window]
+			if energy > best {
+				best, bestX = energy, x-window+1
+			}
+		}
+
+		center := 0.5
+		if best > 0 {
+			center = (float64(bestX) + float64(window)/2) / float64(width)
+		}
+		samples = append(samples, SubjectSample{
+			Time:   float64(i) / reframeSampleRate,
+			Center: center,
+		})
+	}
+	return samples, nil
+}
+
+// CommandDetector runs a command to find the subject, for plugging in real
+// face or object detection. The command gets the input path as its last
+// argument and prints a "seconds center" line per sample, the center being a
+// share of the width from 0 to 1.
+type CommandDetector struct {
+	Command string
+}
+
+func (d CommandDetector) DetectSubject(ctx context.Context, inputPath string, metadata *VideoMetadata, cropWidth int) ([]SubjectSample, error) {
+	args := strings.Fields(d.Command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("subject detector command is empty")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], inputPath)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("subject detector %s stopped: %v", args[0], ctx.Err())
+		}
+		return nil, fmt.Errorf("subject detector %s failed: %v\n%s", args[0], err, lastLines(stderr.String(), stderrTailLines))
+	}
+
+	var samples []SubjectSample
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("subject detector printed %q, expected \"seconds center\"", scanner.Text())
+		}
+		t, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("subject detector printed an invalid time %q", fields[0])
+		}
+		center, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || center < 0 || center > 1 {
+			return nil, fmt.Errorf("subject detector printed an invalid center %q, expected 0 to 1", fields[1])
+		}
+		if len(samples) > 0 && t <= samples[len(samples)-1].Time {
+			return nil, fmt.Errorf("subject detector printed times out of order at %gs", t)
+		}
+		samples = append(samples, SubjectSample{Time: t, Center: center})
+	}
+	return samples, nil
+}
+
+// smartCropX returns the crop filter's x expression moving a cropWidth wide
+// window along the subject of the video, or the centered x when no subject
+// is found
+func (p *Processor) smartCropX(inputPath string, metadata *VideoMetadata, cropWidth int) (string, error) {
+	centered := strconv.Itoa((metadata.Width - cropWidth) / 2)
+
+	detector := p.subjectDetector
+	if detector == nil {
+		detector = edgeDetector{p: p}
+	}
+	// Detection gets the same timeout as an encode
+	ctx := p.ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(p.ctx, p.timeout)
+		defer cancel()
+	}
+	samples, err := detector.DetectSubject(ctx, inputPath, metadata, cropWidth)
+	if err != nil {
+		return "", err
+	}
+	if len(samples) == 0 {
+		return centered, nil
+	}
+
+	// Smooth the path with a centered moving average
+	smoothed := make([]SubjectSample, len(samples))
+	for i := range samples {
+		lo, hi := max(i-reframeSmoothing/2, 0), min(i+reframeSmoothing/2+1, len(samples))
+		sum := 0.0
+		for _, s := range samples[lo:hi] {
+			sum += s.Center
+		}
+		smoothed[i] = SubjectSample{Time: samples[i].Time, Center: sum / float64(hi-lo)}
+	}
+
+	// Keep every step-th sample, plus the last
+	step := max((len(smoothed)+maxReframeKeyframes-1)/maxReframeKeyframes, 1)
+	var keyframes []SubjectSample
+	for i := 0; i < len(smoothed); i += step {
+		keyframes = append(keyframes, smoothed[i])
+	}
+	if last := smoothed[len(smoothed)-1]; keyframes[len(keyframes)-1] != last {
+		keyframes = append(keyframes, last)
+	}
+
+	maxX := float64(metadata.Width - cropWidth)
+	times := make([]float64, len(keyframes))
+	xs := make([]float64, len(keyframes))
+	for i, k := range keyframes {
+		times[i] = k.Time
+		xs[i] = math.Round(min(max(k.Center*float64(metadata.Width)-float64(cropWidth)/2, 0), maxX))
+	}
+	return KeyframeExpr(times, xs), nil
+}
+
+// KeyframeExpr returns an expression of t moving linearly between values at
+// times, which must be ascending, and holding the first and last outside
+// them. It's one term per segment, so it stays flat however many keyframes
+// there are.
+func KeyframeExpr(times, values []float64) string {
+	if len(values) == 1 {
+		return fmt.Sprintf("%g", values[0])
+	}
+
+	terms := make([]string, 0, len(values)+1)
+	terms = append(terms, fmt.Sprintf("lt(t,%g)*%g", times[0], values[0]))
+	for i := 0; i+1 < len(values); i++ {
+		slope := (values[i+1] - values[i]) / (times[i+1] - times[i])
+		terms = append(terms, fmt.Sprintf("gte(t,%g)*lt(t,%g)*(%g+%g*(t-%g))",
+			times[i], times[i+1], values[i], slope, times[i]))
+	}
+	last := len(values) - 1
+	terms = append(terms, fmt.Sprintf("gte(t,%g)*%g", times[last], values[last]))
+	return strings.Join(terms, "+")
+}
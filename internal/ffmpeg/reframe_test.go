@@ -0,0 +1,57 @@
+package ffmpeg
+
+import "testing"
+
+func TestKeyframeExpr(t *testing.T) {
+	tests := []struct {
+		name   string
+		times  []float64
+		values []float64
+		want   string
+	}{
+		{
+			name:   "single keyframe holds",
+			times:  []float64{3},
+			values: []float64{120},
+			want:   "120",
+		},
+		{
+			name:   "two keyframes",
+			times:  []float64{0, 2},
+			values: []float64{0, 10},
+			want:   "lt(t,0)*0+gte(t,0)*lt(t,2)*(0+5*(t-0))+gte(t,2)*10",
+		},
+		{
+			name:   "flat after a late start",
+			times:  []float64{1.5, 3.5},
+			values: []float64{100, 100},
+			want:   "lt(t,1.5)*100+gte(t,1.5)*lt(t,3.5)*(100+0*(t-1.5))+gte(t,3.5)*100",
+		},
+		{
+			name:   "moving back",
+			times:  []float64{0, 4},
+			values: []float64{640, 0},
+			want:   "lt(t,0)*640+gte(t,0)*lt(t,4)*(640+-160*(t-0))+gte(t,4)*0",
+		},
+		{
+			name:   "three keyframes",
+			times:  []float64{0, 1, 3},
+			values: []float64{0, 10, 0},
+			want:   "lt(t,0)*0+gte(t,0)*lt(t,1)*(0+10*(t-0))+gte(t,1)*lt(t,3)*(10+-5*(t-1))+gte(t,3)*0",
+		},
+		{
+			name:   "fractional slope",
+			times:  []float64{0, 3},
+			values: []float64{0, 1},
+			want:   "lt(t,0)*0+gte(t,0)*lt(t,3)*(0+0.3333333333333333*(t-0))+gte(t,3)*1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KeyframeExpr(tt.times, tt.values); got != tt.want {
+				t.Errorf("KeyframeExpr(%v, %v) = %q, want %q", tt.times, tt.values, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// remuxContainer is a container Remux can write
+type remuxContainer struct {
+	muxer  string
+	codecs map[string]bool // By ffprobe codec name, nil accepts any codec
+}
+
+func codecSet(codecs ...string) map[string]bool {
+	set := make(map[string]bool, len(codecs))
+	for _, c := range codecs {
+		set[c] = true
+	}
+	return set
+}
+
+// remuxContainers maps output file extensions to their container
+var remuxContainers = map[string]remuxContainer{
+	".mp4": {"mp4", codecSet(
+		"h264", "hevc", "av1", "vp9", "mpeg4",
+		"aac", "mp3", "opus", "flac", "alac", "ac3", "eac3",
+	)},
+	".m4v": {"mp4", codecSet(
+		"h264", "hevc", "mpeg4",
+		"aac", "mp3", "alac", "ac3", "eac3",
+	)},
+	".mov": {"mov", codecSet(
+		"h264", "hevc", "prores", "mpeg4", "mjpeg",
+		"aac", "mp3", "alac", "pcm_s16le", "pcm_s24le", "pcm_f32le",
+	)},
+	".webm": {"webm", codecSet(
+		"vp8", "vp9", "av1",
+		"opus", "vorbis",
+	)},
+	".mkv": {"matroska", nil},
+}
+
+// RemuxExtensions returns the output file extensions Remux can write, sorted
+func RemuxExtensions() []string {
+	exts := make([]string, 0, len(remuxContainers))
+	for ext := range remuxContainers {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// mediaStream is a video or audio stream as reported by ffprobe
+type mediaStream struct {
+	Index       int    `json:"index"`
+	CodecType   string `json:"codec_type"`
+	CodecName   string `json:"codec_name"`
+	Disposition struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+}
+
+// CheckRemux returns an error naming the first video or audio stream of
+// inputPath whose codec the container of outputPath can't hold. Cover art
+// isn't checked, Remux leaves it out.
+func CheckRemux(inputPath, outputPath string) error {
+	ext := strings.ToLower(filepath.Ext(outputPath))
+	container, ok := remuxContainers[ext]
+	if !ok {
+		return fmt.Errorf("unsupported remux output extension %q (supported: %s)",
+			ext, strings.Join(RemuxExtensions(), ", "))
+	}
+	if container.codecs == nil {
+		return nil
+	}
+
+	probe, err := ffmpeg.Probe(inputPath)
+	if err != nil {
+		return fmt.Errorf("error probing video: %v", err)
+	}
+	var data struct {
+		Streams []mediaStream `json:"streams"`
+	}
+	if err := json.Unmarshal([]byte(probe), &data); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, s := range data.Streams {
+		if (s.CodecType != "video" && s.CodecType != "audio") || s.Disposition.AttachedPic == 1 {
+			continue
+		}
+		if !container.codecs[s.CodecName] {
+			return fmt.Errorf("%s stream %d is %s, which %s can't hold without re-encoding",
+				s.CodecType, s.Index, s.CodecName, ext)
+		}
+	}
+	return nil
+}
+
+// Remux copies the video and audio streams of inputPath into the container
+// of outputPath's extension without re-encoding. Subtitle, data and cover art
+// streams are left out since their codecs rarely carry over between containers.
+func (p *Processor) Remux(inputPath, outputPath string) error {
+	if err := CheckRemux(inputPath, outputPath); err != nil {
+		return err
+	}
+	container := remuxContainers[strings.ToLower(filepath.Ext(outputPath))]
+
+	metadata, err := GetVideoMetadata(inputPath)
+	if err != nil {
+		return err
+	}
+
+	kwargs := ffmpeg.KwArgs{
+		"c": "copy",
+		"f": container.muxer,
+	}
+	switch container.muxer {
+	case "mp4", "mov":
+		kwargs["movflags"] = "+faststart"
+		// Apple players only accept HEVC tagged hvc1, Matroska sources often carry hev1
+		if metadata.Codec == "hevc" {
+			kwargs["tag:v"] = "hvc1"
+		}
+	}
+	p.MuxArgs(kwargs)
+
+	if p.verbose {
+		log.Printf("Remuxing %s into %s\n", inputPath, container.muxer)
+	}
+
+	input := ffmpeg.Input(inputPath)
+	// V leaves out attached pictures, the ? lets inputs without audio through
+	streams := []*ffmpeg.Stream{input.Get("V"), input.Get("a?")}
+	if err := p.Run(ffmpeg.Output(streams, outputPath, kwargs), metadata.Duration); err != nil {
+		return fmt.Errorf("failed to remux video: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// sequenceNumber matches the frame number of an image sequence pattern, e.g.
+// the %05d of frames/%05d.png
+var sequenceNumber = regexp.MustCompile(`%(0?)(\d*)d`)
+
+// IsImageSequence reports whether path is a numbered image sequence pattern,
+// e.g. frames/%05d.png, as ffmpeg's image2 demuxer reads them
+func IsImageSequence(path string) bool {
+	return IsImage(path) && len(sequenceNumber.FindAllStringIndex(filepath.Base(path), -1)) == 1
+}
+
+// SequenceName returns the file name of an image sequence pattern without its
+// frame number and extension, or the name of its directory when that leaves
+// nothing, e.g. "render" for render_%04d.png and "frames" for frames/%05d.png
+func SequenceName(pattern string) string {
+	base := filepath.Base(pattern)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	name := strings.Trim(sequenceNumber.ReplaceAllString(base, ""), "_-. ")
+	if name == "" {
+		name = filepath.Base(filepath.Dir(pattern))
+	}
+	return name
+}
+
+// SequenceFrames returns the first frame number of the image sequence pattern
+// and how many frames follow it without a gap, which is what ffmpeg reads
+func SequenceFrames(pattern string) (int, int, error) {
+	dir, file := filepath.Split(pattern)
+	loc := sequenceNumber.FindStringSubmatchIndex(file)
+	if loc == nil {
+		return 0, 0, fmt.Errorf("%s is not an image sequence pattern such as frames/%%05d.png", pattern)
+	}
+
+	digits := `\d+`
+	if file[loc[2]:loc[3]] == "0" {
+		digits = fmt.Sprintf(`\d{%s,}`, file[loc[4]:loc[5]])
+	}
+	match := regexp.MustCompile("^" + regexp.QuoteMeta(file[:loc[0]]) + "(" + digits + ")" + regexp.QuoteMeta(file[loc[1]:]) + "$")
+
+	entries, err := os.ReadDir(filepath.Clean(dir + "."))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read image sequence directory: %v", err)
+	}
+	frames := make(map[int]bool)
+	first := -1
+	for _, entry := range entries {
+		m := match.FindStringSubmatch(entry.Name())
+		if m == nil || entry.IsDir() {
+			continue
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		frames[n] = true
+		if first < 0 || n < first {
+			first = n
+		}
+	}
+	if len(frames) == 0 {
+		return 0, 0, fmt.Errorf("no frames match %s", pattern)
+	}
+
+	count := 0
+	for frames[first+count] {
+		count++
+	}
+	if count < len(frames) {
+		log.Printf("Warning: %s skips frame %d, ignoring the %d frames after it\n",
+			pattern, first+count, len(frames)-count)
+	}
+	return first, count, nil
+}
+
+// SequenceVideo encodes the image sequence pattern, played at fps frames per
+// second, to a video at outputPath, on a lossless-quality intermediate the
+// way effects passes are. Odd dimensions are trimmed by a pixel, which 4:2:0
+// video can't hold.
+func (p *Processor) SequenceVideo(pattern, outputPath string, fps float64) error {
+	first, count, err := SequenceFrames(pattern)
+	if err != nil {
+		return err
+	}
+	duration := float64(count) / fps
+
+	video := ffmpeg.Input(pattern, ffmpeg.KwArgs{"f": "image2", "framerate": fmt.Sprintf("%g", fps), "start_number": first}).Video().
+		Filter("scale", ffmpeg.Args{"trunc(iw/2)*2:trunc(ih/2)*2"}).
+		Filter("setsar", ffmpeg.Args{"1"}).
+		Filter("format", ffmpeg.Args{"yuv420p"})
+
+	kwargs := ffmpeg.KwArgs{
+		"c:v":    "libx264",
+		"crf":    10,
+		"preset": "veryfast",
+		"f":      "matroska",
+	}
+
+	if p.verbose {
+		log.Printf("Encoding %d frames of %s from frame %d at %g fps\n", count, pattern, first, fps)
+	}
+
+	if err := p.Run(video.Output(outputPath, kwargs), duration); err != nil {
+		return fmt.Errorf("failed to encode image sequence %s: %v", pattern, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// maxSizeAttempts bounds how many times EncodeWithinSize encodes an output
+const maxSizeAttempts = 3
+
+// EncodeWithinSize encodes like Encode and, while the output is larger than
+// maxSize bytes, encodes it again at a 25% lower bitrate, or a higher CRF for
+// constant quality encodes. A maxSize of zero disables the check. An output
+// still too large after the last attempt is kept with a warning.
+func (p *Processor) EncodeWithinSize(streams []*ffmpeg.Stream, outputPath string, kwargs ffmpeg.KwArgs, duration float64, maxSize int64) error {
+	for attempt := 1; ; attempt++ {
+		if err := p.Encode(streams, outputPath, kwargs, duration); err != nil {
+			return err
+		}
+		if maxSize <= 0 {
+			return nil
+		}
+
+		info, err := os.Stat(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to check output size: %v", err)
+		}
+		if info.Size() <= maxSize {
+			return nil
+		}
+
+		if attempt == maxSizeAttempts {
+			log.Printf("Warning: %s is %d bytes, still over the %d byte limit after %d attempts\n",
+				outputPath, info.Size(), maxSize, attempt)
+			return nil
+		}
+
+		kwargs = shrinkArgs(kwargs)
+		if p.verbose {
+			log.Printf("%s is %d bytes, over the %d byte limit, encoding again (b:v=%v crf=%v)\n",
+				outputPath, info.Size(), maxSize, kwargs["b:v"], kwargs["crf"])
+		}
+	}
+}
+
+// shrinkArgs returns a copy of kwargs that encodes to a smaller output
+func shrinkArgs(kwargs ffmpeg.KwArgs) ffmpeg.KwArgs {
+	shrunk := ffmpeg.KwArgs{}
+	for k, v := range kwargs {
+		shrunk[k] = v
+	}
+
+	if crf, ok := shrunk["crf"].(int); ok {
+		maxCRF := 63
+		if shrunk["c:v"] == "libx264" || shrunk["c:v"] == "libx265" {
+			maxCRF = 51
+		}
+		shrunk["crf"] = min(crf+4, maxCRF)
+		return shrunk
+	}
+
+	for _, key := range []string{"b:v", "maxrate", "bufsize"} {
+		if bitrate, ok := shrunk[key].(string); ok && bitrate != "0" {
+			shrunk[key] = reduceBitrate(bitrate)
+		}
+	}
+	return shrunk
+}
@@ -0,0 +1,63 @@
+package ffmpeg
+
+import (
+	"reflect"
+	"testing"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+func TestShrinkArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		kwargs ffmpeg.KwArgs
+		want   ffmpeg.KwArgs
+	}{
+		{
+			name:   "x264 crf",
+			kwargs: ffmpeg.KwArgs{"c:v": "libx264", "crf": 23, "preset": "slow"},
+			want:   ffmpeg.KwArgs{"c:v": "libx264", "crf": 27, "preset": "slow"},
+		},
+		{
+			name:   "x264 crf at its limit",
+			kwargs: ffmpeg.KwArgs{"c:v": "libx264", "crf": 49},
+			want:   ffmpeg.KwArgs{"c:v": "libx264", "crf": 51},
+		},
+		{
+			name:   "vp9 crf leaves its zero bitrate",
+			kwargs: ffmpeg.KwArgs{"c:v": "libvpx-vp9", "crf": 61, "b:v": "0"},
+			want:   ffmpeg.KwArgs{"c:v": "libvpx-vp9", "crf": 63, "b:v": "0"},
+		},
+		{
+			name:   "bitrates",
+			kwargs: ffmpeg.KwArgs{"c:v": "libx264", "b:v": "2M", "maxrate": "2M", "bufsize": "4M", "b:a": "128k"},
+			want:   ffmpeg.KwArgs{"c:v": "libx264", "b:v": "1500k", "maxrate": "1500k", "bufsize": "3000k", "b:a": "128k"},
+		},
+		{
+			name:   "kilobit bitrate",
+			kwargs: ffmpeg.KwArgs{"b:v": "1165k"},
+			want:   ffmpeg.KwArgs{"b:v": "873k"},
+		},
+		{
+			name:   "nothing to shrink",
+			kwargs: ffmpeg.KwArgs{"c:v": "libx264", "b:v": "0"},
+			want:   ffmpeg.KwArgs{"c:v": "libx264", "b:v": "0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := ffmpeg.KwArgs{}
+			for k, v := range tt.kwargs {
+				before[k] = v
+			}
+
+			if got := shrinkArgs(tt.kwargs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shrinkArgs(%v) = %v, want %v", tt.kwargs, got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.kwargs, before) {
+				t.Errorf("shrinkArgs changed its input to %v", tt.kwargs)
+			}
+		})
+	}
+}
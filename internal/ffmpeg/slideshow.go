@@ -0,0 +1,163 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// imageExtensions are the still images turned into video clips
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".webp", ".bmp", ".tif", ".tiff"}
+
+// IsImage reports whether path is a still image, by its extension
+func IsImage(path string) bool {
+	return slices.Contains(imageExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// kenBurnsZoom is how far Ken Burns clips zoom in over their length
+const kenBurnsZoom = 0.2
+
+// kenBurnsOversample is how much larger than the clip images are scaled
+// before zoompan, whose crops snap to whole pixels and jitter at 1x
+const kenBurnsOversample = 4
+
+// StillVideo encodes the image at imagePath to a clip of duration seconds at
+// width x height and fps frames per second, covering the frame and cropping
+// what overflows it, with a silent audio track so it joins like any video.
+// Ken Burns clips slowly zoom into the center, or out of it with zoomOut, on
+// a lossless-quality intermediate the way effects passes are.
+func (p *Processor) StillVideo(imagePath, outputPath string, duration float64, width, height, fps int, kenBurns, zoomOut bool) error {
+	size := fmt.Sprintf("%d:%d", width, height)
+	video := ffmpeg.Input(imagePath, ffmpeg.KwArgs{"loop": 1, "framerate": fps, "t": fmt.Sprintf("%.3f", duration)}).Video()
+	if kenBurns {
+		frames := max(int(duration*float64(fps)), 1)
+		step := kenBurnsZoom / float64(frames)
+		zoom := fmt.Sprintf("1+%g*on", step)
+		if zoomOut {
+			zoom = fmt.Sprintf("%g-%g*on", 1+kenBurnsZoom, step)
+		}
+		video = video.
+			Filter("scale", ffmpeg.Args{fmt.Sprintf("%d:%d", width*kenBurnsOversample, height*kenBurnsOversample)},
+				ffmpeg.KwArgs{"force_original_aspect_ratio": "increase"}).
+			Filter("crop", ffmpeg.Args{fmt.Sprintf("%d:%d", width*kenBurnsOversample, height*kenBurnsOversample)}).
+			Filter("zoompan", ffmpeg.Args{}, ffmpeg.KwArgs{
+				"z":   zoom,
+				"x":   "iw/2-iw/zoom/2",
+				"y":   "ih/2-ih/zoom/2",
+				"d":   1,
+				"s":   fmt.Sprintf("%dx%d", width, height),
+				"fps": fps,
+			})
+	} else {
+		video = video.
+			Filter("scale", ffmpeg.Args{size}, ffmpeg.KwArgs{"force_original_aspect_ratio": "increase"}).
+			Filter("crop", ffmpeg.Args{size})
+	}
+	video = video.Filter("setsar", ffmpeg.Args{"1"}).Filter("format", ffmpeg.Args{"yuv420p"})
+	audio := ffmpeg.Input("anullsrc=channel_layout=stereo:sample_rate=48000",
+		ffmpeg.KwArgs{"f": "lavfi", "t": fmt.Sprintf("%.3f", duration)}).Audio()
+
+	kwargs := ffmpeg.KwArgs{
+		"c:v":    "libx264",
+		"crf":    10,
+		"preset": "veryfast",
+		"c:a":    "flac",
+		"f":      "matroska",
+		"t":      fmt.Sprintf("%.3f", duration),
+	}
+
+	if p.verbose {
+		log.Printf("Turning %s into a %gs clip (Ken Burns: %t)\n", imagePath, duration, kenBurns)
+	}
+
+	if err := p.Run(ffmpeg.Output([]*ffmpeg.Stream{video, audio}, outputPath, kwargs), duration); err != nil {
+		return fmt.Errorf("failed to turn image %s into a video: %v", imagePath, err)
+	}
+	return nil
+}
+
+// Slideshow joins clips of the same size and frame rate, as StillVideo
+// writes them, crossfading each into the next over transitionDuration seconds
+// with transition, or cutting hard when it's empty, and encodes the result for
+// plat in outputFormat
+func (p *Processor) Slideshow(clips []string, outputPath, transition string, transitionDuration float64, plat platform.Platform, outputFormat string) error {
+	xfade, ok := transitions[transition]
+	if transition != "" && !ok {
+		return fmt.Errorf("unsupported transition: %s (supported: %s)", transition, strings.Join(Transitions(), ", "))
+	}
+
+	durations := make([]float64, len(clips))
+	var videos, audios []*ffmpeg.Stream
+	for i, path := range clips {
+		metadata, err := GetVideoMetadata(path)
+		if err != nil {
+			return errors.Wrap(err, "failed to get video metadata")
+		}
+		if transition != "" && transitionDuration >= metadata.Duration {
+			return fmt.Errorf("transition of %gs must be shorter than each slide (%gs)", transitionDuration, metadata.Duration)
+		}
+		durations[i] = metadata.Duration
+
+		input := ffmpeg.Input(path)
+		videos = append(videos, input.Video().Filter("settb", ffmpeg.Args{"AVTB"}))
+		audios = append(audios, input.Audio())
+	}
+
+	var video, audio *ffmpeg.Stream
+	total := durations[0]
+	if transition == "" || len(clips) == 1 {
+		for _, d := range durations[1:] {
+			total += d
+		}
+		joined := ffmpeg.Concat(interleave(videos, audios), ffmpeg.KwArgs{"v": 1, "a": 1}).Node
+		video, audio = joined.Get("0"), joined.Get("1")
+	} else {
+		video, audio = videos[0], audios[0]
+		for i := 1; i < len(clips); i++ {
+			video = ffmpeg.Filter([]*ffmpeg.Stream{video, videos[i]}, "xfade", ffmpeg.Args{}, ffmpeg.KwArgs{
+				"transition": xfade,
+				"duration":   fmt.Sprintf("%g", transitionDuration),
+				"offset":     fmt.Sprintf("%.3f", total-transitionDuration),
+			})
+			audio = ffmpeg.Filter([]*ffmpeg.Stream{audio, audios[i]}, "acrossfade", ffmpeg.Args{}, ffmpeg.KwArgs{
+				"d": fmt.Sprintf("%g", transitionDuration),
+			})
+			total += durations[i] - transitionDuration
+		}
+	}
+
+	outputs := []*ffmpeg.Stream{video}
+	if !p.noAudio {
+		outputs = append(outputs, audio)
+	}
+	outputKwargs := p.OutputArgs(PlatformOutputArgs(plat, outputFormat), nil)
+
+	if p.verbose {
+		log.Printf("Joining %d slides into a %.1fs slideshow\n", len(clips), total)
+	}
+
+	var maxSize int64
+	if plat != nil {
+		maxSize = plat.GetMaxFileSize()
+	}
+	if err := p.EncodeWithinSize(outputs, outputPath, outputKwargs, total, maxSize); err != nil {
+		return fmt.Errorf("failed to encode slideshow: %v", err)
+	}
+	return nil
+}
+
+// interleave returns the videos and audios in the v0 a0 v1 a1 ... order the
+// concat filter takes them in
+func interleave(videos, audios []*ffmpeg.Stream) []*ffmpeg.Stream {
+	streams := make([]*ffmpeg.Stream, 0, len(videos)+len(audios))
+	for i := range videos {
+		streams = append(streams, videos[i], audios[i])
+	}
+	return streams
+}
@@ -0,0 +1,74 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// SetBurnTimecode draws the running timestamp on every segment cut from an
+// input, counted from the start of the input so chunk boundaries can be
+// checked against it
+func (p *Processor) SetBurnTimecode(burn bool) {
+	p.burnTimecode = burn
+}
+
+// TimecodeFilter returns a drawtext filter showing the running timestamp at
+// the top center of the video, below the top of area, offset by startTime
+// seconds, in the font of text
+func TimecodeFilter(startTime float64, area platform.SafeArea, text config.TextOptions) string {
+	y := config.TextPadding
+	if area.Top > 0 {
+		y = fmt.Sprintf("%s+h*%g", config.TextPadding, area.Top)
+	}
+	return fmt.Sprintf(
+		"drawtext=text='%%{pts\\:hms\\:%.3f}':"+
+			"fontsize=%s:"+
+			"fontcolor=%s:"+
+			"bordercolor=%s:"+
+			"borderw=%s:"+
+			"x=(w-tw)/2:"+
+			"y=%s:"+
+			"box=1:"+
+			"boxcolor=black@0.5:"+
+			"boxborderw=5%s",
+		startTime,
+		config.TextSize,
+		config.TextColor,
+		config.TextBorderColor,
+		config.TextBorderWidth,
+		y,
+		FontOption(text),
+	)
+}
+
+// withTimecode returns filter with the timecode of a segment starting at
+// startTime appended when burning timecodes, kept out of plat's interface
+// when plat is set
+func (p *Processor) withTimecode(filter string, startTime float64, plat platform.Platform) string {
+	if !p.burnTimecode {
+		return filter
+	}
+
+	var area platform.SafeArea
+	if plat != nil {
+		area = plat.GetSafeArea()
+	}
+	if filter == "" {
+		return TimecodeFilter(startTime, area, p.text)
+	}
+	return filter + "," + TimecodeFilter(startTime, area, p.text)
+}
+
+// timecodeStream draws the timecode of a segment starting at startTime on a
+// video stream when burning timecodes
+func (p *Processor) timecodeStream(video *ffmpeg.Stream, startTime float64) *ffmpeg.Stream {
+	if !p.burnTimecode {
+		return video
+	}
+	name, args, _ := strings.Cut(TimecodeFilter(startTime, platform.SafeArea{}, p.text), "=")
+	return video.Filter(name, ffmpeg.Args{args})
+}
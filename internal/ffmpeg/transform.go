@@ -0,0 +1,149 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// normalizeRotation returns degrees as a clockwise rotation from 0 to 359
+func normalizeRotation(degrees int) int {
+	return (degrees%360 + 360) % 360
+}
+
+// parseRotation returns the clockwise rotation players apply to a probed
+// video stream, from its display matrix or, in older ffprobe output, its
+// rotate tag
+func parseRotation(stream map[string]interface{}) int {
+	sideData, _ := stream["side_data_list"].([]interface{})
+	for _, entry := range sideData {
+		data, ok := entry.(map[string]interface{})
+		if !ok || data["side_data_type"] != "Display Matrix" {
+			continue
+		}
+		// The display matrix rotation is counterclockwise
+		if rotation, ok := data["rotation"].(float64); ok {
+			return normalizeRotation(-int(math.Round(rotation)))
+		}
+	}
+
+	if tags, ok := stream["tags"].(map[string]interface{}); ok {
+		if rotate, ok := tags["rotate"].(string); ok {
+			degrees, _ := strconv.Atoi(rotate)
+			return normalizeRotation(degrees)
+		}
+	}
+	return 0
+}
+
+// TransformFilter returns the filter rotating video rotate degrees clockwise,
+// a multiple of 90, then mirroring it left to right when hflip is set and top
+// to bottom when vflip is, or "" when that leaves it as is
+func TransformFilter(rotate int, hflip, vflip bool) string {
+	var filters []string
+	switch normalizeRotation(rotate) {
+	case 90:
+		filters = append(filters, "transpose=clock")
+	case 180:
+		// Turning half way is mirroring both ways, which cancels out flips
+		hflip, vflip = !hflip, !vflip
+	case 270:
+		filters = append(filters, "transpose=cclock")
+	}
+	if hflip {
+		filters = append(filters, "hflip")
+	}
+	if vflip {
+		filters = append(filters, "vflip")
+	}
+	return strings.Join(filters, ",")
+}
+
+// FileOutputArgs returns the output arguments encoding to outputPath with the
+// codecs of the format its extension names, mp4's when it names none, with
+// OutputArgs applied for source
+func (p *Processor) FileOutputArgs(outputPath string, source *VideoMetadata) ffmpeg.KwArgs {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(outputPath)), ".")
+	if !IsSupportedFormat(format) {
+		format = "mp4"
+	}
+	kwargs := GenericOutputArgs(format)
+	for k, v := range p.EncoderPreset(GetCodecSettings(format)) {
+		kwargs[k] = v
+	}
+	return p.OutputArgs(kwargs, source)
+}
+
+// Transform re-encodes inputPath to outputPath rotated rotate degrees
+// clockwise and mirrored as TransformFilter does. The transform is relative
+// to how the input displays: ffmpeg applies the input's own rotation first,
+// and the output's rotation tag is cleared so players don't turn it again.
+// The output's extension picks the codecs, defaulting to mp4's, and the audio
+// is copied when the container can hold it.
+func (p *Processor) Transform(inputPath, outputPath string, rotate int, hflip, vflip bool) error {
+	metadata, err := GetVideoMetadata(inputPath)
+	if err != nil {
+		return err
+	}
+
+	kwargs := p.FileOutputArgs(outputPath, metadata)
+	// The audio is copied when the output container can hold it
+	if codecs := remuxContainers[strings.ToLower(filepath.Ext(outputPath))].codecs; codecs == nil || codecs[metadata.AudioCodec] {
+		kwargs["c:a"] = "copy"
+		delete(kwargs, "b:a")
+	}
+	kwargs["metadata:s:v:0"] = "rotate=0"
+	if filter := p.WithTonemap(TransformFilter(rotate, hflip, vflip), metadata); filter != "" {
+		kwargs["vf"] = filter
+	}
+
+	if p.verbose {
+		log.Printf("Transforming %s: rotating %d degrees, hflip %t, vflip %t (source rotated %d degrees)\n",
+			inputPath, normalizeRotation(rotate), hflip, vflip, metadata.Rotation)
+	}
+
+	input := ffmpeg.Input(inputPath)
+	streams := []*ffmpeg.Stream{input.Get("V"), input.Get("a?")}
+	if err := p.Run(ffmpeg.Output(streams, outputPath, kwargs), metadata.Duration); err != nil {
+		return fmt.Errorf("failed to transform video: %v", err)
+	}
+	return nil
+}
+
+// Reorient rewrites the display rotation of inputPath into outputPath, adding
+// rotate degrees clockwise to its own, without re-encoding. Players honor
+// rotation but mirroring metadata much less reliably, so flips need Transform.
+// The display rotation option needs ffmpeg 6.0 or later.
+func (p *Processor) Reorient(inputPath, outputPath string, rotate int) error {
+	if err := CheckRemux(inputPath, outputPath); err != nil {
+		return err
+	}
+
+	metadata, err := GetVideoMetadata(inputPath)
+	if err != nil {
+		return err
+	}
+	rotation := normalizeRotation(metadata.Rotation + rotate)
+
+	if p.verbose {
+		log.Printf("Reorienting %s from %d to %d degrees without re-encoding\n", inputPath, metadata.Rotation, rotation)
+	}
+
+	// display_rotation is counterclockwise
+	input := ffmpeg.Input(inputPath, ffmpeg.KwArgs{"display_rotation:v:0": -rotation})
+	kwargs := ffmpeg.KwArgs{"c": "copy"}
+	if muxer := remuxContainers[strings.ToLower(filepath.Ext(outputPath))].muxer; muxer == "mp4" || muxer == "mov" {
+		kwargs["movflags"] = "+faststart"
+	}
+	p.MuxArgs(kwargs)
+	streams := []*ffmpeg.Stream{input.Get("V"), input.Get("a?")}
+	if err := p.Run(ffmpeg.Output(streams, outputPath, kwargs), metadata.Duration); err != nil {
+		return fmt.Errorf("failed to reorient video: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// transitions maps the supported transitions between joined videos to their
+// xfade transition
+var transitions = map[string]string{
+	"fade":     "fade",
+	"dissolve": "dissolve",
+	"slide":    "slideleft",
+}
+
+// defaultTransitionFPS is the frame rate of transitions from videos whose
+// frame rate is unknown
+const defaultTransitionFPS = 30
+
+// Transitions returns the supported transitions between joined videos
+func Transitions() []string {
+	names := make([]string, 0, len(transitions))
+	for name := range transitions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// IsTransition reports whether name is a supported transition
+func IsTransition(name string) bool {
+	_, ok := transitions[name]
+	return ok
+}
+
+// CrossfadeVideos joins second after first, blending the last duration seconds
+// of first into the start of second with transition, and writes the result to
+// outputPath. Both videos are scaled and padded to width x height and brought
+// to first's frame rate and pixel format so xfade accepts them, and the result
+// is re-encoded as ConcatSegments does. A video without audio crossfades from
+// or to silence.
+func (p *Processor) CrossfadeVideos(first, second, outputPath, transition string, duration float64, width, height int, plat platform.Platform, outputFormat string) error {
+	xfade, ok := transitions[transition]
+	if !ok {
+		return fmt.Errorf("unsupported transition: %s (supported: %s)", transition, strings.Join(Transitions(), ", "))
+	}
+
+	metadatas := make([]*VideoMetadata, 2)
+	for i, path := range []string{first, second} {
+		metadata, err := GetVideoMetadata(path)
+		if err != nil {
+			return errors.Wrap(err, "failed to get video metadata")
+		}
+		if duration <= 0 || duration >= metadata.Duration {
+			return fmt.Errorf("transition of %gs must be shorter than %s (%gs)", duration, path, metadata.Duration)
+		}
+		metadatas[i] = metadata
+	}
+
+	fps := metadatas[0].FrameRate
+	if fps <= 0 {
+		fps = defaultTransitionFPS
+	}
+	pixelFormat := "yuv420p"
+	if p.preserveHDR && metadatas[0].IsHDR() {
+		pixelFormat = metadatas[0].PixelFormat
+	}
+	hasAudio := !p.noAudio && (metadatas[0].HasAudio || metadatas[1].HasAudio)
+
+	var videos, audios []*ffmpeg.Stream
+	for i, path := range []string{first, second} {
+		metadata := metadatas[i]
+		input := ffmpeg.Input(path)
+		videos = append(videos, p.tonemapStream(input.Video(), metadata).
+			Filter("scale", ffmpeg.Args{fmt.Sprintf("%d:%d", width, height)},
+				ffmpeg.KwArgs{"force_original_aspect_ratio": "decrease"}).
+			Filter("pad", ffmpeg.Args{fmt.Sprintf("%d:%d:(ow-iw)/2:(oh-ih)/2:black", width, height)}).
+			Filter("setsar", ffmpeg.Args{"1"}).
+			Filter("fps", ffmpeg.Args{fmt.Sprintf("%g", fps)}).
+			Filter("format", ffmpeg.Args{pixelFormat}).
+			Filter("settb", ffmpeg.Args{"AVTB"}))
+		if !hasAudio {
+			continue
+		}
+
+		// acrossfade needs audio on both sides once either has it
+		audio := input.Audio()
+		if !metadata.HasAudio {
+			if p.verbose {
+				log.Printf("%s has no audio, crossfading with silence\n", path)
+			}
+			audio = ffmpeg.Input("anullsrc=channel_layout=stereo:sample_rate=48000",
+				ffmpeg.KwArgs{"f": "lavfi", "t": metadata.Duration}).Audio()
+		}
+		audios = append(audios, audio.
+			Filter("aresample", ffmpeg.Args{"48000"}).
+			Filter("aformat", ffmpeg.Args{}, ffmpeg.KwArgs{"sample_fmts": "fltp", "channel_layouts": "stereo"}))
+	}
+
+	outputs := []*ffmpeg.Stream{ffmpeg.Filter(videos, "xfade", ffmpeg.Args{}, ffmpeg.KwArgs{
+		"transition": xfade,
+		"duration":   fmt.Sprintf("%g", duration),
+		"offset":     fmt.Sprintf("%.3f", metadatas[0].Duration-duration),
+	})}
+	if hasAudio {
+		outputs = append(outputs, ffmpeg.Filter(audios, "acrossfade", ffmpeg.Args{}, ffmpeg.KwArgs{
+			"d": fmt.Sprintf("%g", duration),
+		}))
+	}
+
+	// The output takes the color properties of the first HDR video, if any
+	source := metadatas[0]
+	if metadatas[1].IsHDR() && !source.IsHDR() {
+		source = metadatas[1]
+	}
+	outputKwargs := p.OutputArgs(PlatformOutputArgs(plat, outputFormat), source)
+
+	if p.verbose {
+		log.Printf("Joining %s and %s with a %gs %s transition\n", first, second, duration, transition)
+	}
+
+	var maxSize int64
+	if plat != nil {
+		maxSize = plat.GetMaxFileSize()
+	}
+
+	totalDuration := metadatas[0].Duration + metadatas[1].Duration - duration
+	if err := p.EncodeWithinSize(outputs, outputPath, outputKwargs, totalDuration, maxSize); err != nil {
+		return fmt.Errorf("failed to crossfade videos: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// twoPassEncoders are the encoders Encode runs in two passes
+var twoPassEncoders = map[string]bool{
+	"libx264":    true,
+	"libvpx-vp9": true,
+}
+
+// SetTwoPass makes bitrate-targeted x264 and VP9 encodes run in two passes, so
+// the output lands on the target bitrate instead of overshooting it
+func (p *Processor) SetTwoPass(twoPass bool) {
+	p.twoPass = twoPass
+}
+
+// Encode encodes streams to outputPath with kwargs, like Run. The encode takes
+// two passes when two-pass is enabled and kwargs target a bitrate with an
+// encoder that supports it.
+func (p *Processor) Encode(streams []*ffmpeg.Stream, outputPath string, kwargs ffmpeg.KwArgs, duration float64) error {
+	if !p.usesTwoPass(kwargs) {
+		return p.Run(ffmpeg.Output(streams, outputPath, kwargs), duration)
+	}
+
+	logDir, err := os.MkdirTemp("", "video_passlog_")
+	if err != nil {
+		return fmt.Errorf("failed to create pass log directory: %v", err)
+	}
+	defer os.RemoveAll(logDir)
+	passLog := filepath.Join(logDir, "pass")
+
+	// The first pass only analyses the video, so it skips audio and muxing
+	firstPass := ffmpeg.KwArgs{}
+	for k, v := range kwargs {
+		switch {
+		case k == "c:a", k == "b:a", k == "af", k == "movflags", slices.Contains(dashMuxerOptions, k):
+			continue
+		}
+		firstPass[k] = v
+	}
+	firstPass["pass"] = 1
+	firstPass["passlogfile"] = passLog
+	firstPass["an"] = ""
+	firstPass["f"] = "null"
+
+	secondPass := ffmpeg.KwArgs{}
+	for k, v := range kwargs {
+		secondPass[k] = v
+	}
+	secondPass["pass"] = 2
+	secondPass["passlogfile"] = passLog
+
+	// Report both passes as one encode, the first half and the second half
+	onProgress := p.onProgress
+	defer func() { p.onProgress = onProgress }()
+
+	if onProgress != nil {
+		p.onProgress = func(pr Progress) {
+			pr.Percent /= 2
+			pr.Done = false
+			onProgress(pr)
+		}
+	}
+	if p.verbose {
+		log.Printf("Encoding %s in two passes\n", outputPath)
+	}
+	if err := p.Run(ffmpeg.Output(streams, os.DevNull, firstPass), duration); err != nil {
+		return fmt.Errorf("first pass failed: %v", err)
+	}
+
+	if onProgress != nil {
+		p.onProgress = func(pr Progress) {
+			pr.Percent = 50 + pr.Percent/2
+			onProgress(pr)
+		}
+	}
+	return p.Run(ffmpeg.Output(streams, outputPath, secondPass), duration)
+}
+
+// usesTwoPass reports whether Encode runs kwargs in two passes
+func (p *Processor) usesTwoPass(kwargs ffmpeg.KwArgs) bool {
+	if !p.twoPass {
+		return false
+	}
+
+	encoder, _ := kwargs["c:v"].(string)
+	if !twoPassEncoders[encoder] {
+		return false
+	}
+
+	// Constant quality encodes have no bitrate target for a second pass to hit
+	if _, ok := kwargs["crf"]; ok {
+		return false
+	}
+	bitrate, ok := kwargs["b:v"]
+	return ok && fmt.Sprint(bitrate) != "0"
+}
@@ -0,0 +1,604 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
+	"github.com/pkg/errors"
+)
+
+// Kind is the operation a job performs
+type Kind string
+
+const (
+	KindSplit    Kind = "split"
+	KindTemplate Kind = "template"
+)
+
+// Status is the lifecycle state of a job
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Origin is where a job was submitted from
+type Origin string
+
+const (
+	OriginCLI Origin = "cli" // The batch command, trusted with every option
+	OriginAPI Origin = "api" // A server client, limited to the options its requests list
+)
+
+// Job is a submitted split or template operation
+type Job struct {
+	ID        string                       `json:"id"`
+	Kind      Kind                         `json:"kind"`
+	Origin    Origin                       `json:"origin,omitempty"`
+	Status    Status                       `json:"status"`
+	Error     string                       `json:"error,omitempty"`
+	Split     *config.VideoSplitterOptions `json:"split,omitempty"`
+	Template  *config.VideoTemplateOptions `json:"template,omitempty"`
+	Outputs   []string                     `json:"outputs,omitempty"`
+	Progress  string                       `json:"progress,omitempty"` // e.g. "encoding chunk 3/9: 42%"
+	CreatedAt time.Time                    `json:"created_at"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+}
+
+// Manager queues jobs and runs them on a fixed number of workers. Jobs without
+// an explicit output location write into their own directory under the work dir.
+type Manager struct {
+	workDir  string
+	workers  int
+	store    Store
+	origin   Origin
+	sanitize func(job *Job) error
+
+	mu       sync.Mutex
+	saveMu   sync.Mutex
+	jobs     map[string]*Job
+	subs     map[string][]chan Job
+	queue    chan string
+	pending  sync.WaitGroup
+	workerWG sync.WaitGroup
+}
+
+// NewManager creates a new job manager. The store may be nil, in which case
+// jobs only live in memory. Jobs submitted through the manager are recorded as
+// coming from origin, and Start only resumes jobs of the same origin, leaving
+// the others in the store for the command that submitted them. API jobs are
+// passed to sanitize before resuming, to re-apply the restrictions of their
+// requests; they fail when it returns an error or is nil.
+func NewManager(workDir string, workers int, store Store, origin Origin, sanitize func(job *Job) error) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Manager{
+		workDir:  workDir,
+		workers:  workers,
+		store:    store,
+		origin:   origin,
+		sanitize: sanitize,
+		jobs:     make(map[string]*Job),
+		subs:     make(map[string][]chan Job),
+		queue:    make(chan string, 1024),
+	}
+}
+
+// Start restores persisted jobs, requeues the unfinished ones and launches the
+// workers, which stop once ctx is cancelled
+func (m *Manager) Start(ctx context.Context) error {
+	if err := os.MkdirAll(m.workDir, 0755); err != nil {
+		return fmt.Errorf("error creating work directory: %v", err)
+	}
+
+	if m.store != nil {
+		stored, err := m.store.Load()
+		if err != nil {
+			return errors.Wrap(err, "failed to load jobs")
+		}
+
+		for i := range stored {
+			job := stored[i]
+			if job.Origin == "" {
+				// Stored before jobs recorded their origin, when clients could
+				// set any option; restrict them like API jobs
+				job.Origin = OriginAPI
+			}
+			if job.Finished() {
+				m.mu.Lock()
+				m.jobs[job.ID] = &job
+				m.mu.Unlock()
+				continue
+			}
+			if job.Origin != m.origin {
+				continue
+			}
+
+			if err := m.vet(&job); err != nil {
+				log.Printf("Not resuming %s job %s: %v\n", job.Kind, job.ID, err)
+				job.Status = StatusFailed
+				job.Progress = ""
+				job.Error = err.Error()
+				m.mu.Lock()
+				m.jobs[job.ID] = &job
+				m.mu.Unlock()
+				m.save(job)
+				continue
+			}
+
+			// Interrupted jobs start over from the beginning
+			log.Printf("Resuming %s job %s\n", job.Kind, job.ID)
+			job.Status = StatusQueued
+			job.Progress = ""
+			if _, err := m.enqueue(&job); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := 0; i < m.workers; i++ {
+		m.workerWG.Add(1)
+		go m.work(ctx)
+	}
+	return nil
+}
+
+// SubmitSplit queues a split job. When no output directory is set the job
+// writes into its own directory under the work dir.
+func (m *Manager) SubmitSplit(opts config.VideoSplitterOptions) (*Job, error) {
+	if err := validateSplit(&opts); err != nil {
+		return nil, err
+	}
+
+	job, err := m.newJob(KindSplit)
+	if err != nil {
+		return nil, err
+	}
+	job.Split = &opts
+	m.defaultOutput(job)
+
+	return m.enqueue(job)
+}
+
+// SubmitTemplate queues a template job. When no output path is set the job
+// writes into its own directory under the work dir.
+func (m *Manager) SubmitTemplate(opts config.VideoTemplateOptions) (*Job, error) {
+	if err := validateTemplate(&opts); err != nil {
+		return nil, err
+	}
+	defaultTemplate(&opts)
+
+	job, err := m.newJob(KindTemplate)
+	if err != nil {
+		return nil, err
+	}
+	job.Template = &opts
+	m.defaultOutput(job)
+
+	return m.enqueue(job)
+}
+
+func validateSplit(opts *config.VideoSplitterOptions) error {
+	if opts.InputPath == "" {
+		return errors.New("input path is required")
+	}
+	if opts.ChunkDuration <= 0 {
+		return errors.New("chunk duration must be positive")
+	}
+	return nil
+}
+
+func validateTemplate(opts *config.VideoTemplateOptions) error {
+	if len(opts.InputPaths) == 0 {
+		return errors.New("input paths are required")
+	}
+	return nil
+}
+
+// validate checks the job's options the way submitting it does
+func (j Job) validate() error {
+	switch {
+	case j.Kind == KindSplit && j.Split != nil:
+		return validateSplit(j.Split)
+	case j.Kind == KindTemplate && j.Template != nil:
+		return validateTemplate(j.Template)
+	}
+	return fmt.Errorf("%s job has no options", j.Kind)
+}
+
+// vet checks an unfinished job before Start resumes it. API jobs go through
+// sanitize again, as jobs stored by older versions or edited in the store may
+// hold options their requests can't set, and get their output defaulted anew.
+func (m *Manager) vet(job *Job) error {
+	if job.Origin == OriginAPI {
+		if m.sanitize == nil {
+			return errors.New("API jobs can't be resumed here")
+		}
+		if err := m.sanitize(job); err != nil {
+			return err
+		}
+		m.defaultOutput(job)
+	}
+	return job.validate()
+}
+
+// defaultOutput points a job without an output location at its own directory
+func (m *Manager) defaultOutput(job *Job) {
+	switch {
+	case job.Split != nil && job.Split.OutputDir == "":
+		job.Split.OutputDir = m.JobDir(job.ID)
+	case job.Template != nil && job.Template.OutputPath == "":
+		job.Template.OutputPath = filepath.Join(m.JobDir(job.ID), "output"+videoprocessor.FileExtension(job.Template.OutputFormat))
+	}
+}
+
+// defaultTemplate fills in the template options SubmitTemplate defaults
+func defaultTemplate(opts *config.VideoTemplateOptions) {
+	if opts.OutputFormat == "" {
+		opts.OutputFormat = "webm"
+	}
+}
+
+// Key identifies the work a job does by its kind and options, outputs
+// included, so a batch rerun only skips jobs it would submit again as is.
+// Verbosity doesn't change the outputs and is left out.
+func (j Job) Key() string {
+	var opts interface{}
+	switch {
+	case j.Split != nil:
+		split := *j.Split
+		split.Verbose = false
+		opts = split
+	case j.Template != nil:
+		template := *j.Template
+		template.Verbose = false
+		opts = template
+	}
+
+	data, err := json.Marshal(opts)
+	if err != nil {
+		// Options are plain data, this only happens to a corrupted job
+		return string(j.Kind) + ":" + j.ID
+	}
+	return string(j.Kind) + ":" + string(data)
+}
+
+// SplitKey returns the Key of the job SubmitSplit queues for opts
+func SplitKey(opts config.VideoSplitterOptions) string {
+	return Job{Kind: KindSplit, Split: &opts}.Key()
+}
+
+// TemplateKey returns the Key of the job SubmitTemplate queues for opts
+func TemplateKey(opts config.VideoTemplateOptions) string {
+	defaultTemplate(&opts)
+	return Job{Kind: KindTemplate, Template: &opts}.Key()
+}
+
+// outputDir returns the directory the job writes its outputs into
+func (j Job) outputDir() string {
+	switch {
+	case j.Split != nil:
+		return j.Split.OutputDir
+	case j.Template != nil:
+		return filepath.Dir(j.Template.OutputPath)
+	}
+	return ""
+}
+
+// Get returns a copy of the job with the given ID
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// List returns copies of all jobs, oldest first
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		res = append(res, *job)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+	return res
+}
+
+// Subscribe returns a channel receiving a copy of the job on every update. The
+// channel is closed once the job is done or failed; call cancel to stop early.
+func (m *Manager) Subscribe(id string) (<-chan Job, func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, nil, fmt.Errorf("job not found: %s", id)
+	}
+
+	ch := make(chan Job, 16)
+	ch <- *job
+	if job.Finished() {
+		close(ch)
+		return ch, func() {}, nil
+	}
+	m.subs[id] = append(m.subs[id], ch)
+
+	cancel := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		subs := m.subs[id]
+		for i, sub := range subs {
+			if sub == ch {
+				m.subs[id] = append(subs[:i], subs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}
+	return ch, cancel, nil
+}
+
+// Finished reports whether the job reached a terminal state
+func (j Job) Finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed
+}
+
+// JobDir returns the directory a job writes its outputs into
+func (m *Manager) JobDir(id string) string {
+	return filepath.Join(m.workDir, id)
+}
+
+func (m *Manager) newJob(kind Kind) (*Job, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, errors.Wrap(err, "failed to generate job id")
+	}
+
+	now := time.Now()
+	return &Job{
+		ID:        hex.EncodeToString(id),
+		Kind:      kind,
+		Origin:    m.origin,
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Wait blocks until every queued job has finished
+func (m *Manager) Wait() {
+	m.pending.Wait()
+}
+
+// Drain blocks until the workers have exited after the Start context was
+// cancelled, which includes interrupted jobs finishing their cleanup
+func (m *Manager) Drain() {
+	m.workerWG.Wait()
+}
+
+func (m *Manager) enqueue(job *Job) (*Job, error) {
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	copied := *job
+	m.mu.Unlock()
+	m.persist(job.ID)
+
+	m.pending.Add(1)
+	select {
+	case m.queue <- job.ID:
+	default:
+		m.pending.Done()
+		m.update(job.ID, func(j *Job) {
+			j.Status = StatusFailed
+			j.Error = "job queue is full"
+		})
+		return nil, errors.New("job queue is full")
+	}
+
+	return &copied, nil
+}
+
+func (m *Manager) save(job Job) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.Save(job); err != nil {
+		log.Printf("Warning: failed to persist job %s: %v", job.ID, err)
+	}
+}
+
+// persist saves the job's latest state. Saves are serialized and read the job
+// only once it's their turn, so a slow save can't overwrite a newer one.
+func (m *Manager) persist(id string) {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+
+	if job, ok := m.Get(id); ok {
+		m.save(job)
+	}
+}
+
+// update applies f to the job and notifies its subscribers. Only status
+// changes are persisted, outside the lock: progress is cleared when a job
+// resumes, so saving it on every percent would only slow the workers down.
+func (m *Manager) update(id string, f func(job *Job)) {
+	if m.apply(id, f) {
+		m.persist(id)
+	}
+}
+
+// apply runs f on the job under the lock and reports whether its status changed
+func (m *Manager) apply(id string, f func(job *Job)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return false
+	}
+	status := job.Status
+	f(job)
+	job.UpdatedAt = time.Now()
+
+	// Slow subscribers miss intermediate updates but always see the final state
+	for _, sub := range m.subs[id] {
+		select {
+		case sub <- *job:
+		default:
+			if job.Finished() {
+				// Make room for the final state by dropping the oldest update
+				select {
+				case <-sub:
+				default:
+				}
+				sub <- *job
+			}
+		}
+	}
+	if job.Finished() {
+		for _, sub := range m.subs[id] {
+			close(sub)
+		}
+		delete(m.subs, id)
+	}
+	return job.Status != status
+}
+
+func (m *Manager) work(ctx context.Context) {
+	defer m.workerWG.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-m.queue:
+			m.run(ctx, id)
+		}
+	}
+}
+
+// run processes a single job. A job cut short by ctx ending keeps its running
+// status so the next Start resumes it. A job that panics fails instead of
+// taking the server down, as staying running would only resume it into the
+// same panic on every restart.
+func (m *Manager) run(ctx context.Context, id string) {
+	defer m.pending.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v\n%s", id, r, debug.Stack())
+			m.update(id, func(j *Job) {
+				j.Status = StatusFailed
+				j.Progress = ""
+				j.Error = fmt.Sprintf("internal error: %v", r)
+			})
+		}
+	}()
+
+	job, ok := m.Get(id)
+	if !ok {
+		return
+	}
+
+	m.update(id, func(j *Job) {
+		j.Status = StatusRunning
+	})
+	log.Printf("Running %s job %s\n", job.Kind, id)
+
+	// Outputs only go into the job's own directory when none was given
+	if job.outputDir() == m.JobDir(id) {
+		if err := os.MkdirAll(m.JobDir(id), 0755); err != nil {
+			m.fail(ctx, id, err)
+			return
+		}
+	}
+
+	var outputs []string
+	switch job.Kind {
+	case KindSplit:
+		opts := *job.Split
+		opts.OnProgress = m.progressReporter(id)
+		clips, err := videoprocessor.SplitVideoContext(ctx, &opts)
+		if err != nil {
+			m.fail(ctx, id, err)
+			return
+		}
+		for _, clip := range clips {
+			outputs = append(outputs, clip.FilePath)
+		}
+	case KindTemplate:
+		opts := *job.Template
+		opts.OnProgress = m.progressReporter(id)
+		output, err := videoprocessor.ApplyTemplateContext(ctx, &opts)
+		if err != nil {
+			m.fail(ctx, id, err)
+			return
+		}
+		outputs = append(outputs, output.FilePath)
+	}
+
+	m.update(id, func(j *Job) {
+		j.Status = StatusDone
+		j.Progress = ""
+		j.Outputs = outputs
+	})
+	log.Printf("Finished %s job %s\n", job.Kind, id)
+}
+
+// progressReporter returns a progress callback that records the job's progress,
+// only updating when the whole percentage or the step changes
+func (m *Manager) progressReporter(id string) func(types.ProgressEvent) {
+	var last string
+	return func(ev types.ProgressEvent) {
+		label := ev.Stage
+		if ev.Total > 0 {
+			label = fmt.Sprintf("%s %d/%d", ev.Stage, ev.Index, ev.Total)
+		}
+		progress := fmt.Sprintf("encoding %s: %d%%", label, int(ev.Percent))
+		if progress == last {
+			return
+		}
+		last = progress
+
+		m.update(id, func(j *Job) {
+			j.Progress = progress
+		})
+	}
+}
+
+func (m *Manager) fail(ctx context.Context, id string, err error) {
+	if ctx.Err() != nil {
+		log.Printf("Job %s interrupted: %v\n", id, err)
+		return
+	}
+
+	log.Printf("Job %s failed: %v\n", id, err)
+	m.update(id, func(j *Job) {
+		j.Status = StatusFailed
+		j.Error = err.Error()
+	})
+}
@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store persists jobs so unfinished work survives a crash or restart
+type Store interface {
+	Save(job Job) error
+	Load() ([]Job, error)
+	Close() error
+}
+
+var jobsBucket = []byte("jobs")
+
+// BoltStore is a Store backed by a BoltDB file
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens or creates the BoltDB file at path. Only one process can
+// hold the file open at a time.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open jobs database (is another process using it?)")
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to initialize jobs database")
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Save writes the job, replacing any previous version
+func (s *BoltStore) Save(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put([]byte(job.ID), data)
+	})
+}
+
+// Load returns every stored job, oldest first
+func (s *BoltStore) Load() ([]Job, error) {
+	var res []Job
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(k, v []byte) error {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return errors.Wrapf(err, "corrupt job %s", k)
+			}
+			res = append(res, job)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+	return res, nil
+}
+
+// Close releases the database file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -40,6 +40,14 @@ func (p *Instagram) GetAudioBitrate() string {
 	return "128k"
 }
 
+func (p *Instagram) GetFrameRate() int {
+	return 30
+}
+
+func (p *Instagram) GetMaxFrameRate() int {
+	return 60
+}
+
 func (p *Instagram) GetOutputFormat() string {
 	return "mp4"
 }
@@ -47,3 +55,11 @@ func (p *Instagram) GetOutputFormat() string {
 func (p *Instagram) ForcePortrait() bool {
 	return true
 }
+
+func (p *Instagram) SupportsHDR() bool {
+	return false
+}
+
+func (p *Instagram) GetSafeArea() SafeArea {
+	return SafeArea{Top: 0.08, Right: 0.12, Bottom: 0.2} // Reels header, action buttons and caption bar
+}
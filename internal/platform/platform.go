@@ -32,11 +32,33 @@ type Platform interface {
 	// GetAudioBitrate returns the recommended audio bitrate
 	GetAudioBitrate() string
 
+	// GetFrameRate returns the frame rate template cells are normalized to
+	GetFrameRate() int
+
+	// GetMaxFrameRate returns the highest frame rate the platform plays back;
+	// faster sources are brought down to GetFrameRate
+	GetMaxFrameRate() int
+
 	// GetOutputFormat returns the preferred output format (e.g., "mp4", "webm")
 	GetOutputFormat() string
 
 	// ForcePortrait returns whether videos should be forced into portrait orientation
 	ForcePortrait() bool
+
+	// SupportsHDR returns whether the platform accepts 10-bit HDR uploads; HDR
+	// sources are tone mapped to SDR for platforms that don't
+	SupportsHDR() bool
+
+	// GetSafeArea returns the edges of the video the platform covers with its
+	// own interface, which text and watermarks are kept out of
+	GetSafeArea() SafeArea
+}
+
+// SafeArea is the share of the video's height (Top, Bottom) or width (Left,
+// Right) along each edge that a platform's interface covers, e.g. 0.2 for the
+// bottom fifth
+type SafeArea struct {
+	Top, Right, Bottom, Left float64
 }
 
 var platforms = make(map[types.ProcessingPlatform]Platform)
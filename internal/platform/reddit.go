@@ -40,6 +40,14 @@ func (p *Reddit) GetAudioBitrate() string {
 	return "192k"
 }
 
+func (p *Reddit) GetFrameRate() int {
+	return 30
+}
+
+func (p *Reddit) GetMaxFrameRate() int {
+	return 60
+}
+
 func (p *Reddit) GetOutputFormat() string {
 	return "mp4"
 }
@@ -47,3 +55,11 @@ func (p *Reddit) GetOutputFormat() string {
 func (p *Reddit) ForcePortrait() bool {
 	return false
 }
+
+func (p *Reddit) SupportsHDR() bool {
+	return false
+}
+
+func (p *Reddit) GetSafeArea() SafeArea {
+	return SafeArea{}
+}
@@ -40,6 +40,14 @@ func (p *TikTok) GetAudioBitrate() string {
 	return "128k"
 }
 
+func (p *TikTok) GetFrameRate() int {
+	return 30
+}
+
+func (p *TikTok) GetMaxFrameRate() int {
+	return 60
+}
+
 func (p *TikTok) GetOutputFormat() string {
 	return "mp4"
 }
@@ -47,3 +55,11 @@ func (p *TikTok) GetOutputFormat() string {
 func (p *TikTok) ForcePortrait() bool {
 	return true
 }
+
+func (p *TikTok) SupportsHDR() bool {
+	return false
+}
+
+func (p *TikTok) GetSafeArea() SafeArea {
+	return SafeArea{Top: 0.08, Right: 0.15, Bottom: 0.2} // Tabs, right rail and caption
+}
@@ -40,6 +40,14 @@ func (p *TryonhaulcentralLandscape) GetAudioBitrate() string {
 	return "192k"
 }
 
+func (p *TryonhaulcentralLandscape) GetFrameRate() int {
+	return 30
+}
+
+func (p *TryonhaulcentralLandscape) GetMaxFrameRate() int {
+	return 30
+}
+
 func (p *TryonhaulcentralLandscape) GetOutputFormat() string {
 	return "mp4"
 }
@@ -47,3 +55,11 @@ func (p *TryonhaulcentralLandscape) GetOutputFormat() string {
 func (p *TryonhaulcentralLandscape) ForcePortrait() bool {
 	return false
 }
+
+func (p *TryonhaulcentralLandscape) SupportsHDR() bool {
+	return false
+}
+
+func (p *TryonhaulcentralLandscape) GetSafeArea() SafeArea {
+	return SafeArea{}
+}
@@ -40,6 +40,14 @@ func (p *Tryonhaulcentral) GetAudioBitrate() string {
 	return "192k"
 }
 
+func (p *Tryonhaulcentral) GetFrameRate() int {
+	return 30
+}
+
+func (p *Tryonhaulcentral) GetMaxFrameRate() int {
+	return 30
+}
+
 func (p *Tryonhaulcentral) GetOutputFormat() string {
 	return "mp4"
 }
@@ -47,3 +55,11 @@ func (p *Tryonhaulcentral) GetOutputFormat() string {
 func (p *Tryonhaulcentral) ForcePortrait() bool {
 	return true
 }
+
+func (p *Tryonhaulcentral) SupportsHDR() bool {
+	return false
+}
+
+func (p *Tryonhaulcentral) GetSafeArea() SafeArea {
+	return SafeArea{}
+}
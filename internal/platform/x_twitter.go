@@ -40,6 +40,14 @@ func (p *Twitter) GetAudioBitrate() string {
 	return "128k"
 }
 
+func (p *Twitter) GetFrameRate() int {
+	return 30
+}
+
+func (p *Twitter) GetMaxFrameRate() int {
+	return 60
+}
+
 func (p *Twitter) GetOutputFormat() string {
 	return "mp4"
 }
@@ -47,3 +55,11 @@ func (p *Twitter) GetOutputFormat() string {
 func (p *Twitter) ForcePortrait() bool {
 	return false
 }
+
+func (p *Twitter) SupportsHDR() bool {
+	return false
+}
+
+func (p *Twitter) GetSafeArea() SafeArea {
+	return SafeArea{}
+}
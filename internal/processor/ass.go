@@ -0,0 +1,164 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// assCenter is the ASS alignment centering text in the frame
+const assCenter = 5
+
+// assAlignments are the ASS alignments of the text positions, numbered like a
+// numpad
+var assAlignments = map[string]int{
+	textBottomLeft:  1,
+	textBottomRight: 3,
+	textCenter:      assCenter,
+	textTopLeft:     7,
+	textTopRight:    9,
+}
+
+// assStyle is how libass draws overlay text, in pixels of the frame it's
+// drawn on
+type assStyle struct {
+	fontSize  int
+	color     string  // ffmpeg color name or hex code
+	alignment int     // ASS alignment of the text block
+	marginL   int     // Distance from the left edge
+	marginR   int     // Distance from the right edge
+	marginV   int     // Distance from the top or bottom edge
+	fadeIn    float64 // Seconds the text fades in over, none when zero
+	box       bool    // Semi-transparent box behind the text instead of an outline and shadow
+}
+
+// assColors are the ffmpeg color names overlay text commonly uses, as
+// RRGGBB
+var assColors = map[string]string{
+	"white":   "ffffff",
+	"black":   "000000",
+	"red":     "ff0000",
+	"lime":    "00ff00",
+	"green":   "008000",
+	"blue":    "0000ff",
+	"yellow":  "ffff00",
+	"cyan":    "00ffff",
+	"magenta": "ff00ff",
+	"orange":  "ffa500",
+	"purple":  "800080",
+	"pink":    "ffc0cb",
+	"gray":    "808080",
+	"grey":    "808080",
+}
+
+// assColor converts an ffmpeg color to the &HAABBGGRR form ASS scripts use
+func assColor(color string) (string, error) {
+	name, alpha, hasAlpha := strings.Cut(color, "@")
+	rgb, ok := assColors[strings.ToLower(name)]
+	if !ok {
+		rgb = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(name), "#"), "0x")
+		if _, err := strconv.ParseUint(rgb, 16, 32); err != nil || len(rgb) != 6 {
+			return "", fmt.Errorf("color %q can't be drawn with libass; use a hex code like #ffcc00", color)
+		}
+	}
+
+	transparency := 0
+	if hasAlpha {
+		opacity, err := strconv.ParseFloat(alpha, 64)
+		if err != nil || opacity < 0 || opacity > 1 {
+			return "", fmt.Errorf("invalid opacity in color %q", color)
+		}
+		transparency = int((1 - opacity) * 255)
+	}
+	rgb = strings.ToUpper(rgb)
+	return fmt.Sprintf("&H%02X%s%s%s", transparency, rgb[4:6], rgb[2:4], rgb[0:2]), nil
+}
+
+// assText escapes text for an ASS dialogue line, keeping its line breaks
+func assText(text string) string {
+	text = strings.NewReplacer(`\`, `\\`, "{", `\{`, "}", `\}`).Replace(text)
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", `\N`)
+}
+
+// assPlacement returns the style placing text at position within margins of
+// the frame's edges, moved by text's offsets. libass centers text between the
+// left and right margins, so shifting both moves centered text too.
+func assPlacement(position string, left, top, right, bottom int, text config.TextOptions) assStyle {
+	style := assStyle{
+		alignment: assAlignments[position],
+		marginL:   left + text.OffsetX,
+		marginR:   right - text.OffsetX,
+		marginV:   bottom - text.OffsetY,
+	}
+	if strings.HasPrefix(position, "top") {
+		style.marginV = top + text.OffsetY
+	}
+	return style
+}
+
+// writeASS writes an ASS script to <name>.ass in dir drawing lines on a
+// width x height frame for as long as the video runs, returning its path.
+// libass picks fonts by family name, so a font file's family is taken to be
+// its file name, falling back to a font covering the text when it's not.
+func writeASS(dir, name string, width, height int, lines []string, style assStyle, text config.TextOptions) (string, error) {
+	primary, err := assColor(style.color)
+	if err != nil {
+		return "", err
+	}
+
+	font := "sans-serif"
+	switch {
+	case text.Font != "":
+		font = text.Font
+	case text.FontFile != "":
+		font = strings.TrimSuffix(filepath.Base(text.FontFile), filepath.Ext(text.FontFile))
+	}
+
+	// A border style of 3 draws an opaque box in the outline color
+	borderStyle, outline, shadow, outlineColor := 1, 3, 3, "&H00000000"
+	if style.box {
+		borderStyle, outline, shadow, outlineColor = 3, 5, 0, "&H80000000"
+	}
+
+	effects := ""
+	if style.fadeIn > 0 {
+		effects = fmt.Sprintf(`{\fad(%d,0)}`, int(style.fadeIn*1000))
+	}
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = assText(line)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[Script Info]\nScriptType: v4.00+\nPlayResX: %d\nPlayResY: %d\nWrapStyle: 2\nScaledBorderAndShadow: yes\n\n", width, height)
+	sb.WriteString("[V4+ Styles]\nFormat: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, " +
+		"Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, " +
+		"Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	fmt.Fprintf(&sb, "Style: Default,%s,%d,%s,%s,%s,&H80000000,0,0,0,0,100,100,0,0,%d,%d,%d,%d,%d,%d,%d,1\n\n",
+		font, style.fontSize, primary, primary, outlineColor, borderStyle, outline, shadow,
+		style.alignment, style.marginL, style.marginR, style.marginV)
+	sb.WriteString("[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	fmt.Fprintf(&sb, "Dialogue: 0,0:00:00.00,9:59:59.99,Default,,0,0,0,,%s%s\n", effects, strings.Join(escaped, `\N`))
+
+	path := filepath.Join(dir, name+".ass")
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s script: %v", name, err)
+	}
+	return path, nil
+}
+
+// drawASS renders the ASS script at path on video, finding the font file of
+// text when it's set
+func drawASS(video *ffmpeg.Stream, path string, text config.TextOptions) *ffmpeg.Stream {
+	fontsDir := ""
+	if text.FontFile != "" {
+		fontsDir = filepath.Dir(text.FontFile)
+	}
+	return video.Filter("ass", ffmpeg.Args{ffmpegWrap.ASSOption(path, fontsDir)})
+}
@@ -0,0 +1,64 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// videoExtensions are the file extensions treated as videos when scanning directories
+var videoExtensions = []string{".mp4", ".webm", ".mkv", ".avi", ".mov"}
+
+// ResolveInputs expands a directory, glob pattern or single file into the
+// matching video files, sorted by path
+func ResolveInputs(pattern string) ([]string, error) {
+	info, err := os.Stat(pattern)
+	if err == nil && !info.IsDir() {
+		return []string{pattern}, nil
+	}
+
+	var matches []string
+	if err == nil && info.IsDir() {
+		entries, err := os.ReadDir(pattern)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read input directory")
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && IsVideoFile(entry.Name()) {
+				matches = append(matches, filepath.Join(pattern, entry.Name()))
+			}
+		}
+	} else {
+		globbed, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern %q: %v", pattern, err)
+		}
+		for _, match := range globbed {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				matches = append(matches, match)
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no videos match %q", pattern)
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// IsVideoFile reports whether the file name has a known video extension
+func IsVideoFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, videoExt := range videoExtensions {
+		if ext == videoExt {
+			return true
+		}
+	}
+	return false
+}
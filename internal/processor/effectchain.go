@@ -0,0 +1,325 @@
+package processor
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+)
+
+// lutFormats are the 3D LUT file formats lut3d reads
+var lutFormats = []string{".cube", ".3dl", ".dat", ".m3d", ".csp"}
+
+// Video denoise levels: light and medium run hqdn3d, which is fast, strong
+// runs nlmeans, which keeps more detail at a much higher cost
+var denoiseLevels = []string{"light", "medium", "strong"}
+
+var denoiseFilters = map[string]config.Effect{
+	"light":  {Filter: "hqdn3d", Args: "2:1.5:3:2.25"},
+	"medium": {Filter: "hqdn3d", Args: "4:3:6:4.5"},
+	"strong": {Filter: "nlmeans", Options: map[string]string{"s": "4", "p": "7", "r": "15"}},
+}
+
+// Speed changes are bounded to quarter speed slow motion, below which even
+// interpolated motion falls apart, and 100x timelapses
+const (
+	minSpeed = 0.25
+	maxSpeed = 100.0
+)
+
+// maxBoomerangRepeat bounds boomerangs, whose frames are all kept in memory
+const maxBoomerangRepeat = 20
+
+// preprocesses reports whether an input of duration seconds needs the
+// effects pass: for the effects filter graph, blurring faces, stabilizing, a speed change, a
+// boomerang or looping it up to the minimum duration
+func preprocesses(effects string, opts config.EffectOptions, duration float64) bool {
+	speed := cmp.Or(opts.Speed, 1)
+	return effects != "" || opts.BlurFaces || opts.Stabilize || speed != 1 || opts.Boomerang || duration/speed < opts.MinDuration
+}
+
+// loadEffects returns the filter graph blurring regions of the input,
+// cropping it, denoising it, grading it with the LUT and look of opts and then
+// running its effect chain, or "" when none are set. Stabilizing takes a detection pass of its own and speed changes
+// retime the audio too, so they're left to ApplyEffects.
+// Named filters the installed ffmpeg lacks are rejected up front rather than
+// after a failed encode.
+func loadEffects(opts config.EffectOptions) (string, error) {
+	var filters []string
+
+	for i, region := range opts.BlurRegions {
+		blur, err := blurRegionFilter(region, i)
+		if err != nil {
+			return "", err
+		}
+		filters = append(filters, blur)
+	}
+
+	if opts.BlurFaces && opts.FaceDetector == "" {
+		return "", fmt.Errorf("blurring faces needs a face detector command, ffmpeg has no face detection of its own")
+	}
+
+	if opts.Crop != "" {
+		crop, err := cropFilter(opts.Crop, opts.CropKeyframes)
+		if err != nil {
+			return "", err
+		}
+		filters = append(filters, crop)
+	} else if opts.CropKeyframes != "" {
+		return "", fmt.Errorf("crop keyframes need a crop region for the size")
+	}
+
+	if opts.Speed != 0 && (opts.Speed < minSpeed || opts.Speed > maxSpeed) {
+		return "", fmt.Errorf("speed %g must be between %g and %g", opts.Speed, minSpeed, maxSpeed)
+	}
+	if opts.Interpolate && cmp.Or(opts.Speed, 1) >= 1 {
+		return "", fmt.Errorf("interpolating frames needs a speed below 1")
+	}
+	if opts.SpeedAudio != "" && opts.SpeedAudio != config.SpeedAudioTempo && opts.SpeedAudio != config.SpeedAudioMute {
+		return "", fmt.Errorf("unsupported speed audio: %s (supported: %s, %s)", opts.SpeedAudio, config.SpeedAudioTempo, config.SpeedAudioMute)
+	}
+	if opts.MinDuration < 0 {
+		return "", fmt.Errorf("minimum duration %gs can't be negative", opts.MinDuration)
+	}
+	if opts.LoopMode != "" && opts.LoopMode != config.LoopForward && opts.LoopMode != config.LoopPingPong {
+		return "", fmt.Errorf("unsupported loop mode: %s (supported: %s, %s)", opts.LoopMode, config.LoopForward, config.LoopPingPong)
+	}
+	if opts.BoomerangRepeat < 0 || opts.BoomerangRepeat > maxBoomerangRepeat {
+		return "", fmt.Errorf("boomerang repeat %d must be between 1 and %d", opts.BoomerangRepeat, maxBoomerangRepeat)
+	}
+	if opts.Stabilize && (!ffmpegWrap.HasFilter("vidstabdetect") || !ffmpegWrap.HasFilter("vidstabtransform")) {
+		return "", fmt.Errorf("stabilizing needs an ffmpeg build with libvidstab")
+	}
+
+	if opts.Denoise != "" {
+		effect, ok := denoiseFilters[opts.Denoise]
+		if !ok {
+			return "", fmt.Errorf("unsupported denoise level: %s (supported: %s)", opts.Denoise, strings.Join(denoiseLevels, ", "))
+		}
+		denoise := &config.EffectChain{Effects: []config.Effect{effect}}
+		if err := checkEffectFilters(denoise, opts.Denoise+" denoising"); err != nil {
+			return "", err
+		}
+		filters = append(filters, denoise.Filter())
+	}
+
+	if opts.LUTFile != "" {
+		if ext := strings.ToLower(filepath.Ext(opts.LUTFile)); !slices.Contains(lutFormats, ext) {
+			return "", fmt.Errorf("unsupported LUT format %q (supported: %s)", ext, strings.Join(lutFormats, ", "))
+		}
+		if _, err := os.Stat(opts.LUTFile); err != nil {
+			return "", fmt.Errorf("LUT file not found: %v", err)
+		}
+		if !ffmpegWrap.HasFilter("lut3d") {
+			return "", fmt.Errorf("LUT grading needs an ffmpeg build with the lut3d filter")
+		}
+		filters = append(filters, ffmpegWrap.LUTFilter(opts.LUTFile))
+	}
+
+	if opts.Look != "" {
+		look, ok := config.Looks[opts.Look]
+		if !ok {
+			return "", fmt.Errorf("unsupported look: %s (supported: %s)", opts.Look, strings.Join(config.LookNames(), ", "))
+		}
+		if err := checkEffectFilters(&look, "the "+opts.Look+" look"); err != nil {
+			return "", err
+		}
+		filters = append(filters, look.Filter())
+	}
+
+	if opts.EffectsFile != "" {
+		chain, err := config.LoadEffectChain(opts.EffectsFile)
+		if err != nil {
+			return "", err
+		}
+		if err := checkEffectFilters(chain, "effect chain "+opts.EffectsFile); err != nil {
+			return "", err
+		}
+		filters = append(filters, chain.Filter())
+	}
+
+	return strings.Join(filters, ","), nil
+}
+
+// sharpenRe matches unsharp options, positional like "5:5:0.5" or named like
+// "luma_amount=0.5", without any filter graph syntax
+var sharpenRe = regexp.MustCompile(`^[a-z_]*=?-?[0-9.]+(:[a-z_]*=?-?[0-9.]+)*$`)
+
+// checkSharpen rejects malformed unsharp options and ffmpeg builds without
+// the unsharp filter
+func checkSharpen(sharpen string) error {
+	if sharpen == "" {
+		return nil
+	}
+	if !sharpenRe.MatchString(sharpen) {
+		return fmt.Errorf("invalid sharpen options %q, expected unsharp options like %q", sharpen, config.DefaultSharpen)
+	}
+	if !ffmpegWrap.HasFilter("unsharp") {
+		return fmt.Errorf("sharpening needs an ffmpeg build with the unsharp filter")
+	}
+	return nil
+}
+
+// cropRe matches crop regions, WxH+X+Y in pixels
+var cropRe = regexp.MustCompile(`^(\d+)x(\d+)\+(\d+)\+(\d+)$`)
+
+// cropFilter returns the crop filter keeping the WxH+X+Y region of crop,
+// moved along the "seconds X Y" lines of the keyframes file when it's set
+func cropFilter(crop, keyframesPath string) (string, error) {
+	match := cropRe.FindStringSubmatch(crop)
+	if match == nil {
+		return "", fmt.Errorf("invalid crop %q, expected WxH+X+Y, e.g. 1080x1080+420+0", crop)
+	}
+	if match[1] == "0" || match[2] == "0" {
+		return "", fmt.Errorf("crop %q has no area", crop)
+	}
+	x, y := match[3], match[4]
+
+	if keyframesPath != "" {
+		data, err := os.ReadFile(keyframesPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read crop keyframes: %v", err)
+		}
+
+		var times, xs, ys []float64
+		for i, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			var values [3]float64
+			ok := len(fields) == len(values)
+			for j := 0; ok && j < len(values); j++ {
+				values[j], err = strconv.ParseFloat(fields[j], 64)
+				ok = err == nil && values[j] >= 0
+			}
+			if !ok {
+				return "", fmt.Errorf("crop keyframes line %d: expected \"seconds X Y\", got %q", i+1, line)
+			}
+			if len(times) > 0 && values[0] <= times[len(times)-1] {
+				return "", fmt.Errorf("crop keyframes line %d: %gs isn't after the previous keyframe", i+1, values[0])
+			}
+			times, xs, ys = append(times, values[0]), append(xs, values[1]), append(ys, values[2])
+		}
+		if len(times) == 0 {
+			return "", fmt.Errorf("crop keyframes file %s has no keyframes", keyframesPath)
+		}
+		x, y = "'"+ffmpegWrap.KeyframeExpr(times, xs)+"'", "'"+ffmpegWrap.KeyframeExpr(times, ys)+"'"
+	}
+
+	return fmt.Sprintf("crop=w=%s:h=%s:x=%s:y=%s", match[1], match[2], x, y), nil
+}
+
+// blurRegionFilter returns the filter blurring the "x,y,w,h[,start,end]"
+// rectangle of region, in pixels and seconds, throughout the video when no
+// time range is given. It's a graph of its own, the rectangle cropped out,
+// blurred and overlaid back, with labels numbered by index to keep them
+// unique.
+func blurRegionFilter(region string, index int) (string, error) {
+	fields := strings.Split(region, ",")
+	if len(fields) != 4 && len(fields) != 6 {
+		return "", fmt.Errorf("invalid blur region %q, expected x,y,w,h or x,y,w,h,start,end", region)
+	}
+	var box [4]int
+	for i := range box {
+		n, err := strconv.Atoi(strings.TrimSpace(fields[i]))
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("invalid blur region %q: %q isn't a pixel count", region, fields[i])
+		}
+		box[i] = n
+	}
+	x, y, w, h := box[0], box[1], box[2], box[3]
+	if w < 2 || h < 2 {
+		return "", fmt.Errorf("blur region %q is too small, it needs to be at least 2x2", region)
+	}
+
+	overlay := fmt.Sprintf("overlay=%d:%d", x, y)
+	if len(fields) == 6 {
+		var times [2]float64
+		for i := range times {
+			t, err := strconv.ParseFloat(strings.TrimSpace(fields[4+i]), 64)
+			if err != nil || t < 0 {
+				return "", fmt.Errorf("invalid blur region %q: %q isn't a time in seconds", region, fields[4+i])
+			}
+			times[i] = t
+		}
+		if times[1] <= times[0] {
+			return "", fmt.Errorf("blur region %q ends before it starts", region)
+		}
+		overlay += fmt.Sprintf(":enable='between(t,%g,%g)'", times[0], times[1])
+	}
+
+	// The radius is bounded by half the chroma plane, a quarter of the region
+	radius := max(min(w, h)/4, 1)
+	main, rect, blurred := fmt.Sprintf("[blur%dmain]", index), fmt.Sprintf("[blur%drect]", index), fmt.Sprintf("[blur%d]", index)
+	return fmt.Sprintf("split%s%s;%scrop=%d:%d:%d:%d,boxblur=luma_radius=%d:luma_power=3%s;%s%s%s",
+		main, rect, rect, w, h, x, y, radius, blurred, main, blurred, overlay), nil
+}
+
+// maxFaceBlurs bounds the faces blurred, each a crop, blur and overlay of
+// its own in the filter graph
+const maxFaceBlurs = 200
+
+// facePadding is the share of a face's size its blur reaches past it on
+// every side, covering hair and movement within the detected stretch
+const facePadding = 0.2
+
+// withFaceBlurs returns the effects filter graph of inputPath with the faces
+// opts.FaceDetector finds in it blurred first, or effects as is when faces
+// aren't blurred
+func withFaceBlurs(p *ffmpegWrap.Processor, inputPath, effects string, opts config.EffectOptions) (string, error) {
+	if !opts.BlurFaces {
+		return effects, nil
+	}
+
+	metadata, err := ffmpegWrap.GetVideoMetadata(inputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get video metadata: %v", err)
+	}
+	faces, err := p.DetectFaces(opts.FaceDetector, inputPath)
+	if err != nil {
+		return "", err
+	}
+	if len(faces) > maxFaceBlurs {
+		return "", fmt.Errorf("face detector found %d faces, more than the %d that can be blurred; merge detections that stay in place", len(faces), maxFaceBlurs)
+	}
+
+	filters := make([]string, 0, len(faces)+1)
+	for i, face := range faces {
+		padX, padY := int(float64(face.W)*facePadding), int(float64(face.H)*facePadding)
+		x, y := max(face.X-padX, 0), max(face.Y-padY, 0)
+		w, h := min(face.X+face.W+padX, metadata.Width)-x, min(face.Y+face.H+padY, metadata.Height)-y
+		if w < 2 || h < 2 {
+			continue
+		}
+
+		// Labels are numbered after the blur regions'
+		region := fmt.Sprintf("%d,%d,%d,%d,%g,%g", x, y, w, h, face.Start, face.End)
+		blur, err := blurRegionFilter(region, len(opts.BlurRegions)+i)
+		if err != nil {
+			return "", err
+		}
+		filters = append(filters, blur)
+	}
+	if effects != "" {
+		filters = append(filters, effects)
+	}
+	return strings.Join(filters, ","), nil
+}
+
+// checkEffectFilters rejects chains using filters the installed ffmpeg lacks
+func checkEffectFilters(chain *config.EffectChain, name string) error {
+	for _, effect := range chain.Effects {
+		if effect.Filter != "" && !ffmpegWrap.HasFilter(effect.Filter) {
+			return fmt.Errorf("%s uses the %s filter, which this ffmpeg build lacks", name, effect.Filter)
+		}
+	}
+	return nil
+}
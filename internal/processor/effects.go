@@ -1,11 +1,20 @@
 package processor
 
 import (
+	"cmp"
 	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/ZacxDev/video-splitter/config"
 	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/ZacxDev/video-splitter/internal/platform"
 	"github.com/pkg/errors"
 	ffmpeg "github.com/u2takey/ffmpeg-go"
 )
@@ -15,8 +24,22 @@ func (t *Templater) ApplyObscurifyEffects(inputPath, outputPath string) error {
 	if outputFormat == "" {
 		outputFormat = "mp4"
 	}
-	if outputFormat != "webm" && outputFormat != "mp4" {
-		return fmt.Errorf("unsupported output format: %s (supported: webm, mp4)", outputFormat)
+	if !ffmpegWrap.IsSupportedFormat(outputFormat) {
+		return fmt.Errorf("unsupported output format: %s (supported: %s)",
+			outputFormat, strings.Join(ffmpegWrap.SupportedFormats(), ", "))
+	}
+
+	if err := checkObscurify(t.opts.ObscurifyOptions); err != nil {
+		return err
+	}
+	// The speed change has to match between video and audio
+	speed := 1.0
+	if t.obscurifies("speed") {
+		speed = 1 + 0.02*t.obscurifyStrength()
+	}
+	audioFilter, err := t.obscurifyAudioFilter(speed)
+	if err != nil {
+		return err
 	}
 
 	metadata, err := ffmpegWrap.GetVideoMetadata(inputPath)
@@ -24,24 +47,21 @@ func (t *Templater) ApplyObscurifyEffects(inputPath, outputPath string) error {
 		return errors.Wrap(err, "failed to get video metadata")
 	}
 
-	// Calculate dimensions for zoom effect
-	zoomScale := 1.025
-	zoomWidth := int(float64(metadata.Width) * zoomScale)
-	zoomHeight := int(float64(metadata.Height) * zoomScale)
-
-	videoFilters := []string{
-		fmt.Sprintf("scale=%d:%d", zoomWidth, zoomHeight),
-		fmt.Sprintf("crop=%d:%d", metadata.Width, metadata.Height),
-		"eq=gamma=1.05:saturation=1.2:contrast=1.1",
-		"unsharp=3:3:1.5:3:3:0.5",
-		"vignette=a=0.628319:x0=w/2:y0=h/2", // PI/5 ≈ 0.628319
+	videoFilters := t.obscurifyVideoFilters(metadata, speed)
+	if t.opts.Verbose {
+		log.Printf("Obscurify video filters: %s\n", strings.Join(videoFilters, ","))
 	}
 
 	// Join filters with comma
-	filterComplex := strings.Join(videoFilters, ",")
+	filterComplex := cmp.Or(t.ffmpeg.WithTonemap(strings.Join(videoFilters, ","), metadata), "null")
 
-	// Create input stream
-	stream := ffmpeg.Input(inputPath)
+	// Create input stream, skipping the first few frames for the offset
+	inputArgs := ffmpeg.KwArgs{}
+	if t.obscurifies("offset") {
+		frames := max(math.Round(2*t.obscurifyStrength()), 1)
+		inputArgs["ss"] = fmt.Sprintf("%.3f", frames/cmp.Or(metadata.FrameRate, 30))
+	}
+	stream := ffmpeg.Input(inputPath, inputArgs)
 
 	codecSettings := ffmpegWrap.GetCodecSettings(outputFormat)
 	outputKwargs := ffmpeg.KwArgs{
@@ -51,75 +71,272 @@ func (t *Templater) ApplyObscurifyEffects(inputPath, outputPath string) error {
 	}
 
 	// Apply format-specific encoder settings
-	for k, v := range codecSettings.EncoderPresets["balanced"] {
+	for k, v := range t.ffmpeg.EncoderPreset(codecSettings) {
 		outputKwargs[k] = v
 	}
 
 	// Add audio effects
-	audioFilter := fmt.Sprintf(
-		"aresample=48000,asetrate=48000*1.05,atempo=0.95",
-	)
-	outputKwargs["af"] = audioFilter
+	if audioFilter != "" && metadata.HasAudio {
+		outputKwargs["af"] = audioFilter
+	} else if audioFilter != "" && t.opts.Verbose {
+		log.Printf("%s has no audio, skipping the audio effects\n", inputPath)
+	}
 
 	// Ensure correct output extension
 	outputPath = ffmpegWrap.EnsureExtension(outputPath, codecSettings.FileExtension)
 
-	if err := stream.Output(outputPath, outputKwargs).
-		OverWriteOutput().
-		ErrorToStdOut().
-		Run(); err != nil {
+	var duration float64
+	if metadata, err := ffmpegWrap.GetVideoMetadata(inputPath); err == nil {
+		duration = metadata.Duration
+	}
+
+	if err := t.ffmpeg.Run(stream.Output(outputPath, t.ffmpeg.OutputArgs(outputKwargs, metadata)), duration); err != nil {
 		return errors.Wrap(err, "failed to apply obscurify effects")
 	}
 
 	return nil
 }
 
-// AddTextOverlay adds text overlay to a video
-func AddTextOverlay(stream *ffmpeg.Stream, text, position string) *ffmpeg.Stream {
-	// Escape single quotes in the text
-	escapedText := strings.ReplaceAll(text, "'", "'\\''")
+// checkObscurify rejects unknown obscurify effects and strengths or jitter
+// out of range
+func checkObscurify(opts config.ObscurifyOptions) error {
+	for _, effect := range opts.ObscurifyEffects {
+		if !slices.Contains(config.ObscurifyEffects, effect) {
+			return fmt.Errorf("unsupported obscurify effect: %s (supported: %s)",
+				effect, strings.Join(config.ObscurifyEffects, ", "))
+		}
+	}
+	if opts.ObscurifyStrength < 0 || opts.ObscurifyStrength > 4 {
+		return fmt.Errorf("obscurify strength %g must be between 0 and 4", opts.ObscurifyStrength)
+	}
+	if opts.ObscurifyJitter < 0 || opts.ObscurifyJitter > 1 {
+		return fmt.Errorf("obscurify jitter %g must be between 0 and 1", opts.ObscurifyJitter)
+	}
+	return nil
+}
+
+// obscurifies reports whether obscurify applies effect
+func (t *Templater) obscurifies(effect string) bool {
+	effects := t.opts.ObscurifyEffects
+	if len(effects) == 0 {
+		effects = config.DefaultObscurifyEffects
+	}
+	return slices.Contains(effects, effect)
+}
+
+// obscurifyStrength returns the strength of a transform, varied by the jitter
+func (t *Templater) obscurifyStrength() float64 {
+	strength := cmp.Or(t.opts.ObscurifyStrength, config.DefaultObscurifyStrength)
+	return strength * (1 + t.opts.ObscurifyJitter*(2*t.rng.Float64()-1))
+}
+
+// obscurifyVideoFilters returns the obscurify video filters of a video with
+// metadata, each scaled by the strength and varied by the jitter, playing at
+// speed times the original
+func (t *Templater) obscurifyVideoFilters(metadata *ffmpegWrap.VideoMetadata, speed float64) []string {
+	width, height := metadata.Width, metadata.Height
+
+	var filters []string
+	if t.obscurifies("mirror") {
+		filters = append(filters, "hflip")
+	}
+	if t.obscurifies("rotate") {
+		// Up to a degree at full strength, zoomed in just enough to cover the
+		// corners the rotation uncovers
+		angle := math.Pi / 180 * t.obscurifyStrength()
+		cover := math.Cos(angle) + math.Sin(math.Abs(angle))*float64(max(width, height))/float64(min(width, height))
+		filters = append(filters,
+			fmt.Sprintf("rotate=%.5f", angle),
+			fmt.Sprintf("scale=%d:%d", int(float64(width)*cover+1)&^1, int(float64(height)*cover+1)&^1),
+			fmt.Sprintf("crop=%d:%d", width, height))
+	}
+	if t.obscurifies("zoom") {
+		// Zoom in and crop back to the original size, keeping dimensions even
+		scale := 1 + 0.025*t.obscurifyStrength()
+		filters = append(filters,
+			fmt.Sprintf("scale=%d:%d", int(float64(width)*scale)&^1, int(float64(height)*scale)&^1),
+			fmt.Sprintf("crop=%d:%d", width, height))
+	}
+	if t.obscurifies("crop") {
+		// Cut off a border of 2% of the smaller side at full strength
+		border := int(float64(min(width, height))*0.02*t.obscurifyStrength()) &^ 1
+		width, height = width-2*border, height-2*border
+		filters = append(filters, fmt.Sprintf("crop=%d:%d", width, height))
+	}
+	if t.obscurifies("color") {
+		s := t.obscurifyStrength()
+		filters = append(filters, fmt.Sprintf("eq=gamma=%.3f:saturation=%.3f:contrast=%.3f", 1+0.05*s, 1+0.2*s, 1+0.1*s))
+	}
+	if t.obscurifies("sharpen") {
+		s := t.obscurifyStrength()
+		filters = append(filters, fmt.Sprintf("unsharp=3:3:%.3f:3:3:%.3f", min(1.5*s, 5), min(0.5*s, 5)))
+	}
+	if t.obscurifies("grain") {
+		filters = append(filters, fmt.Sprintf("noise=alls=%d:allf=t", min(int(6*t.obscurifyStrength()), 100)))
+	}
+	if t.obscurifies("vignette") {
+		// PI/5 at full strength, vignette's angle tops out at PI/2
+		filters = append(filters, fmt.Sprintf("vignette=a=%.6f:x0=w/2:y0=h/2", min(math.Pi/5*t.obscurifyStrength(), math.Pi/2)))
+	}
+	if speed != 1 {
+		filters = append(filters, fmt.Sprintf("setpts=PTS/%.4f", speed))
+	}
+	return filters
+}
+
+// obscurifyAudioFilter returns the obscurify audio filter chain playing at
+// speed times the original, or an empty string when the audio is kept.
+// Resampling at a higher rate raises the pitch and speeds the audio up,
+// atempo then adjusts the speed alone.
+func (t *Templater) obscurifyAudioFilter(speed float64) (string, error) {
+	if t.opts.ObscurifyKeepAudio || !t.obscurifies("pitch") {
+		if speed != 1 {
+			return fmt.Sprintf("atempo=%.4f", speed), nil
+		}
+		return "", nil
+	}
+
+	pitch := t.opts.ObscurifyPitch
+	if pitch == 0 {
+		pitch = config.DefaultObscurifyPitch
+	}
+	tempo := t.opts.ObscurifyTempo
+	if tempo == 0 {
+		tempo = config.DefaultObscurifyTempo
+	}
+
+	if pitch < 0.5 || pitch > 2 {
+		return "", fmt.Errorf("obscurify pitch %g must be between 0.5 and 2", pitch)
+	}
+	// atempo's own limits
+	if tempo < 0.5 || tempo > 100 {
+		return "", fmt.Errorf("obscurify tempo %g must be between 0.5 and 100", tempo)
+	}
+
+	return fmt.Sprintf("aresample=48000,asetrate=48000*%g,atempo=%g", pitch, tempo*speed), nil
+}
+
+// Text overlay positions
+const (
+	textTopLeft     = "top-left"
+	textTopRight    = "top-right"
+	textBottomLeft  = "bottom-left"
+	textBottomRight = "bottom-right"
+	textCenter      = "center"
+)
+
+var textPositions = []string{textTopLeft, textTopRight, textBottomLeft, textBottomRight, textCenter}
 
-	var x, y string
+// textPosition returns drawtext's x and y expressions placing a block of text
+// blockH tall at position, m from the edges and moved by text's offsets. The
+// block is placed as a whole, each line as wide as its own text.
+func textPosition(position string, m overlayMargins, blockH string, text config.TextOptions) (x, y string) {
 	switch position {
-	case "bottom-right":
-		x = "w-tw-20"
-		y = "h-th-20"
-	case "bottom-left":
-		x = "20"
-		y = "h-th-20"
-	case "top-right":
-		x = "w-tw-20"
-		y = "20"
-	case "top-left":
-		x = "20"
-		y = "20"
+	case textTopLeft:
+		x, y = m.left, m.top
+	case textTopRight:
+		x, y = "w-tw-"+m.right, m.top
+	case textBottomLeft:
+		x, y = m.left, "h-"+blockH+"-"+m.bottom
+	case textCenter:
+		x, y = "(w-tw)/2", "(h-"+blockH+")/2"
 	default:
-		x = "w-tw-20"
-		y = "h-th-20"
+		x, y = "w-tw-"+m.right, "h-"+blockH+"-"+m.bottom
 	}
+	return withOffset(x, text.OffsetX), withOffset(y, text.OffsetY)
+}
 
-	drawTextFilter := fmt.Sprintf(
-		"text='%s':"+
-			"fontsize=%s:"+
+// withOffset returns expr moved by offset pixels
+func withOffset(expr string, offset int) string {
+	if offset == 0 {
+		return expr
+	}
+	return fmt.Sprintf("%s%+d", expr, offset)
+}
+
+// addTextOverlay draws lines of text on a video at text's position, or
+// position when it has none, kept out of the area a platform covers with its
+// interface, in the font and animation of text
+func addTextOverlay(stream *ffmpeg.Stream, lines []overlayLine, position string, area platform.SafeArea, text config.TextOptions) *ffmpeg.Stream {
+	padding, _ := strconv.Atoi(config.TextPadding)
+	fontSize, _ := strconv.Atoi(config.TextSize)
+	spacing := lineSpacing(fontSize)
+	x, y := textPosition(cmp.Or(text.Position, position), safeMargins(area, padding, "w", "h"), blockHeight(len(lines), spacing), text)
+
+	style := fmt.Sprintf(
+		"fontsize=%s:"+
 			"fontcolor=%s:"+
 			"bordercolor=%s:"+
 			"borderw=%s:"+
-			"x=%s:"+
-			"y=%s:"+
 			"shadowcolor=black:"+
 			"shadowx=2:"+
 			"shadowy=2:"+
 			"box=1:"+
 			"boxcolor=black@0.5:"+
 			"boxborderw=5",
-		escapedText,
 		config.TextSize,
 		config.TextColor,
 		config.TextBorderColor,
 		config.TextBorderWidth,
-		x,
-		y,
 	)
+	return drawOverlayLines(stream, lines, style, x, y, spacing, text)
+}
+
+// lineSpacing returns the distance between the tops of consecutive lines of
+// text in fontSize
+func lineSpacing(fontSize int) int {
+	return fontSize * 4 / 3
+}
 
-	return stream.Filter("drawtext", ffmpeg.Args{drawTextFilter})
+// blockHeight returns the drawtext expression for the height of a block of
+// lines spacing apart. Blocks of several lines are measured by the font
+// rather than each line's own text so every line agrees on it.
+func blockHeight(lines, spacing int) string {
+	if lines == 1 {
+		return "th"
+	}
+	return fmt.Sprintf("(max_glyph_h+%d)", (lines-1)*spacing)
+}
+
+// overlayLines splits text into the lines to draw: at its line breaks,
+// including "\n" typed literally on the command line, and at the last space
+// within wrap characters when wrap is set. Words longer than wrap get a line
+// of their own.
+func overlayLines(text string, wrap int) []string {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, `\n`, "\n"), "\r\n", "\n")
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if wrap <= 0 {
+			lines = append(lines, line)
+			continue
+		}
+
+		current := ""
+		for _, word := range strings.Fields(line) {
+			switch {
+			case current == "":
+				current = word
+			case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= wrap:
+				current += " " + word
+			default:
+				lines = append(lines, current)
+				current = word
+			}
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// writeOverlayLines writes each line to <name>_line_<n>.txt in dir for drawing
+// with ffmpeg.TextFileOption, returning their paths
+func writeOverlayLines(dir, name string, lines []string) ([]string, error) {
+	paths := make([]string, len(lines))
+	for i, line := range lines {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%s_line_%d.txt", name, i+1))
+		if err := os.WriteFile(paths[i], []byte(line), 0644); err != nil {
+			return nil, errors.Wrap(err, "failed to write overlay text")
+		}
+	}
+	return paths, nil
 }
@@ -0,0 +1,115 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// ExtractAudio writes the audio track of opts.InputPath to opts.OutputDir,
+// as one file or split on the chunk boundaries a split with the same chunk
+// duration and skip would use, so file names match the video chunks.
+// It stops ffmpeg and returns ctx's error once ctx is done.
+func ExtractAudio(ctx context.Context, opts *config.ExtractAudioOptions) ([]types.ProcessedClip, error) {
+	format := strings.ToLower(opts.Format)
+	if !ffmpeg.IsAudioFormat(format) {
+		return nil, fmt.Errorf("unsupported audio format: %s (supported: %s)",
+			opts.Format, strings.Join(ffmpeg.AudioFormats(), ", "))
+	}
+	if opts.ChunkDuration < 0 {
+		return nil, fmt.Errorf("chunk duration %ds can't be negative", opts.ChunkDuration)
+	}
+
+	p := ffmpeg.NewProcessor(opts.Verbose)
+	p.SetContext(ctx)
+
+	var progress progressTracker
+	progress.attach(p, opts.OnProgress)
+
+	metadata, err := ffmpeg.GetVideoMetadata(opts.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video metadata: %v", err)
+	}
+	if !metadata.HasAudio {
+		return nil, fmt.Errorf("%s has no audio track", opts.InputPath)
+	}
+
+	skipSeconds, err := parseSkipDuration(opts.Skip)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	duration := metadata.Duration - skipSeconds
+	if duration <= 0 {
+		return nil, fmt.Errorf("skip duration exceeds video duration")
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	baseFileName := baseName(opts.InputPath)
+	ext := ffmpeg.AudioFileExtension(format)
+
+	numChunks := 1
+	if opts.ChunkDuration > 0 {
+		numChunks = int(duration) / opts.ChunkDuration
+		if int(duration)%opts.ChunkDuration != 0 {
+			numChunks++
+		}
+	}
+
+	res := make([]types.ProcessedClip, 0, numChunks)
+	for i := 0; i < numChunks; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		name := baseFileName
+		startTime := skipSeconds
+		chunkDuration := 0
+		if opts.ChunkDuration > 0 {
+			name, err = renderName(config.DefaultChunkNameTemplate, NameData{Base: baseFileName, Stage: "chunk", Index: i + 1})
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			startTime += float64(i * opts.ChunkDuration)
+			chunkDuration = opts.ChunkDuration
+		}
+		outputPath := filepath.Join(opts.OutputDir, name+ext)
+
+		if opts.Verbose {
+			log.Printf("Extracting audio %d/%d: %s\n", i+1, numChunks, outputPath)
+		}
+
+		progress.set("extract", i+1, numChunks)
+		if err := p.ExtractAudio(opts.InputPath, outputPath, format, startTime, chunkDuration, opts.Bitrate); err != nil {
+			// Don't leave a half written file behind
+			os.Remove(outputPath)
+			return nil, fmt.Errorf("error extracting audio %d: %v", i+1, err)
+		}
+
+		clipDuration := metadata.Duration - startTime
+		if chunkDuration > 0 {
+			clipDuration = min(clipDuration, float64(chunkDuration))
+		}
+		res = append(res, types.ProcessedClip{
+			FilePath:        outputPath,
+			DurationSeconds: uint64(clipDuration),
+		})
+	}
+
+	return res, nil
+}
+
+// AudioFormats returns the formats ExtractAudio writes
+func AudioFormats() []string {
+	return ffmpeg.AudioFormats()
+}
@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+)
+
+// NameData holds the values available to output name templates
+type NameData struct {
+	Base     string // Sanitized input file name without extension
+	Platform string // Target platform name, empty when none was given
+	Stage    string // Processing stage, e.g. "chunk", "cropped", "optimized", "final"
+	Index    int    // 1-based chunk or input index
+}
+
+// renderName executes a name template and sanitizes the result for use as a file name
+func renderName(tmpl string, data NameData) (string, error) {
+	t, err := template.New("name").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid name template: %v", err)
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render name template: %v", err)
+	}
+
+	name := sanitizeFilename(sb.String())
+	if name == "" {
+		return "", fmt.Errorf("name template %q rendered an empty file name", tmpl)
+	}
+
+	return name, nil
+}
+
+// validateIndexedNameTemplate ensures a template yields a distinct name per index
+// so that chunks don't overwrite each other
+func validateIndexedNameTemplate(tmpl string) error {
+	first, err := renderName(tmpl, NameData{Base: "base", Stage: "chunk", Index: 1})
+	if err != nil {
+		return err
+	}
+	second, err := renderName(tmpl, NameData{Base: "base", Stage: "chunk", Index: 2})
+	if err != nil {
+		return err
+	}
+	if first == second {
+		return fmt.Errorf("name template %q must include {{.Index}}", tmpl)
+	}
+	return nil
+}
+
+// baseName returns the sanitized file name of path without its extension,
+// or the name of an image sequence pattern
+func baseName(path string) string {
+	if ffmpegWrap.IsImageSequence(path) {
+		return sanitizeFilename(ffmpegWrap.SequenceName(path))
+	}
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return sanitizeFilename(base)
+}
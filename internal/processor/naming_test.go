@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/ZacxDev/video-splitter/config"
+)
+
+func TestRenderName(t *testing.T) {
+	data := NameData{Base: "clip", Platform: "tiktok", Stage: "chunk", Index: 7}
+	tests := []struct {
+		name    string
+		tmpl    string
+		data    NameData
+		want    string
+		wantErr bool
+	}{
+		{name: "default", tmpl: config.DefaultChunkNameTemplate, data: data, want: "clip_chunk_007"},
+		{name: "all fields", tmpl: "{{.Platform}}-{{.Stage}}-{{.Base}}-{{.Index}}", data: data, want: "tiktok-chunk-clip-7"},
+		{name: "literal", tmpl: "output", data: data, want: "output"},
+		{name: "unsafe characters", tmpl: "{{.Base}} / {{.Platform}}?*", data: data, want: "clip_tiktok"},
+		{name: "non-ascii", tmpl: "café {{.Index}}", data: data, want: "caf_7"},
+		{name: "repeated underscores", tmpl: "{{.Base}}___{{.Index}}", data: data, want: "clip_7"},
+		{name: "trimmed underscores", tmpl: "__{{.Base}}__", data: data, want: "clip"},
+		{name: "path separators", tmpl: "../{{.Base}}", data: data, want: ".._clip"},
+		{name: "extension dropped", tmpl: "{{.Base}}.mp4", data: data, want: "clip"},
+		{name: "dots kept", tmpl: "{{.Base}}.v2", data: data, want: "clip.v2"},
+		{name: "empty platform", tmpl: "{{.Base}}_{{.Platform}}", data: NameData{Base: "clip"}, want: "clip"},
+
+		{name: "empty", tmpl: "", data: data, wantErr: true},
+		{name: "renders empty", tmpl: "{{.Platform}}", data: NameData{Base: "clip"}, wantErr: true},
+		{name: "only unsafe characters", tmpl: "???", data: data, wantErr: true},
+		{name: "unknown field", tmpl: "{{.Name}}", data: data, wantErr: true},
+		{name: "unclosed action", tmpl: "{{.Base", data: data, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderName(tt.tmpl, tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("renderName(%q) = %q, want an error", tt.tmpl, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderName(%q) failed: %v", tt.tmpl, err)
+			}
+			if got != tt.want {
+				t.Errorf("renderName(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateIndexedNameTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		wantErr bool
+	}{
+		{tmpl: config.DefaultChunkNameTemplate},
+		{tmpl: "{{.Index}}"},
+		{tmpl: "{{.Base}}_part{{.Index}}"},
+		{tmpl: "{{.Base}}_chunk", wantErr: true},
+		{tmpl: "{{.Stage}}", wantErr: true},
+		{tmpl: "{{.Base", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			err := validateIndexedNameTemplate(tt.tmpl)
+			if tt.wantErr && err == nil {
+				t.Errorf("validateIndexedNameTemplate(%q) succeeded, want an error", tt.tmpl)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("validateIndexedNameTemplate(%q) failed: %v", tt.tmpl, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package processor
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Picture-in-picture overlays go in a corner of the main video
+var overlayPositions = []string{"bottom-right", "bottom-left", "top-right", "top-left"}
+
+// Overlay audio: the main video's, the overlay's or both mixed
+const (
+	overlayAudioMain    = "main"
+	overlayAudioOverlay = "overlay"
+	overlayAudioMix     = "mix"
+)
+
+// Overlay composites opts.OverlayPath in a corner of opts.InputPath into
+// opts.OutputPath, scaled, framed and rounded per opts. The overlay
+// disappears when it ends before the main video. It stops ffmpeg and returns
+// ctx's error once ctx is done.
+func Overlay(ctx context.Context, opts *config.OverlayOptions) (*types.ProcessedOutput, error) {
+	if err := checkOverlay(opts); err != nil {
+		return nil, err
+	}
+
+	p := ffmpegWrap.NewProcessor(opts.Verbose)
+	p.SetContext(ctx)
+
+	var progress progressTracker
+	progress.attach(p, opts.OnProgress)
+	progress.set("overlay", 0, 0)
+
+	main, err := ffmpegWrap.GetVideoMetadata(opts.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video metadata: %v", err)
+	}
+	cam, err := ffmpegWrap.GetVideoMetadata(opts.OverlayPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overlay metadata: %v", err)
+	}
+
+	// The border is part of the overlay's width
+	width := int(float64(main.Width)*cmp.Or(opts.Scale, config.DefaultOverlayScale)) - 2*opts.Border
+	width &^= 1
+	if width < 2 {
+		return nil, fmt.Errorf("overlay scale %g leaves no room inside a %dpx border", opts.Scale, opts.Border)
+	}
+	height := (width*cam.Height/cam.Width)&^1 + 2*opts.Border
+	width += 2 * opts.Border
+
+	mainInput := ffmpeg.Input(opts.InputPath)
+	camKwargs := ffmpeg.KwArgs{}
+	if opts.Offset > 0 {
+		camKwargs["itsoffset"] = opts.Offset
+	}
+	camInput := ffmpeg.Input(opts.OverlayPath, camKwargs)
+
+	overlay := camInput.Video().Filter("scale", ffmpeg.Args{fmt.Sprintf("%d:%d", width-2*opts.Border, height-2*opts.Border)})
+	if opts.Border > 0 {
+		borderColor := cmp.Or(opts.BorderColor, config.DefaultTemplateBorderColor)
+		overlay = overlay.Filter("pad", ffmpeg.Args{fmt.Sprintf("%d:%d:%d:%d:%s", width, height, opts.Border, opts.Border, borderColor)})
+	}
+	if opts.Radius > 0 {
+		overlay = overlay.Filter("format", ffmpeg.Args{"yuva420p"}).
+			Filter("geq", ffmpeg.Args{roundedCornersExpr(min(opts.Radius, width/2, height/2))})
+	}
+
+	margin := strconv.Itoa(opts.Margin)
+	position := qrOverlayPosition(opts.Position, overlayMargins{left: margin, top: margin, right: margin, bottom: margin})
+	video := ffmpeg.Filter([]*ffmpeg.Stream{mainInput.Video(), overlay}, "overlay", ffmpeg.Args{position},
+		ffmpeg.KwArgs{"eof_action": "pass"})
+
+	outputs := []*ffmpeg.Stream{video}
+	switch audio := cmp.Or(opts.Audio, overlayAudioMain); {
+	case audio == overlayAudioMix && main.HasAudio && cam.HasAudio:
+		outputs = append(outputs, ffmpeg.Filter([]*ffmpeg.Stream{mainInput.Audio(), camInput.Audio()}, "amix",
+			ffmpeg.Args{}, ffmpeg.KwArgs{"inputs": 2, "duration": "first", "normalize": 0}))
+	case audio == overlayAudioOverlay || (audio == overlayAudioMix && !main.HasAudio):
+		if cam.HasAudio {
+			outputs = append(outputs, camInput.Audio())
+		}
+	default:
+		if main.HasAudio {
+			outputs = append(outputs, mainInput.Audio())
+		}
+	}
+
+	if opts.Verbose {
+		log.Printf("Overlaying %s at %dx%d in the %s corner of %s\n", opts.OverlayPath, width, height,
+			cmp.Or(opts.Position, defaultQRPosition), opts.InputPath)
+	}
+
+	kwargs := p.FileOutputArgs(opts.OutputPath, main)
+	if err := p.Run(ffmpeg.Output(outputs, opts.OutputPath, kwargs), main.Duration); err != nil {
+		return nil, fmt.Errorf("failed to overlay video: %v", err)
+	}
+
+	metadata, err := ffmpegWrap.GetVideoMetadata(opts.OutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting video metadata: %v", err)
+	}
+
+	return &types.ProcessedOutput{
+		FilePath:        opts.OutputPath,
+		DurationSeconds: uint64(metadata.Duration),
+	}, nil
+}
+
+// checkOverlay rejects missing inputs, unknown positions and audio, and
+// negative or out of range sizes
+func checkOverlay(opts *config.OverlayOptions) error {
+	if _, err := os.Stat(opts.OverlayPath); err != nil {
+		return fmt.Errorf("overlay not found: %v", err)
+	}
+	if opts.Position != "" && !slices.Contains(overlayPositions, opts.Position) {
+		return fmt.Errorf("unsupported overlay position: %s (supported: %s)",
+			opts.Position, strings.Join(overlayPositions, ", "))
+	}
+	if opts.Audio != "" && opts.Audio != overlayAudioMain && opts.Audio != overlayAudioOverlay && opts.Audio != overlayAudioMix {
+		return fmt.Errorf("unsupported overlay audio: %s (supported: %s, %s, %s)",
+			opts.Audio, overlayAudioMain, overlayAudioOverlay, overlayAudioMix)
+	}
+	if opts.Scale < 0 || opts.Scale > 1 {
+		return fmt.Errorf("overlay scale %g must be between 0 and 1", opts.Scale)
+	}
+	if opts.Margin < 0 || opts.Radius < 0 || opts.Border < 0 || opts.Offset < 0 {
+		return fmt.Errorf("overlay margin, radius, border and offset can't be negative")
+	}
+	if opts.BorderColor != "" && !templateColorRe.MatchString(opts.BorderColor) {
+		return fmt.Errorf("invalid overlay border color %q, expected a name or hex code, e.g. 'white' or '#ff3366'", opts.BorderColor)
+	}
+	return nil
+}
+
+// roundedCornersExpr returns the geq options keeping the picture and making
+// what's outside corners of the given radius transparent
+func roundedCornersExpr(radius int) string {
+	// Distance from the center of the nearest corner's circle, for pixels in
+	// a corner square
+	dx := fmt.Sprintf("(abs(W/2-X)-(W/2-%d))", radius)
+	dy := fmt.Sprintf("(abs(H/2-Y)-(H/2-%d))", radius)
+	alpha := fmt.Sprintf("if(gt(%s,0)*gt(%s,0)*gt(hypot(%s,%s),%d),0,255)", dx, dy, dx, dy, radius)
+	return fmt.Sprintf("lum='p(X,Y)':cb='p(X,Y)':cr='p(X,Y)':a='%s'", alpha)
+}
@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Pipeline splits a video and arranges the resulting chunks into templates
+type Pipeline struct {
+	opts     *config.PipelineOptions
+	platform platform.Platform
+}
+
+// NewPipeline creates a new split and template pipeline
+func NewPipeline(opts *config.PipelineOptions, platform platform.Platform) *Pipeline {
+	return &Pipeline{
+		opts:     opts,
+		platform: platform,
+	}
+}
+
+// Process splits the input and applies the template to each group of chunks
+func (p *Pipeline) Process() ([]types.ProcessedOutput, error) {
+	return p.ProcessContext(context.Background())
+}
+
+// ProcessContext runs the pipeline until it finishes or ctx is done
+func (p *Pipeline) ProcessContext(ctx context.Context) ([]types.ProcessedOutput, error) {
+	groupSize, err := TemplateInputs(&p.opts.Template)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := os.MkdirAll(p.opts.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	splitOpts := p.opts.Split
+	if p.opts.KeepChunks {
+		splitOpts.OutputDir = filepath.Join(p.opts.OutputDir, "chunks")
+	} else {
+		tempDir, err := os.MkdirTemp("", config.SplitTempDirPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+		splitOpts.OutputDir = tempDir
+	}
+
+	clips, err := NewSplitter(&splitOpts).ProcessContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to split video")
+	}
+
+	if remainder := len(clips) % groupSize; remainder != 0 {
+		log.Printf("Warning: %s template needs %d chunks per output, ignoring last %d chunks",
+			p.opts.Template.TemplateType, groupSize, remainder)
+	}
+
+	base := baseName(p.opts.Split.InputPath)
+	res := make([]types.ProcessedOutput, 0, len(clips)/groupSize)
+	for start := 0; start+groupSize <= len(clips); start += groupSize {
+		inputPaths := make([]string, 0, groupSize)
+		for _, clip := range clips[start : start+groupSize] {
+			inputPaths = append(inputPaths, clip.FilePath)
+		}
+
+		index := start/groupSize + 1
+		templateOpts := p.opts.Template
+		templateOpts.InputPaths = inputPaths
+		templateOpts.OutputPath = filepath.Join(p.opts.OutputDir,
+			fmt.Sprintf("%s_template_%03d%s", base, index, FileExtension(templateOpts.OutputFormat)))
+
+		if templateOpts.Verbose {
+			log.Printf("Applying %s template %d: %v\n", templateOpts.TemplateType, index, inputPaths)
+		}
+
+		output, err := NewTemplater(&templateOpts, p.platform).ProcessContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply template %d: %v", index, err)
+		}
+		res = append(res, *output)
+	}
+
+	return res, nil
+}
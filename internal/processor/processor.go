@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,21 +14,47 @@ import (
 	"github.com/ZacxDev/video-splitter/internal/ffmpeg"
 	"github.com/ZacxDev/video-splitter/internal/platform"
 	"github.com/ZacxDev/video-splitter/pkg/types"
+	"golang.org/x/exp/rand"
 )
 
 // Splitter handles video splitting operations
 type Splitter struct {
-	opts     *config.VideoSplitterOptions
-	ffmpeg   *ffmpeg.Processor
-	platform platform.Platform
+	opts         *config.VideoSplitterOptions
+	ffmpeg       *ffmpeg.Processor
+	platform     platform.Platform
+	outputFormat string
+	dashLadder   []config.Rendition // Packaged into one manifest per chunk instead of a file per rung
+	copyChunks   bool               // The source meets the platform's specs, so chunks are stream copied
+	input        string             // The input video, or its image sequence encoded to one
+	source       string             // Chunks are cut from it: the input, or a copy with its audio tracks selected or effects applied
+	recapText    []overlayLine      // Recap overlay text lines, if any
+	recapASS     bool               // Draw the recap text with libass, which shapes it where drawtext can't
+	progress     progressTracker
 }
 
 // NewSplitter creates a new video splitter
 func NewSplitter(opts *config.VideoSplitterOptions) *Splitter {
-	return &Splitter{
+	s := &Splitter{
 		opts:   opts,
 		ffmpeg: ffmpeg.NewProcessor(opts.Verbose),
 	}
+	s.ffmpeg.SetTimeout(opts.EncodeTimeout)
+	s.ffmpeg.SetExtraOutputArgs(opts.ExtraOutputArgs)
+	s.ffmpeg.SetTwoPass(opts.TwoPass)
+	s.ffmpeg.SetQuality(opts.Quality)
+	s.ffmpeg.SetEncodePreset(opts.EncodePreset)
+	s.ffmpeg.SetFragmented(opts.Fragmented)
+	s.ffmpeg.SetAnimation(opts.AnimationFPS, opts.AnimationWidth)
+	s.ffmpeg.SetNoAudio(opts.NoAudio)
+	s.ffmpeg.SetBurnTimecode(opts.BurnTimecode)
+	s.ffmpeg.SetSharpen(opts.Sharpen)
+	s.ffmpeg.SetFrameRate(opts.FrameRate, opts.SmoothFrameRate)
+	s.ffmpeg.SetText(opts.TextOptions)
+	s.ffmpeg.SetAudioCopy(opts.AudioCopy)
+	s.ffmpeg.SetAudioEncoding(opts.AudioCodec, opts.AudioBitrate)
+	s.ffmpeg.SetAudio(opts.AudioOptions)
+	s.progress.attach(s.ffmpeg, opts.OnProgress)
+	return s
 }
 
 // Templater handles video template operations
@@ -35,15 +62,41 @@ type Templater struct {
 	opts     *config.VideoTemplateOptions
 	ffmpeg   *ffmpeg.Processor
 	platform platform.Platform
+	progress progressTracker
+	rng      *rand.Rand // Picks the text color and obscurify jitter, seeded by opts.Seed
 }
 
 // NewTemplater creates a new video templater
 func NewTemplater(opts *config.VideoTemplateOptions, platform platform.Platform) *Templater {
-	return &Templater{
+	seed := opts.Seed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	t := &Templater{
 		opts:     opts,
 		ffmpeg:   ffmpeg.NewProcessor(opts.Verbose),
 		platform: platform,
+		rng:      rand.New(rand.NewSource(seed)),
+	}
+	t.ffmpeg.SetTimeout(opts.EncodeTimeout)
+	t.ffmpeg.SetExtraOutputArgs(opts.ExtraOutputArgs)
+	t.ffmpeg.SetTwoPass(opts.TwoPass)
+	t.ffmpeg.SetQuality(opts.Quality)
+	t.ffmpeg.SetEncodePreset(opts.EncodePreset)
+	t.ffmpeg.SetFragmented(opts.Fragmented)
+	t.ffmpeg.SetAnimation(opts.AnimationFPS, opts.AnimationWidth)
+	t.ffmpeg.SetNoAudio(opts.NoAudio)
+	t.ffmpeg.SetAudioCopy(opts.AudioCopy)
+	t.ffmpeg.SetAudioEncoding(opts.AudioCodec, opts.AudioBitrate)
+	t.ffmpeg.SetAudio(opts.AudioOptions)
+	t.ffmpeg.SetFrameRate(0, opts.SmoothFrameRate)
+	if opts.ReframeDetector != "" {
+		t.ffmpeg.SetReframe(opts.Reframe, ffmpeg.CommandDetector{Command: opts.ReframeDetector})
+	} else {
+		t.ffmpeg.SetReframe(opts.Reframe, nil)
 	}
+	t.progress.attach(t.ffmpeg, opts.OnProgress)
+	return t
 }
 
 // GetSupportedPlatforms returns a list of supported platforms
@@ -51,6 +104,157 @@ func GetSupportedPlatforms() []types.ProcessingPlatform {
 	return platform.GetSupportedPlatforms()
 }
 
+// GetSupportedFormats returns the supported output formats
+func GetSupportedFormats() []string {
+	return ffmpeg.SupportedFormats()
+}
+
+// FileExtension returns the file extension, with the dot, for the output format
+func FileExtension(outputFormat string) string {
+	return ffmpeg.FileExtension(outputFormat)
+}
+
+// DASHSegments returns the segment files written next to a DASH manifest
+func DASHSegments(manifestPath string) ([]string, error) {
+	return ffmpeg.DASHSegments(manifestPath)
+}
+
+// checkQuality rejects a CRF outside the range the tool considers sensible and
+// unknown encode presets
+func checkQuality(crf int, preset string) error {
+	if crf != 0 && (crf < config.MinCRF || crf > config.MaxCRF) {
+		return fmt.Errorf("quality (CRF) %d must be between %d and %d", crf, config.MinCRF, config.MaxCRF)
+	}
+	if preset != "" && !slices.Contains(ffmpeg.EncodePresets, preset) {
+		return fmt.Errorf("unsupported encode preset: %s (supported: %s)",
+			preset, strings.Join(ffmpeg.EncodePresets, ", "))
+	}
+	return nil
+}
+
+// DefaultEncodePreset is the encoder speed/quality tier used when none is set
+const DefaultEncodePreset = ffmpeg.DefaultEncodePreset
+
+// EncodePresets returns the encoder speed/quality tiers, fastest first
+func EncodePresets() []string {
+	return slices.Clone(ffmpeg.EncodePresets)
+}
+
+// DefaultTonemapOperator is the tone mapping operator used when none is set
+const DefaultTonemapOperator = ffmpeg.DefaultTonemapOperator
+
+// TonemapOperators returns the supported tone mapping operators
+func TonemapOperators() []string {
+	return slices.Clone(ffmpeg.TonemapOperators)
+}
+
+// setupHDR configures how p treats HDR sources: kept as HDR when preserve is
+// set and plat, if any, accepts it, otherwise tone mapped to SDR with operator
+func setupHDR(p *ffmpeg.Processor, preserve bool, operator string, plat platform.Platform, verbose bool) error {
+	if operator != "" && !ffmpeg.IsTonemapOperator(operator) {
+		return fmt.Errorf("unsupported tonemap operator: %s (supported: %s)",
+			operator, strings.Join(ffmpeg.TonemapOperators, ", "))
+	}
+
+	if preserve && plat != nil && !plat.SupportsHDR() {
+		if verbose {
+			log.Printf("%s doesn't accept HDR, tone mapping HDR sources to SDR\n", plat.GetName())
+		}
+		preserve = false
+	}
+
+	p.SetPreserveHDR(preserve)
+	p.SetTonemapOperator(operator)
+	return nil
+}
+
+// checkAudio rejects audio processing that can't apply to the output format.
+// Files the options refer to must exist.
+func checkAudio(audio *config.AudioOptions, noAudio bool, outputFormat string) error {
+	if !audio.ProcessesAudio() {
+		return nil
+	}
+
+	switch {
+	case noAudio:
+		return fmt.Errorf("audio processing can't be combined with dropping the audio")
+	case ffmpeg.IsDASH(outputFormat), ffmpeg.IsAnimation(outputFormat):
+		return fmt.Errorf("audio processing isn't supported for %s output", outputFormat)
+	}
+
+	if audio.MusicVolume < 0 {
+		return fmt.Errorf("music volume %g can't be negative", audio.MusicVolume)
+	}
+	if audio.DuckThreshold < 0 || audio.DuckThreshold > 1 {
+		return fmt.Errorf("duck threshold %g must be between 0 and 1", audio.DuckThreshold)
+	}
+
+	if audio.DenoiseStrength < 0 || audio.DenoiseStrength > 1 {
+		return fmt.Errorf("denoise strength %g must be between 0 and 1", audio.DenoiseStrength)
+	}
+	if audio.DenoiseAudio && audio.DenoiseModel != "" && !ffmpeg.HasFilter("arnndn") {
+		return fmt.Errorf("ffmpeg was built without the arnndn filter needed for the denoise model")
+	}
+
+	for _, file := range []string{audio.AudioFile, audio.MusicFile, audio.DenoiseModel} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("audio file not found: %v", err)
+		}
+	}
+	return nil
+}
+
+// checkText rejects a font file for text overlays that doesn't exist, or that
+// is combined with a font name
+func checkText(text config.TextOptions) error {
+	if text.Wrap < 0 {
+		return fmt.Errorf("text wrap width %d can't be negative", text.Wrap)
+	}
+	if text.Position != "" && !slices.Contains(textPositions, text.Position) {
+		return fmt.Errorf("unsupported text position: %s (supported: %s)", text.Position, strings.Join(textPositions, ", "))
+	}
+	if err := checkOverlayAnimation(text); err != nil {
+		return err
+	}
+	if text.FontFile == "" {
+		return nil
+	}
+	if text.Font != "" {
+		return fmt.Errorf("a font file can't be combined with a font name")
+	}
+	if _, err := os.Stat(text.FontFile); err != nil {
+		return fmt.Errorf("font file not found: %v", err)
+	}
+	return nil
+}
+
+// checkAudioEncoding rejects audio encoder and bitrate overrides the output
+// format's container can't hold or ffmpeg can't parse
+func checkAudioEncoding(encoder, bitrate, outputFormat string) error {
+	if encoder != "" {
+		if err := ffmpeg.CheckAudioEncoder(outputFormat, encoder); err != nil {
+			return err
+		}
+	}
+	if bitrate != "" && !ffmpeg.ValidBitrate(bitrate) {
+		return fmt.Errorf("invalid audio bitrate %q, expected e.g. '128k'", bitrate)
+	}
+	return nil
+}
+
+// outputAudioCodec returns the audio codec and bitrate of outputs: the
+// platform's when one is set, otherwise the output format's codec at the
+// encoder's default bitrate
+func outputAudioCodec(plat platform.Platform, outputFormat string) (codec, bitrate string) {
+	if plat != nil {
+		return plat.GetAudioCodec(), plat.GetAudioBitrate()
+	}
+	return ffmpeg.GetCodecSettings(outputFormat).AudioCodec, ""
+}
+
 // Helper functions
 func parseSkipDuration(skip string) (float64, error) {
 	if skip == "" {
@@ -93,7 +297,7 @@ func ensureOutputPath(path, format string) string {
 	}
 
 	// Ensure correct file extension
-	ext := fmt.Sprintf(".%s", format)
+	ext := ffmpeg.FileExtension(format)
 	if !strings.HasSuffix(strings.ToLower(path), ext) {
 		path = strings.TrimSuffix(path, filepath.Ext(path)) + ext
 	}
@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+)
+
+// progressTracker labels ffmpeg progress with the step currently being encoded
+type progressTracker struct {
+	stage string
+	index int
+	total int
+}
+
+// set records the step the next encode belongs to
+func (pt *progressTracker) set(stage string, index, total int) {
+	pt.stage, pt.index, pt.total = stage, index, total
+}
+
+// attach forwards the processor's progress to fn as labelled events
+func (pt *progressTracker) attach(p *ffmpeg.Processor, fn func(types.ProgressEvent)) {
+	if fn == nil {
+		return
+	}
+
+	p.SetProgressHandler(func(pr ffmpeg.Progress) {
+		fn(types.ProgressEvent{
+			Stage:   pt.stage,
+			Index:   pt.index,
+			Total:   pt.total,
+			Percent: pr.Percent,
+			FPS:     pr.FPS,
+			Speed:   pr.Speed,
+			ETA:     pr.ETA,
+			Done:    pr.Done,
+		})
+	})
+}
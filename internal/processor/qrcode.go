@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/skip2/go-qrcode"
+)
+
+// QR code positions on the outro card
+var qrPositions = []string{"bottom-right", "bottom-left", "top-right", "top-left", "center"}
+
+// defaultQRPosition is where the outro QR code goes when no position is set
+const defaultQRPosition = "bottom-right"
+
+// checkQRPosition rejects unknown QR code positions
+func checkQRPosition(position string) error {
+	if position != "" && !slices.Contains(qrPositions, position) {
+		return fmt.Errorf("unsupported QR code position: %s (supported: %s)",
+			position, strings.Join(qrPositions, ", "))
+	}
+	return nil
+}
+
+// writeQRCode renders a size x size pixel QR code of content as a PNG at path
+func writeQRCode(content string, size int, path string) error {
+	if err := qrcode.WriteFile(content, qrcode.Medium, size, path); err != nil {
+		return errors.Wrap(err, "failed to generate QR code")
+	}
+	return nil
+}
+
+// qrOverlayPosition returns the overlay position of a QR code at position on
+// the card, the margins away from the edges it's placed against
+func qrOverlayPosition(position string, m overlayMargins) string {
+	switch position {
+	case "bottom-left":
+		return fmt.Sprintf("%s:H-h-%s", m.left, m.bottom)
+	case "top-right":
+		return fmt.Sprintf("W-w-%s:%s", m.right, m.top)
+	case "top-left":
+		return fmt.Sprintf("%s:%s", m.left, m.top)
+	case "center":
+		return "(W-w)/2:(H-h)/2"
+	default:
+		return fmt.Sprintf("W-w-%s:H-h-%s", m.right, m.bottom)
+	}
+}
@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/pkg/types"
+)
+
+// qualityReportEntry is one scored encode in a quality report
+type qualityReportEntry struct {
+	File   string  `json:"file"`
+	Source string  `json:"source"`
+	Start  float64 `json:"start_seconds"`
+	VMAF   float64 `json:"vmaf,omitempty"`
+	SSIM   float64 `json:"ssim"`
+	PSNR   float64 `json:"psnr"`
+}
+
+func newQualityReportEntry(file, source string, start float64, scores *types.QualityScores) qualityReportEntry {
+	return qualityReportEntry{
+		File:   file,
+		Source: source,
+		Start:  start,
+		VMAF:   scores.VMAF,
+		SSIM:   scores.SSIM,
+		PSNR:   scores.PSNR,
+	}
+}
+
+// writeQualityReport writes entries to path as indented JSON
+func writeQualityReport(path string, entries []qualityReportEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write quality report: %v", err)
+	}
+	return nil
+}
+
+// TemplateQualityReportPath returns where a template's quality report is
+// written for the given output path
+func TemplateQualityReportPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "_quality.json"
+}
+
+// averageQuality returns the mean of scores, or nil when there are none
+func averageQuality(scores []*types.QualityScores) *types.QualityScores {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	avg := &types.QualityScores{}
+	for _, s := range scores {
+		avg.VMAF += s.VMAF
+		avg.SSIM += s.SSIM
+		avg.PSNR += s.PSNR
+	}
+	n := float64(len(scores))
+	avg.VMAF /= n
+	avg.SSIM /= n
+	avg.PSNR /= n
+	return avg
+}
@@ -0,0 +1,41 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+)
+
+// Remux rewraps opts.InputPath into the container of opts.OutputPath without
+// re-encoding, stopping ffmpeg and returning ctx's error once ctx is done
+func Remux(ctx context.Context, opts *config.RemuxOptions) (*types.ProcessedOutput, error) {
+	p := ffmpeg.NewProcessor(opts.Verbose)
+	p.SetContext(ctx)
+	p.SetFragmented(opts.Fragmented)
+
+	var progress progressTracker
+	progress.attach(p, opts.OnProgress)
+	progress.set("remux", 0, 0)
+
+	if err := p.Remux(opts.InputPath, opts.OutputPath); err != nil {
+		return nil, err
+	}
+
+	metadata, err := ffmpeg.GetVideoMetadata(opts.OutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting video metadata: %v", err)
+	}
+
+	return &types.ProcessedOutput{
+		FilePath:        opts.OutputPath,
+		DurationSeconds: uint64(metadata.Duration),
+	}, nil
+}
+
+// RemuxExtensions returns the output file extensions Remux can write
+func RemuxExtensions() []string {
+	return ffmpeg.RemuxExtensions()
+}
@@ -0,0 +1,371 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Runner executes the steps of a declarative pipeline spec
+type Runner struct {
+	spec     *config.PipelineSpec
+	platform platform.Platform
+}
+
+// NewRunner creates a new pipeline spec runner. The platform may be nil when no
+// step requires one.
+func NewRunner(spec *config.PipelineSpec, platform platform.Platform) *Runner {
+	return &Runner{
+		spec:     spec,
+		platform: platform,
+	}
+}
+
+// Process runs every step in order, feeding each step's outputs into the next,
+// and moves the final files into the spec's output directory
+func (r *Runner) Process() ([]types.ProcessedOutput, error) {
+	return r.ProcessContext(context.Background())
+}
+
+// ProcessContext runs the steps until they finish or ctx is done, stopping
+// the running encode or upload command
+func (r *Runner) ProcessContext(ctx context.Context) ([]types.ProcessedOutput, error) {
+	workDir, err := os.MkdirTemp("", "video_pipeline_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	files := r.spec.Inputs
+	for i, step := range r.spec.Steps {
+		stepDir := filepath.Join(workDir, fmt.Sprintf("step_%02d_%s", i+1, step.Name()))
+		if err := os.MkdirAll(stepDir, 0755); err != nil {
+			return nil, fmt.Errorf("error creating step directory: %v", err)
+		}
+
+		if r.spec.Verbose {
+			log.Printf("Running step %d/%d (%s) on %d files\n", i+1, len(r.spec.Steps), step.Name(), len(files))
+		}
+
+		switch {
+		case step.Split != nil:
+			files, err = r.split(ctx, files, step.Split, stepDir)
+		case step.Obscurify != nil:
+			files, err = r.obscurify(ctx, files, step.Obscurify, stepDir)
+		case step.Template != nil:
+			files, err = r.template(ctx, files, step.Template, stepDir)
+		case step.Outro != nil:
+			files, err = r.outro(ctx, files, step.Outro, stepDir)
+		case step.Upload != nil:
+			err = r.upload(ctx, files, step.Upload)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s) failed: %v", i+1, step.Name(), err)
+		}
+	}
+
+	if err := os.MkdirAll(r.spec.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	res := make([]types.ProcessedOutput, 0, len(files))
+	for _, file := range files {
+		outputPath := filepath.Join(r.spec.OutputDir, filepath.Base(file))
+		if err := moveFile(file, outputPath); err != nil {
+			return nil, fmt.Errorf("failed to move %s to output: %v", file, err)
+		}
+
+		metadata, err := ffmpegWrap.GetVideoMetadata(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("error getting video metadata: %v", err)
+		}
+
+		res = append(res, types.ProcessedOutput{
+			FilePath:        outputPath,
+			DurationSeconds: uint64(metadata.Duration),
+		})
+	}
+
+	return res, nil
+}
+
+func (r *Runner) split(ctx context.Context, files []string, step *config.SplitStep, stepDir string) ([]string, error) {
+	res := make([]string, 0)
+	for _, file := range files {
+		clips, err := NewSplitter(&config.VideoSplitterOptions{
+			InputPath:       file,
+			OutputDir:       stepDir,
+			ChunkDuration:   step.Duration,
+			Skip:            step.Skip,
+			Renditions:      step.Ladder,
+			BurnTimecode:    step.Timecode,
+			FrameRate:       step.FPS,
+			SmoothFrameRate: r.spec.SmoothFPS,
+			InputFPS:        r.spec.InputFPS,
+			TextOptions:     r.textOptions(),
+			EffectOptions:   r.effectOptions(),
+			Sharpen:         r.spec.Sharpen,
+			TargetPlatform:  r.spec.TargetPlatform,
+			OutputFormat:    r.spec.OutputFormat,
+			Verbose:         r.spec.Verbose,
+			OnProgress:      r.spec.OnProgress,
+		}).ProcessContext(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to split %s", file)
+		}
+
+		for _, clip := range clips {
+			res = append(res, clip.FilePath)
+		}
+	}
+	return res, nil
+}
+
+func (r *Runner) obscurify(ctx context.Context, files []string, step *config.ObscurifyStep, stepDir string) ([]string, error) {
+	opts := r.templateOptions()
+	opts.ObscurifyPitch = step.Pitch
+	opts.ObscurifyTempo = step.Tempo
+	opts.ObscurifyKeepAudio = step.KeepAudio
+	opts.ObscurifyEffects = step.Effects
+	opts.ObscurifyStrength = step.Strength
+	opts.ObscurifyJitter = step.Jitter
+	opts.Seed = step.Seed
+	templater := NewTemplater(opts, r.platform)
+	templater.ffmpeg.SetContext(ctx)
+
+	res := make([]string, 0, len(files))
+	for i, file := range files {
+		outputPath := filepath.Join(stepDir, baseName(file)+FileExtension(r.spec.OutputFormat))
+		templater.progress.set("obscurify", i+1, len(files))
+		if err := templater.ApplyObscurifyEffects(file, outputPath); err != nil {
+			return nil, errors.Wrapf(err, "failed to obscurify %s", file)
+		}
+		res = append(res, outputPath)
+	}
+	return res, nil
+}
+
+func (r *Runner) template(ctx context.Context, files []string, step *config.TemplateStep, stepDir string) ([]string, error) {
+	groupSize, err := TemplateInputCount(step.Type)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if remainder := len(files) % groupSize; remainder != 0 {
+		log.Printf("Warning: %s template needs %d files per output, ignoring last %d files",
+			step.Type, groupSize, remainder)
+	}
+
+	res := make([]string, 0, len(files)/groupSize)
+	for start := 0; start+groupSize <= len(files); start += groupSize {
+		opts := r.templateOptions()
+		opts.InputPaths = append([]string{}, files[start:start+groupSize]...)
+		opts.OutputPath = filepath.Join(stepDir,
+			fmt.Sprintf("%s_template_%03d%s", baseName(files[start]), start/groupSize+1, FileExtension(r.spec.OutputFormat)))
+		opts.TemplateType = step.Type
+		opts.TemplateAudio = step.Audio
+		opts.TemplateAudioWeights = step.AudioWeights
+		opts.DurationStrategy = step.Duration
+		opts.FrameRate = step.FPS
+		opts.SmoothFrameRate = r.spec.SmoothFPS
+		opts.TemplateGap = step.Gap
+		opts.TemplateBorder = step.Border
+		opts.TemplateBorderColor = step.BorderColor
+		opts.TemplateBackground = step.Background
+		opts.TemplateBackgroundImage = step.BackgroundImage
+		opts.TemplateBackgroundBlur = step.BackgroundBlur
+		opts.KeyBackground = step.KeyBackground
+		opts.KeyColor = step.KeyColor
+		opts.KeySimilarity = step.KeySimilarity
+		opts.KeyBlend = step.KeyBlend
+		opts.LandscapeBottomRightText = step.LandscapeText
+		opts.PortraitBottomRightText = step.PortraitText
+		if opts.PortraitBottomRightText == "" {
+			opts.PortraitBottomRightText = opts.LandscapeBottomRightText
+		}
+		opts.InputTexts = step.InputTexts
+		opts.TextColor = step.TextColor
+		opts.TextPalette = step.TextPalette
+		opts.Seed = step.Seed
+		opts.WatermarkImage = step.WatermarkImage
+		opts.WatermarkMotion = step.WatermarkMotion
+		opts.WatermarkInterval = step.WatermarkInterval
+		opts.BurnTimecode = step.Timecode
+		opts.Reframe = step.Reframe
+		opts.ReframeDetector = step.ReframeDetector
+		opts.EffectOptions = r.effectOptions()
+		opts.Sharpen = r.spec.Sharpen
+
+		output, err := NewTemplater(opts, r.platform).ProcessContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, output.FilePath)
+	}
+	return res, nil
+}
+
+func (r *Runner) outro(ctx context.Context, files []string, step *config.OutroStep, stepDir string) ([]string, error) {
+	opts := r.templateOptions()
+	opts.OutroLines = step.Lines
+	opts.Transition = step.Transition
+	opts.TransitionDuration = step.TransitionDuration
+	opts.OutroDuration = step.Duration
+	opts.OutroFontSize = step.FontSize
+	opts.OutroTextColor = step.TextColor
+	opts.OutroBackground = step.Background
+	opts.OutroFadeIn = step.FadeIn
+	opts.OutroFadeOut = step.FadeOut
+	opts.OutroLogo = step.Logo
+	opts.OutroQR = step.QR
+	opts.OutroQRSize = step.QRSize
+	opts.OutroQRPosition = step.QRPosition
+	opts.OutroAudio = step.Audio
+	templater := NewTemplater(opts, r.platform)
+	templater.ffmpeg.SetContext(ctx)
+	if err := checkTransition(opts.Transition, opts.TransitionDuration, templater.outroStyle().duration); err != nil {
+		return nil, err
+	}
+	if err := checkOutroStyle(opts); err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(files))
+	for _, file := range files {
+		outputPath := filepath.Join(stepDir, baseName(file)+FileExtension(r.spec.OutputFormat))
+		if err := templater.AppendOutro(stepDir, file, outputPath); err != nil {
+			return nil, errors.Wrapf(err, "failed to append outro to %s", file)
+		}
+		res = append(res, outputPath)
+	}
+	return res, nil
+}
+
+func (r *Runner) upload(ctx context.Context, files []string, step *config.UploadStep) error {
+	args := make([]*template.Template, len(step.Command))
+	for i, arg := range step.Command {
+		t, err := template.New("arg").Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid upload command argument %q: %v", arg, err)
+		}
+		args[i] = t
+	}
+
+	for _, file := range files {
+		data := map[string]string{
+			"Path": file,
+			"Name": filepath.Base(file),
+		}
+
+		rendered := make([]string, len(args))
+		for i, t := range args {
+			var sb strings.Builder
+			if err := t.Execute(&sb, data); err != nil {
+				return fmt.Errorf("failed to render upload command: %v", err)
+			}
+			rendered[i] = sb.String()
+		}
+
+		if r.spec.Verbose {
+			log.Printf("Uploading %s: %s\n", file, strings.Join(rendered, " "))
+		}
+
+		cmd := exec.CommandContext(ctx, rendered[0], rendered[1:]...)
+		// Stdout may be carrying JSON progress records
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("upload command failed for %s: %v", file, err)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) templateOptions() *config.VideoTemplateOptions {
+	return &config.VideoTemplateOptions{
+		OutputFormat:   r.spec.OutputFormat,
+		Verbose:        r.spec.Verbose,
+		TargetPlatform: r.spec.TargetPlatform,
+		TextOptions:    r.textOptions(),
+		OnProgress:     r.spec.OnProgress,
+	}
+}
+
+func (r *Runner) textOptions() config.TextOptions {
+	return config.TextOptions{
+		FontFile: r.spec.FontFile,
+		Font:     r.spec.Font,
+		Wrap:     r.spec.TextWrap,
+
+		Position: r.spec.TextPosition,
+		OffsetX:  r.spec.TextOffsetX,
+		OffsetY:  r.spec.TextOffsetY,
+
+		Animation: r.spec.TextAnimation,
+		ShowAt:    r.spec.TextStart,
+		HideAt:    r.spec.TextEnd,
+	}
+}
+
+func (r *Runner) effectOptions() config.EffectOptions {
+	return config.EffectOptions{
+		BlurRegions:     r.spec.BlurRegions,
+		BlurFaces:       r.spec.BlurFaces,
+		FaceDetector:    r.spec.FaceDetector,
+		Crop:            r.spec.Crop,
+		CropKeyframes:   r.spec.CropKeyframes,
+		Stabilize:       r.spec.Stabilize,
+		Speed:           r.spec.Speed,
+		Interpolate:     r.spec.Interpolate,
+		SpeedAudio:      r.spec.SpeedAudio,
+		MinDuration:     r.spec.MinDuration,
+		LoopMode:        r.spec.LoopMode,
+		Boomerang:       r.spec.Boomerang,
+		BoomerangRepeat: r.spec.BoomerangRepeat,
+		Denoise:         r.spec.Denoise,
+		EffectsFile:     r.spec.EffectsFile,
+		LUTFile:         r.spec.LUTFile,
+		Look:            r.spec.Look,
+	}
+}
+
+// moveFile renames src to dst, falling back to a copy when they are on
+// different filesystems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ZacxDev/video-splitter/internal/platform"
+)
+
+// overlayMargins are ffmpeg expressions for the distance overlays keep from
+// each edge of the frame
+type overlayMargins struct {
+	left, top, right, bottom string
+}
+
+// safeArea returns the area plat covers with its interface, or none without
+// a platform
+func safeArea(plat platform.Platform) platform.SafeArea {
+	if plat == nil {
+		return platform.SafeArea{}
+	}
+	return plat.GetSafeArea()
+}
+
+// safeMarginPixels returns margins of margin pixels plus area's insets on a
+// width x height frame, in pixels
+func safeMarginPixels(area platform.SafeArea, margin, width, height int) (left, top, right, bottom int) {
+	inset := func(size int, share float64) int {
+		return margin + int(float64(size)*share)
+	}
+	return inset(width, area.Left), inset(height, area.Top), inset(width, area.Right), inset(height, area.Bottom)
+}
+
+// safeMargins returns margins of margin pixels plus area's insets on a
+// frameW x frameH frame, where frameW and frameH are the frame size variables
+// of the filter evaluating them, e.g. "w" and "h" for drawtext
+func safeMargins(area platform.SafeArea, margin int, frameW, frameH string) overlayMargins {
+	inset := func(size string, share float64) string {
+		if share <= 0 {
+			return strconv.Itoa(margin)
+		}
+		return fmt.Sprintf("(%d+%s*%g)", margin, size, share)
+	}
+	return overlayMargins{
+		left:   inset(frameW, area.Left),
+		top:    inset(frameH, area.Top),
+		right:  inset(frameW, area.Right),
+		bottom: inset(frameH, area.Bottom),
+	}
+}
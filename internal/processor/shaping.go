@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+)
+
+// complexScripts are scripts drawtext only draws correctly when it shapes
+// the text: right to left ones, and ones whose letters join or reorder
+var complexScripts = []*unicode.RangeTable{
+	unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko,
+	unicode.Devanagari, unicode.Bengali, unicode.Gurmukhi, unicode.Gujarati, unicode.Oriya,
+	unicode.Tamil, unicode.Telugu, unicode.Kannada, unicode.Malayalam, unicode.Sinhala,
+	unicode.Thai, unicode.Lao, unicode.Tibetan, unicode.Myanmar, unicode.Khmer,
+}
+
+// needsShaping reports whether text has letters of a complex script
+func needsShaping(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return unicode.In(r, complexScripts...)
+	}) >= 0
+}
+
+// shapeWithLibass reports whether text has to be drawn with libass because
+// it needs shaping the installed ffmpeg's drawtext can't do. Without libass
+// either, naive drawtext would show the letters reversed and disconnected, so
+// that's an error.
+func shapeWithLibass(text string) (bool, error) {
+	if !needsShaping(text) || ffmpegWrap.CanShapeText() {
+		return false, nil
+	}
+	if !ffmpegWrap.HasFilter("ass") {
+		return false, fmt.Errorf("drawing %q needs an ffmpeg build with libfribidi or libass to shape its script", text)
+	}
+	return true, nil
+}
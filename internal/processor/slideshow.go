@@ -0,0 +1,130 @@
+package processor
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Slideshow turns the images of opts.InputDir, a directory or glob pattern,
+// into a video for plat: each image a clip of its own, zooming in and out in
+// turn, crossfaded into the next. It stops ffmpeg and returns ctx's error
+// once ctx is done.
+func Slideshow(ctx context.Context, opts *config.SlideshowOptions, plat platform.Platform) (*types.ProcessedOutput, error) {
+	imageDuration := cmp.Or(opts.ImageDuration, config.DefaultImageDuration)
+	if imageDuration < 0 {
+		return nil, fmt.Errorf("image duration %gs can't be negative", imageDuration)
+	}
+	transitionDuration := cmp.Or(opts.TransitionDuration, config.DefaultTransitionDuration)
+	if opts.Transition != "" {
+		if !ffmpegWrap.IsTransition(opts.Transition) {
+			return nil, fmt.Errorf("unsupported transition: %s (supported: %s)",
+				opts.Transition, strings.Join(ffmpegWrap.Transitions(), ", "))
+		}
+		if transitionDuration <= 0 || transitionDuration >= imageDuration {
+			return nil, fmt.Errorf("transition duration %gs must be shorter than the %gs each image shows for", transitionDuration, imageDuration)
+		}
+	}
+
+	images, err := resolveImages(opts.InputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	outputFormat := strings.ToLower(cmp.Or(opts.OutputFormat, "mp4"))
+	if ffmpegWrap.IsAnimation(outputFormat) || ffmpegWrap.IsDASH(outputFormat) {
+		return nil, fmt.Errorf("slideshows can't be written as %s", outputFormat)
+	}
+
+	p := ffmpegWrap.NewProcessor(opts.Verbose)
+	p.SetContext(ctx)
+
+	var progress progressTracker
+	progress.attach(p, opts.OnProgress)
+
+	tempDir, err := os.MkdirTemp("", "video_slideshow_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Slides show for the image duration with the transitions overlapping them
+	canvas := defaultCanvas(plat)
+	slideDuration := imageDuration
+	if opts.Transition != "" {
+		slideDuration += transitionDuration
+	}
+	clips := make([]string, len(images))
+	for i, image := range images {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		clips[i] = filepath.Join(tempDir, fmt.Sprintf("slide_%03d.mkv", i))
+		progress.set("still", i+1, len(images))
+		err := p.StillVideo(image, clips[i], slideDuration, canvas.Width, canvas.Height, plat.GetFrameRate(), !opts.NoKenBurns, i%2 == 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	outputPath := ffmpegWrap.EnsureExtension(opts.OutputPath, ffmpegWrap.FileExtension(outputFormat))
+	progress.set("slideshow", 0, 0)
+	if err := p.Slideshow(clips, outputPath, opts.Transition, transitionDuration, plat, outputFormat); err != nil {
+		if ctx.Err() != nil {
+			os.Remove(outputPath)
+		}
+		return nil, err
+	}
+
+	metadata, err := ffmpegWrap.GetVideoMetadata(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting video metadata: %v", err)
+	}
+
+	return &types.ProcessedOutput{
+		FilePath:        outputPath,
+		DurationSeconds: uint64(metadata.Duration),
+	}, nil
+}
+
+// resolveImages expands a directory or glob pattern into the still images it
+// holds, sorted by path
+func resolveImages(pattern string) ([]string, error) {
+	var matches []string
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(pattern)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read image directory")
+		}
+		for _, entry := range entries {
+			matches = append(matches, filepath.Join(pattern, entry.Name()))
+		}
+	} else {
+		if matches, err = filepath.Glob(pattern); err != nil {
+			return nil, fmt.Errorf("invalid image pattern %q: %v", pattern, err)
+		}
+	}
+
+	var images []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() && ffmpegWrap.IsImage(match) {
+			images = append(images, match)
+		}
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images match %q", pattern)
+	}
+
+	sort.Strings(images)
+	return images, nil
+}
@@ -1,27 +1,41 @@
 package processor
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/ZacxDev/video-splitter/config"
 	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
 	"github.com/ZacxDev/video-splitter/internal/platform"
 	"github.com/ZacxDev/video-splitter/pkg/types"
 	"github.com/pkg/errors"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
 )
 
 // Process handles the video splitting operation
 func (s *Splitter) Process() ([]types.ProcessedClip, error) {
+	return s.ProcessContext(context.Background())
+}
+
+// ProcessContext splits the video, stopping the running encode and returning
+// ctx's error once ctx is done
+func (s *Splitter) ProcessContext(ctx context.Context) ([]types.ProcessedClip, error) {
+	s.ffmpeg.SetContext(ctx)
+
 	// If no format specified, use platform preference or default to webm
 	outputFormat := strings.ToLower(s.opts.OutputFormat)
 	if outputFormat == "" {
 		outputFormat = "webm"
 	}
-	if outputFormat != "webm" && outputFormat != "mp4" {
-		return nil, fmt.Errorf("unsupported output format: %s (supported: webm, mp4)", outputFormat)
+	if !ffmpegWrap.IsSupportedFormat(outputFormat) {
+		return nil, fmt.Errorf("unsupported output format: %s (supported: %s)",
+			outputFormat, strings.Join(ffmpegWrap.SupportedFormats(), ", "))
 	}
 
 	if s.opts.TargetPlatform != "" {
@@ -36,7 +50,30 @@ func (s *Splitter) Process() ([]types.ProcessedClip, error) {
 		}
 	}
 
-	metadata, err := ffmpegWrap.GetVideoMetadata(s.opts.InputPath)
+	if err := checkQuality(s.opts.Quality, s.opts.EncodePreset); err != nil {
+		return nil, err
+	}
+	if err := setupHDR(s.ffmpeg, s.opts.PreserveHDR, s.opts.TonemapOperator, s.platform, s.opts.Verbose); err != nil {
+		return nil, err
+	}
+
+	// Stream copies don't encode, so they don't need the format's encoders
+	if !s.opts.StreamCopy || s.platform != nil {
+		outputFormat = ffmpegWrap.ResolveOutputFormat(outputFormat)
+	}
+	s.outputFormat = outputFormat
+
+	s.input = s.opts.InputPath
+	if ffmpegWrap.IsImageSequence(s.opts.InputPath) {
+		input, cleanup, err := s.encodeSequence()
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		s.input = input
+	}
+
+	metadata, err := ffmpegWrap.GetVideoMetadata(s.input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get video metadata: %v", err)
 	}
@@ -61,15 +98,106 @@ func (s *Splitter) Process() ([]types.ProcessedClip, error) {
 		return nil, fmt.Errorf("error creating output directory: %v", err)
 	}
 
-	baseFileName := filepath.Base(s.opts.InputPath)
-	baseFileName = strings.TrimSuffix(baseFileName, filepath.Ext(baseFileName))
-	baseFileName = sanitizeFilename(baseFileName)
+	if s.opts.ChunkDuration <= 0 {
+		return nil, fmt.Errorf("chunk duration of %ds must be positive", s.opts.ChunkDuration)
+	}
+	if s.opts.RecapSeconds < 0 || (s.opts.RecapSeconds > 0 && s.opts.RecapSeconds >= s.opts.ChunkDuration) {
+		return nil, fmt.Errorf("recap of %ds must be between 0 and the chunk duration of %ds",
+			s.opts.RecapSeconds, s.opts.ChunkDuration)
+	}
 
-	numChunks := int(duration) / s.opts.ChunkDuration
-	if int(duration)%s.opts.ChunkDuration != 0 {
-		numChunks++
+	// Chunks are encoded into a temp dir first when extra segments need assembling
+	assemble := s.opts.IntroClipPath != "" || s.opts.OutroClipPath != "" || s.opts.RecapSeconds > 0
+	if ffmpegWrap.IsDASH(outputFormat) {
+		switch {
+		case s.platform != nil:
+			return nil, fmt.Errorf("DASH output can't be combined with a target platform")
+		case s.opts.StreamCopy:
+			return nil, fmt.Errorf("DASH output can't be combined with stream copy")
+		case assemble:
+			return nil, fmt.Errorf("DASH output can't be combined with intro, outro or recap segments")
+		}
+	}
+	if s.opts.BurnTimecode && s.opts.StreamCopy && s.platform == nil {
+		return nil, fmt.Errorf("timecodes can't be burned into stream copied chunks")
+	}
+	if s.opts.Sharpen != "" && s.opts.StreamCopy && s.platform == nil {
+		return nil, fmt.Errorf("stream copied chunks can't be sharpened")
+	}
+	if s.opts.FrameRate < 0 || s.opts.FrameRate > maxFrameRate {
+		return nil, fmt.Errorf("frame rate %d must be between 1 and %d", s.opts.FrameRate, maxFrameRate)
+	}
+	if s.opts.FrameRate > 0 && s.opts.StreamCopy && s.platform == nil {
+		return nil, fmt.Errorf("stream copied chunks can't be converted to %d fps", s.opts.FrameRate)
+	}
+	if ffmpegWrap.IsAnimation(outputFormat) {
+		switch {
+		case s.platform != nil:
+			return nil, fmt.Errorf("%s output can't be combined with a target platform", outputFormat)
+		case s.opts.StreamCopy:
+			return nil, fmt.Errorf("%s output can't be combined with stream copy", outputFormat)
+		case assemble:
+			return nil, fmt.Errorf("%s output can't be combined with intro, outro or recap segments", outputFormat)
+		case len(s.opts.Renditions) > 0:
+			return nil, fmt.Errorf("%s output can't be combined with a bitrate ladder", outputFormat)
+		}
+	}
+	if err := checkAudio(&s.opts.AudioOptions, s.opts.NoAudio, outputFormat); err != nil {
+		return nil, err
+	}
+	if err := checkAudioEncoding(s.opts.AudioCodec, s.opts.AudioBitrate, outputFormat); err != nil {
+		return nil, err
+	}
+	if err := checkText(s.opts.TextOptions); err != nil {
+		return nil, err
+	}
+	effects, err := loadEffects(s.opts.EffectOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSharpen(s.opts.Sharpen); err != nil {
+		return nil, err
+	}
+	var tempDir string
+	if assemble {
+		for _, clip := range []string{s.opts.IntroClipPath, s.opts.OutroClipPath} {
+			if clip == "" {
+				continue
+			}
+			if _, err := os.Stat(clip); err != nil {
+				return nil, fmt.Errorf("bookend clip not found: %v", err)
+			}
+		}
+
+		tempDir, err = os.MkdirTemp("", config.SplitTempDirPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		if s.opts.RecapText != "" {
+			if s.recapASS, err = shapeWithLibass(s.opts.RecapText); err != nil {
+				return nil, err
+			}
+			if s.recapASS && animated(s.opts.TextOptions) {
+				return nil, fmt.Errorf("animating %q needs an ffmpeg build with libfribidi to shape its script", s.opts.RecapText)
+			}
+			if s.recapText, err = writeAnimatedLines(tempDir, "recap_text", overlayLines(s.opts.RecapText, s.opts.Wrap), s.opts.TextOptions); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	nameTemplate := s.opts.NameTemplate
+	if nameTemplate == "" {
+		nameTemplate = config.DefaultChunkNameTemplate
+	}
+	if err := validateIndexedNameTemplate(nameTemplate); err != nil {
+		return nil, errors.WithStack(err)
 	}
 
+	baseFileName := baseName(s.opts.InputPath)
+
 	// Check platform constraints
 	if s.platform != nil {
 		if s.opts.ChunkDuration > s.platform.GetMaxDuration() {
@@ -78,42 +206,412 @@ func (s *Splitter) Process() ([]types.ProcessedClip, error) {
 		}
 	}
 
+	s.source = s.input
+	if len(s.opts.AudioTracks) > 0 {
+		source, cleanup, err := s.selectAudioTracks()
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		s.source = source
+	}
+	if preprocesses(effects, s.opts.EffectOptions, metadata.Duration) {
+		source, cleanup, err := s.applyEffects(effects)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		s.source = source
+
+		// Effects may scale the video, which the renditions are picked from,
+		// and speed changes and loops change its length. The skip still
+		// counts in the input.
+		if metadata, err = ffmpegWrap.GetVideoMetadata(s.source); err != nil {
+			return nil, fmt.Errorf("failed to get video metadata: %v", err)
+		}
+		skipSeconds /= cmp.Or(s.opts.Speed, 1)
+		duration = metadata.Duration - skipSeconds
+	}
+
+	numChunks := int(duration) / s.opts.ChunkDuration
+	if int(duration)%s.opts.ChunkDuration != 0 {
+		numChunks++
+	}
+
+	// Assembly re-encodes the chunk anyway, so copying it first gains nothing,
+	// and copies can't have a timecode burned in, be sharpened or change
+	// frame rate
+	if s.opts.AllowCopy && s.platform != nil && !assemble && !s.opts.BurnTimecode && s.opts.Sharpen == "" && s.opts.FrameRate == 0 {
+		ok, reason, err := ffmpegWrap.CopyCompliant(s.source, s.platform)
+		if err != nil {
+			return nil, err
+		}
+		// The copy keeps the platform's codecs, which need its container
+		if ok && ffmpegWrap.GetCodecSettings(outputFormat).ContainerFormat !=
+			ffmpegWrap.GetCodecSettings(s.platform.GetOutputFormat()).ContainerFormat {
+			ok, reason = false, fmt.Sprintf("%s output can't hold %s's codecs", outputFormat, s.platform.GetName())
+		}
+		s.copyChunks = ok
+		if s.opts.Verbose {
+			if ok {
+				log.Printf("Source already meets %s's specs, stream copying chunks\n", s.platform.GetName())
+			} else {
+				log.Printf("Transcoding chunks: %s\n", reason)
+			}
+		}
+	}
+
+	// A chunk whose encode failed, timed out or was cancelled is incomplete, so it is removed
+	var partial string
+	defer func() {
+		if partial != "" {
+			os.Remove(partial)
+		}
+	}()
+
+	renditions, err := s.renditions(metadata)
+	if err != nil {
+		return nil, err
+	}
+	// DASH carries every rung in the chunk's manifest, so each chunk is a single output
+	if ffmpegWrap.IsDASH(outputFormat) && renditions[0] != nil {
+		for _, r := range renditions {
+			s.dashLadder = append(s.dashLadder, *r)
+		}
+		renditions = []*config.Rendition{nil}
+	}
+
 	res := make([]types.ProcessedClip, 0)
+	var report []qualityReportEntry
 	for i := 0; i < numChunks; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		startTime := float64(i*s.opts.ChunkDuration) + skipSeconds
 
-		extension := fmt.Sprintf(".%s", outputFormat)
-		outputFileName := fmt.Sprintf("%s_chunk_%03d%s", baseFileName, i+1, extension)
-		outputPath := filepath.Join(s.opts.OutputDir, outputFileName)
+		chunkName, err := renderName(nameTemplate, NameData{
+			Base:     baseFileName,
+			Platform: string(s.opts.TargetPlatform),
+			Stage:    "chunk",
+			Index:    i + 1,
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
 
-		if s.opts.Verbose {
-			log.Printf("Processing chunk %d/%d: %s\n", i+1, numChunks, outputPath)
+		for _, rendition := range renditions {
+			name := chunkName
+			if rendition != nil {
+				name += "_" + rendition.Name
+			}
+			outputFileName := name + ffmpegWrap.FileExtension(outputFormat)
+			outputPath := filepath.Join(s.opts.OutputDir, outputFileName)
+
+			if s.opts.Verbose {
+				log.Printf("Processing chunk %d/%d: %s\n", i+1, numChunks, outputPath)
+			}
+
+			s.progress.set("chunk", i+1, numChunks)
+			partial = outputPath
+
+			chunkPath := outputPath
+			if assemble {
+				chunkPath = filepath.Join(tempDir, outputFileName)
+			}
+
+			copied := s.opts.StreamCopy && s.platform == nil
+			if s.copyChunks {
+				copied, err = s.copySegment(chunkPath, startTime, s.opts.ChunkDuration)
+				if err != nil {
+					return nil, fmt.Errorf("error copying chunk %d: %v", i+1, err)
+				}
+			}
+			if !s.copyChunks || !copied {
+				if err := s.encodeSegment(chunkPath, startTime, s.opts.ChunkDuration, rendition); err != nil {
+					return nil, fmt.Errorf("error processing chunk %d: %v", i+1, err)
+				}
+			}
+
+			// Scored against what the chunk was cut from, effects and speed
+			// changes included, and before assembly, the bookends and recap
+			// aren't part of the source
+			var quality *types.QualityScores
+			if s.opts.QualityReport {
+				s.progress.set("quality", i+1, numChunks)
+				quality, err = s.ffmpeg.MeasureQuality(chunkPath, s.source, startTime, float64(s.opts.ChunkDuration))
+				if err != nil {
+					return nil, fmt.Errorf("error measuring quality of chunk %d: %v", i+1, err)
+				}
+				report = append(report, newQualityReportEntry(outputPath, s.opts.InputPath, startTime, quality))
+			}
+
+			if assemble {
+				if err := s.assembleChunk(i, chunkPath, outputPath, startTime, tempDir, rendition); err != nil {
+					return nil, fmt.Errorf("error assembling chunk %d: %v", i+1, err)
+				}
+			}
+
+			if s.opts.ProcessesAudio() {
+				s.progress.set("audio", i+1, numChunks)
+				codec, bitrate := outputAudioCodec(s.platform, outputFormat)
+				if err := s.ffmpeg.ProcessAudio(outputPath, codec, bitrate); err != nil {
+					return nil, fmt.Errorf("error processing audio of chunk %d: %v", i+1, err)
+				}
+			}
+
+			partial = ""
+
+			if s.opts.Verbose {
+				log.Printf("Completed chunk %d/%d\n", i+1, numChunks)
+			}
+
+			metadata, err := ffmpegWrap.GetVideoMetadata(outputPath)
+			if err != nil {
+				return nil, fmt.Errorf("error getting video metadata: %v", err)
+			}
+
+			res = append(res, types.ProcessedClip{
+				FilePath:        outputPath,
+				DurationSeconds: uint64(metadata.Duration),
+				Rendition:       renditionName(rendition),
+				StreamCopied:    copied,
+				Quality:         quality,
+			})
+		}
+	}
+
+	if s.opts.QualityReport {
+		if err := writeQualityReport(filepath.Join(s.opts.OutputDir, config.QualityReportFileName), report); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// encodeSequence writes the image sequence input as a video to a temp dir,
+// returning its path and a func removing it
+func (s *Splitter) encodeSequence() (string, func(), error) {
+	fps := cmp.Or(s.opts.InputFPS, config.DefaultInputFPS)
+	if fps < 0 || fps > maxFrameRate {
+		return "", nil, fmt.Errorf("input frame rate %g must be between 1 and %d", fps, maxFrameRate)
+	}
+
+	tempDir, err := os.MkdirTemp("", config.SplitTempDirPrefix)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+
+	input := filepath.Join(tempDir, "sequence.mkv")
+	s.progress.set("sequence", 0, 0)
+	if err := s.ffmpeg.SequenceVideo(s.opts.InputPath, input, fps); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return input, cleanup, nil
+}
+
+// selectAudioTracks writes a copy of the input keeping only the selected
+// audio tracks to a temp dir, returning its path and a func removing it
+func (s *Splitter) selectAudioTracks() (string, func(), error) {
+	count, err := ffmpegWrap.AudioTrackCount(s.input)
+	if err != nil {
+		return "", nil, err
+	}
+	seen := make(map[int]bool)
+	for _, track := range s.opts.AudioTracks {
+		if track < 0 || track >= count {
+			return "", nil, fmt.Errorf("audio track %d doesn't exist, %s has %d (counting from 0)",
+				track, s.opts.InputPath, count)
 		}
+		if seen[track] {
+			return "", nil, fmt.Errorf("audio track %d is selected twice", track)
+		}
+		seen[track] = true
+	}
 
-		// Apply processing based on platform specifications
-		if s.platform != nil {
-			err = s.ffmpeg.ProcessForPlatform(s.opts.InputPath, outputPath, s.platform, startTime, s.opts.ChunkDuration)
+	tempDir, err := os.MkdirTemp("", config.SplitTempDirPrefix)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+
+	source := filepath.Join(tempDir, "source.mkv")
+	s.progress.set("audio-tracks", 0, 0)
+	if err := s.ffmpeg.SelectAudioTracks(s.input, source, s.opts.AudioTracks); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return source, cleanup, nil
+}
+
+// applyEffects writes a copy of the source, stabilized, retimed, looped and
+// run through the effects filter graph as set, to a temp dir, returning its path and a func removing it
+func (s *Splitter) applyEffects(effects string) (string, func(), error) {
+	tempDir, err := os.MkdirTemp("", config.SplitTempDirPrefix)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+
+	source := filepath.Join(tempDir, "effects.mkv")
+	s.progress.set("effects", 0, 0)
+	effects, err = withFaceBlurs(s.ffmpeg, s.source, effects, s.opts.EffectOptions)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if err := s.ffmpeg.ApplyEffects(s.source, source, effects, s.opts.EffectOptions); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return source, cleanup, nil
+}
+
+// assembleChunk joins the encoded chunk with its intro, recap and outro segments
+func (s *Splitter) assembleChunk(index int, chunkPath, outputPath string, startTime float64, tempDir string, rendition *config.Rendition) error {
+	metadata, err := ffmpegWrap.GetVideoMetadata(chunkPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to get chunk metadata")
+	}
+
+	segments := make([]ffmpegWrap.Segment, 0, 4)
+	if s.opts.IntroClipPath != "" {
+		segments = append(segments, ffmpegWrap.Segment{Path: s.opts.IntroClipPath})
+	}
+
+	// The first chunk has nothing to recap
+	if s.opts.RecapSeconds > 0 && index > 0 {
+		recapPath := filepath.Join(tempDir, "recap_"+filepath.Base(chunkPath))
+		recapStart := startTime - float64(s.opts.RecapSeconds)
+		s.progress.set("recap", index+1, s.progress.total)
+		if err := s.encodeSegment(recapPath, recapStart, s.opts.RecapSeconds, rendition); err != nil {
+			return errors.Wrap(err, "failed to extract recap")
+		}
+
+		recap := ffmpegWrap.Segment{Path: recapPath}
+		switch {
+		case s.recapASS:
+			// The recap is scaled to the chunk's size before the text is drawn
+			padding, _ := strconv.Atoi(config.TextPadding)
+			left, top, right, bottom := safeMarginPixels(safeArea(s.platform), padding, metadata.Width, metadata.Height)
+			style := assPlacement(cmp.Or(s.opts.Position, textTopLeft), left, top, right, bottom, s.opts.TextOptions)
+			style.fontSize, _ = strconv.Atoi(config.TextSize)
+			style.color = config.TextColor
+			style.box = true
+			scriptPath, err := writeASS(tempDir, fmt.Sprintf("recap_text_%d", index+1), metadata.Width, metadata.Height,
+				overlayLines(s.opts.RecapText, s.opts.Wrap), style, s.opts.TextOptions)
 			if err != nil {
-				return nil, fmt.Errorf("error processing chunk %d: %v", i+1, err)
+				return err
+			}
+			recap.VideoFilter = func(stream *ffmpeg.Stream) *ffmpeg.Stream {
+				return drawASS(stream, scriptPath, s.opts.TextOptions)
+			}
+		case len(s.recapText) > 0:
+			recap.VideoFilter = func(stream *ffmpeg.Stream) *ffmpeg.Stream {
+				return addTextOverlay(stream, s.recapText, textTopLeft, safeArea(s.platform), s.opts.TextOptions)
 			}
-		} else {
-			return nil, errors.New("platform is nil")
 		}
+		segments = append(segments, recap)
+	}
 
+	segments = append(segments, ffmpegWrap.Segment{Path: chunkPath})
+	if s.opts.OutroClipPath != "" {
+		segments = append(segments, ffmpegWrap.Segment{Path: s.opts.OutroClipPath})
+	}
+
+	s.progress.set("assemble", index+1, s.progress.total)
+	return s.ffmpeg.ConcatSegments(segments, outputPath, metadata.Width, metadata.Height, s.platform, s.outputFormat)
+}
+
+// copySegment stream copies a segment of an input that already meets the
+// platform's specs. It reports false, removing the copy, when the copy is over
+// the platform's file size limit and needs re-encoding after all.
+func (s *Splitter) copySegment(outputPath string, startTime float64, duration int) (bool, error) {
+	if err := s.ffmpeg.ProcessGeneric(s.source, outputPath, s.outputFormat, startTime, duration, true); err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return false, err
+	}
+	if info.Size() > s.platform.GetMaxFileSize() {
 		if s.opts.Verbose {
-			log.Printf("Completed chunk %d/%d\n", i+1, numChunks)
+			log.Printf("Copied chunk is %d bytes, over %s's limit, transcoding instead\n", info.Size(), s.platform.GetName())
 		}
+		os.Remove(outputPath)
+		return false, nil
+	}
+	return true, nil
+}
 
-		metadata, err := ffmpegWrap.GetVideoMetadata(outputPath)
-		if err != nil {
-			return nil, fmt.Errorf("error getting video metadata: %v", err)
-		}
+// encodeSegment cuts a segment of the input. It packages every ladder rung
+// into one manifest for DASH output with a ladder, encodes at the rung's size
+// and bitrate when rendition is set, and otherwise applies the platform's
+// specifications when one is set or the generic path
+func (s *Splitter) encodeSegment(outputPath string, startTime float64, duration int, rendition *config.Rendition) error {
+	if s.dashLadder != nil {
+		return s.ffmpeg.ProcessDASHLadder(s.source, outputPath, s.outputFormat, startTime, duration, s.dashLadder)
+	}
+	if rendition != nil {
+		return s.ffmpeg.ProcessRendition(s.source, outputPath, s.outputFormat, startTime, duration,
+			rendition.Height, rendition.VideoBitrate, rendition.AudioBitrate)
+	}
+	if s.platform != nil {
+		return s.ffmpeg.ProcessForPlatform(s.source, outputPath, s.platform, startTime, duration)
+	}
 
-		res = append(res, types.ProcessedClip{
-			FilePath:        outputPath,
-			DurationSeconds: uint64(metadata.Duration),
-		})
+	return s.ffmpeg.ProcessGeneric(s.source, outputPath, s.outputFormat, startTime, duration, s.opts.StreamCopy)
+}
+
+// renditions returns the ladder rungs to encode every chunk at, leaving out
+// rungs larger than the source, or a single nil rung without a ladder
+func (s *Splitter) renditions(source *ffmpegWrap.VideoMetadata) ([]*config.Rendition, error) {
+	if len(s.opts.Renditions) == 0 {
+		return []*config.Rendition{nil}, nil
+	}
+	if s.platform != nil {
+		return nil, fmt.Errorf("a bitrate ladder can't be combined with a target platform")
+	}
+	if s.opts.StreamCopy {
+		return nil, fmt.Errorf("a bitrate ladder can't be combined with stream copy")
+	}
+	if s.opts.Quality > 0 {
+		return nil, fmt.Errorf("a bitrate ladder can't be combined with constant quality encoding")
 	}
 
+	sourceSize := min(source.Width, source.Height)
+	res := make([]*config.Rendition, 0, len(s.opts.Renditions))
+	for _, r := range s.opts.Renditions {
+		if r.Height <= 0 || r.VideoBitrate == "" {
+			return nil, fmt.Errorf("ladder rung %q needs a height and a video bitrate", r.Name)
+		}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("%dp", r.Height)
+		}
+		// Upscaling only wastes bits
+		if r.Height > sourceSize {
+			if s.opts.Verbose {
+				log.Printf("Skipping %s rendition, the source is only %dp\n", r.Name, sourceSize)
+			}
+			continue
+		}
+		res = append(res, &r)
+	}
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("every ladder rung is larger than the %dp source", sourceSize)
+	}
 	return res, nil
 }
+
+// renditionName returns the rung's name, or "" for the nil rung
+func renditionName(rendition *config.Rendition) string {
+	if rendition == nil {
+		return ""
+	}
+	return rendition.Name
+}
@@ -1,107 +1,266 @@
 package processor
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/ZacxDev/video-splitter/config"
 	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/ZacxDev/video-splitter/internal/platform"
 	"github.com/ZacxDev/video-splitter/pkg/types"
 	"github.com/pkg/errors"
 	ffmpeg "github.com/u2takey/ffmpeg-go"
-	"golang.org/x/exp/rand"
 )
 
+// Process applies the template to the input videos
 func (t *Templater) Process() (*types.ProcessedOutput, error) {
+	return t.ProcessContext(context.Background())
+}
+
+// ProcessContext applies the template, stopping the running encode and
+// returning ctx's error once ctx is done
+func (t *Templater) ProcessContext(ctx context.Context) (*types.ProcessedOutput, error) {
+	t.ffmpeg.SetContext(ctx)
+
 	if len(t.opts.InputPaths) == 0 {
 		return nil, fmt.Errorf("no input videos provided")
 	}
 
+	if ffmpegWrap.IsDASH(t.opts.OutputFormat) {
+		return nil, fmt.Errorf("DASH output is only supported when splitting")
+	}
+	if err := checkQuality(t.opts.Quality, t.opts.EncodePreset); err != nil {
+		return nil, err
+	}
+	if err := setupHDR(t.ffmpeg, t.opts.PreserveHDR, t.opts.TonemapOperator, t.platform, t.opts.Verbose); err != nil {
+		return nil, err
+	}
+	if err := checkAudio(&t.opts.AudioOptions, t.opts.NoAudio, t.opts.OutputFormat); err != nil {
+		return nil, err
+	}
+	if err := checkAudioEncoding(t.opts.AudioCodec, t.opts.AudioBitrate, t.opts.OutputFormat); err != nil {
+		return nil, err
+	}
+	cellAudio, err := parseTemplateAudio(t.opts.TemplateAudio, t.opts.TemplateAudioWeights, len(t.opts.InputPaths))
+	if err != nil {
+		return nil, err
+	}
+	if t.opts.FrameRate < 0 || t.opts.FrameRate > maxFrameRate {
+		return nil, fmt.Errorf("template frame rate %d must be between 1 and %d", t.opts.FrameRate, maxFrameRate)
+	}
+	if err := checkTransition(t.opts.Transition, t.opts.TransitionDuration, t.outroStyle().duration); err != nil {
+		return nil, err
+	}
+	if err := checkOutroStyle(t.opts); err != nil {
+		return nil, err
+	}
+	if err := checkWatermark(t.opts); err != nil {
+		return nil, err
+	}
+	if err := checkText(t.opts.TextOptions); err != nil {
+		return nil, err
+	}
+	if err := checkObscurify(t.opts.ObscurifyOptions); err != nil {
+		return nil, err
+	}
+	effects, err := loadEffects(t.opts.EffectOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSharpen(t.opts.Sharpen); err != nil {
+		return nil, err
+	}
+	if err := checkReframe(t.opts); err != nil {
+		return nil, err
+	}
+	if t.opts.ImageDuration < 0 {
+		return nil, fmt.Errorf("image duration %gs can't be negative", t.opts.ImageDuration)
+	}
+	if t.opts.IntroDuration < 0 {
+		return nil, fmt.Errorf("intro duration %ds can't be negative", t.opts.IntroDuration)
+	}
+	durationStrategy := cmp.Or(t.opts.DurationStrategy, durationLongestFreeze)
+	if !slices.Contains(durationStrategies, durationStrategy) {
+		return nil, fmt.Errorf("unsupported duration strategy: %s (supported: %s)",
+			durationStrategy, strings.Join(durationStrategies, ", "))
+	}
+
 	tempDir, err := os.MkdirTemp("", "video_template_")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp directory: %v", err)
 	}
 	defer os.RemoveAll(tempDir)
 
-	var targetDims config.VideoDimensions
-	var targetSize int64
+	// Animations are converted from the finished video, everything before
+	// that is encoded as MP4
+	var animation string
+	if ffmpegWrap.IsAnimation(t.opts.OutputFormat) {
+		animation = t.opts.OutputFormat
+		t.opts.OutputFormat = "mp4"
+	}
 
-	// Determine template configuration
-	switch t.opts.TemplateType {
-	case "1x1":
-		if len(t.opts.InputPaths) > 1 {
-			log.Printf("Warning: 1x1 template only uses first video, ignoring remaining %d videos",
-				len(t.opts.InputPaths)-1)
-			t.opts.InputPaths = t.opts.InputPaths[:1]
+	if format := ffmpegWrap.ResolveOutputFormat(t.opts.OutputFormat); format != t.opts.OutputFormat {
+		t.opts.OutputFormat = format
+		t.opts.OutputPath = ffmpegWrap.EnsureExtension(t.opts.OutputPath, ffmpegWrap.FileExtension(format))
+	}
+
+	if t.opts.NameTemplate != "" {
+		finalName, err := renderName(t.opts.NameTemplate, NameData{
+			Base:     baseName(t.opts.OutputPath),
+			Platform: string(t.opts.TargetPlatform),
+			Stage:    "final",
+			Index:    1,
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
 		}
-		targetDims = config.VideoDimensions{
-			Width:  config.Template1x1Width,
-			Height: config.Template1x1Height,
+
+		ext := filepath.Ext(t.opts.OutputPath)
+		if ext == "" {
+			ext = ffmpegWrap.FileExtension(t.opts.OutputFormat)
 		}
-		targetSize = config.Template1x1MaxSize
+		t.opts.OutputPath = filepath.Join(filepath.Dir(t.opts.OutputPath), finalName+ext)
+	}
 
-	case "2x2":
-		if len(t.opts.InputPaths) > 4 {
-			log.Printf("Warning: 2x2 template only uses first 4 videos, ignoring remaining %d videos",
-				len(t.opts.InputPaths)-4)
-			t.opts.InputPaths = t.opts.InputPaths[:4]
-		} else if len(t.opts.InputPaths) < 4 {
-			return nil, fmt.Errorf("2x2 template requires exactly 4 videos, got %d", len(t.opts.InputPaths))
+	// Inputs are placed by the custom layout when one is set, otherwise by the
+	// template type's grid
+	var layout templateLayout
+	var rects []config.LayoutRect
+	var canvas config.VideoDimensions
+	var targetDims []config.VideoDimensions
+	name := t.opts.TemplateType
+	if t.opts.Layout != nil {
+		canvas = t.opts.Layout.Canvas(defaultCanvas(t.platform))
+		if rects, err = t.opts.Layout.Resolve(canvas); err != nil {
+			return nil, err
 		}
-		targetDims = config.VideoDimensions{
-			Width:  config.Template2x2Width,
-			Height: config.Template2x2Height,
+		for _, r := range rects {
+			targetDims = append(targetDims, config.VideoDimensions{Width: r.Width, Height: r.Height})
 		}
-		targetSize = config.Template2x2MaxSize
-
-	case "3x1":
-		if len(t.opts.InputPaths) > 3 {
-			log.Printf("Warning: 3x1 template only uses first 3 videos, ignoring remaining %d videos",
-				len(t.opts.InputPaths)-3)
-			t.opts.InputPaths = t.opts.InputPaths[:3]
-		} else if len(t.opts.InputPaths) < 3 {
-			return nil, fmt.Errorf("3x1 template requires exactly 3 videos, got %d", len(t.opts.InputPaths))
+		if name == "" {
+			name = "custom"
+		}
+	} else if t.opts.TemplateType == templateGreenscreen {
+		// A single cell covering the canvas, keyed over the background
+		canvas = defaultCanvas(t.platform)
+		rects = []config.LayoutRect{{Width: canvas.Width, Height: canvas.Height}}
+		targetDims = []config.VideoDimensions{canvas}
+	} else {
+		if layout, err = parseTemplateLayout(t.opts.TemplateType); err != nil {
+			return nil, err
 		}
-		targetDims = config.VideoDimensions{
-			Width:  config.Template3x1Width,
-			Height: config.Template3x1Height,
+		canvas = layout.canvas(t.platform)
+		grid, err := layout.rects(canvas, t.opts.TemplateGap)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range grid {
+			targetDims = append(targetDims, config.VideoDimensions{Width: r.Width, Height: r.Height})
+		}
+		// Framed cells are overlaid on the canvas like a custom layout's
+		if t.opts.TemplateGap > 0 || t.opts.TemplateBorder > 0 ||
+			t.opts.TemplateBackgroundImage != "" || t.opts.TemplateBackgroundBlur {
+			rects = grid
 		}
-		targetSize = config.Template3x1MaxSize
+	}
+	if err := checkTemplateFrame(t.opts, rects); err != nil {
+		return nil, err
+	}
+	if err := checkGreenscreen(t.opts); err != nil {
+		return nil, err
+	}
 
-	default:
-		return nil, fmt.Errorf("unsupported template type: %s", t.opts.TemplateType)
+	cells := len(targetDims)
+	if len(t.opts.InputPaths) > cells {
+		log.Printf("Warning: %s template only uses the first %d videos, ignoring remaining %d videos",
+			name, cells, len(t.opts.InputPaths)-cells)
+		t.opts.InputPaths = t.opts.InputPaths[:cells]
+	} else if len(t.opts.InputPaths) < cells {
+		return nil, fmt.Errorf("%s template requires exactly %d videos, got %d",
+			name, cells, len(t.opts.InputPaths))
+	}
+	captions, err := parseInputTexts(t.opts.InputTexts, cells)
+	if err != nil {
+		return nil, err
 	}
 
+	// The cells share the single video's size budget
+	targetSize := config.Template1x1MaxSize / int64(cells)
+
 	// Get target platform
 	plat := t.platform
 	// Prepare videos
 	optimizedPaths := make([]string, 0, len(t.opts.InputPaths))
+	var qualities []*types.QualityScores
+	var report []qualityReportEntry
 	for i, inputPath := range t.opts.InputPaths {
-		// First apply platform crop
-		maxWidth, maxHeight := plat.GetMaxDimensions()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Still images play as a clip of their own, zooming in or out in turn
+		if ffmpegWrap.IsImage(inputPath) {
+			stillPath := filepath.Join(tempDir, fmt.Sprintf("still_%d.mkv", i))
+			t.progress.set("still", i+1, len(t.opts.InputPaths))
+			err := t.ffmpeg.StillVideo(inputPath, stillPath, cmp.Or(t.opts.ImageDuration, config.DefaultImageDuration),
+				targetDims[i].Width, targetDims[i].Height, t.frameRate(), !t.opts.NoKenBurns, i%2 == 1)
+			if err != nil {
+				return nil, err
+			}
+			inputPath = stillPath
+		}
 
 		metadata, err := ffmpegWrap.GetVideoMetadata(inputPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get video metadata: %v", err)
 		}
 
-		croppedPath := inputPath
+		// Stabilizing, speed changes, loops and the effect chain run on the
+		// input as is, before anything else
+		sourcePath := inputPath
+		if preprocesses(effects, t.opts.EffectOptions, metadata.Duration) {
+			sourcePath = filepath.Join(tempDir, fmt.Sprintf("effects_%d.mkv", i))
+			t.progress.set("effects", i+1, len(t.opts.InputPaths))
+			inputEffects, err := withFaceBlurs(t.ffmpeg, inputPath, effects, t.opts.EffectOptions)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.ffmpeg.ApplyEffects(inputPath, sourcePath, inputEffects, t.opts.EffectOptions); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if metadata, err = ffmpegWrap.GetVideoMetadata(sourcePath); err != nil {
+				return nil, fmt.Errorf("failed to get video metadata: %v", err)
+			}
+		}
+
+		// First apply platform crop
+		maxWidth, maxHeight := plat.GetMaxDimensions()
+
+		croppedPath := sourcePath
 
 		// Handle forced portrait mode
 		if plat.ForcePortrait() && metadata.Width > metadata.Height {
-			croppedPath = filepath.Join(tempDir, fmt.Sprintf("cropped_%d."+t.opts.OutputFormat, i))
+			croppedPath, err = t.intermediatePath(tempDir, "cropped", i, inputPath)
+			if err != nil {
+				return nil, err
+			}
 
-			probe, err := ffmpeg.Probe(inputPath)
+			probe, err := ffmpeg.Probe(sourcePath)
 			if err != nil {
 				return nil, fmt.Errorf("error probing video: %v", err)
 			}
 
-			err = ffmpegWrap.ApplyPlatformCrop(
-				inputPath,
+			t.progress.set("crop", i+1, len(t.opts.InputPaths))
+			err = t.ffmpeg.ApplyPlatformCrop(
+				sourcePath,
 				croppedPath,
 				plat,
 				0,
@@ -110,7 +269,6 @@ func (t *Templater) Process() (*types.ProcessedOutput, error) {
 				maxWidth,
 				maxHeight,
 				probe,
-				t.opts.Verbose,
 			)
 			if err != nil {
 				return nil, errors.WithStack(err)
@@ -120,14 +278,21 @@ func (t *Templater) Process() (*types.ProcessedOutput, error) {
 		// Second, apply obscurify effects if enabled
 		processedPath := croppedPath
 		if t.opts.Obscurify {
-			obscurifiedPath := filepath.Join(tempDir, fmt.Sprintf("obscurified_%d."+t.opts.OutputFormat, i))
+			obscurifiedPath, err := t.intermediatePath(tempDir, "obscurified", i, inputPath)
+			if err != nil {
+				return nil, err
+			}
+			t.progress.set("obscurify", i+1, len(t.opts.InputPaths))
 			if err := t.ApplyObscurifyEffects(croppedPath, obscurifiedPath); err != nil {
 				return nil, fmt.Errorf("failed to apply obscurify effects to video %s: %v", croppedPath, err)
 			}
 			processedPath = obscurifiedPath
 		}
 
-		optimizedPath := filepath.Join(tempDir, fmt.Sprintf("optimized_%d."+t.opts.OutputFormat, i))
+		optimizedPath, err := t.intermediatePath(tempDir, "optimized", i, inputPath)
+		if err != nil {
+			return nil, err
+		}
 		optimizedPaths = append(optimizedPaths, optimizedPath)
 
 		outputFormat := strings.ToLower(t.opts.OutputFormat)
@@ -135,23 +300,62 @@ func (t *Templater) Process() (*types.ProcessedOutput, error) {
 			outputFormat = "mp4"
 		}
 
+		t.progress.set("optimize", i+1, len(t.opts.InputPaths))
 		err = t.ffmpeg.OptimizeVideo(
 			processedPath,
 			optimizedPath,
-			targetDims,
+			targetDims[i],
 			targetSize,
 			t.platform,
 			outputFormat,
+			t.frameRate(),
 		)
 
 		if err != nil {
 			return nil, fmt.Errorf("failed to optimize video %s: %v", inputPath, err)
 		}
+
+		// Scores the optimize encode, the layout is too different from any one input to compare
+		if t.opts.QualityReport {
+			t.progress.set("quality", i+1, len(t.opts.InputPaths))
+			scores, err := t.ffmpeg.MeasureQuality(optimizedPath, processedPath, 0, 0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to measure quality of video %s: %v", inputPath, err)
+			}
+			qualities = append(qualities, scores)
+			report = append(report, newQualityReportEntry(t.opts.OutputPath, inputPath, 0, scores))
+		}
+	}
+
+	// Every layout plays its inputs in parallel, so the output is as long as the
+	// longest, or the shortest per the duration strategy, and takes the color
+	// properties of the first HDR input, if any
+	var mainDuration, shortestDuration float64
+	var source *ffmpegWrap.VideoMetadata
+	durations := make([]float64, len(optimizedPaths))
+	for i, path := range optimizedPaths {
+		if metadata, err := ffmpegWrap.GetVideoMetadata(path); err == nil {
+			durations[i] = metadata.Duration
+			mainDuration = max(mainDuration, metadata.Duration)
+			if shortestDuration == 0 || metadata.Duration < shortestDuration {
+				shortestDuration = metadata.Duration
+			}
+			if source == nil || (metadata.IsHDR() && !source.IsHDR()) {
+				source = metadata
+			}
+		}
 	}
 
 	streams := make([]*ffmpeg.Stream, len(optimizedPaths))
 	for i, path := range optimizedPaths {
-		streams[i] = ffmpeg.Input(path)
+		if durationStrategy == durationLongestLoop && durations[i] < mainDuration {
+			streams[i] = ffmpeg.Input(path, ffmpeg.KwArgs{"stream_loop": -1})
+		} else {
+			streams[i] = ffmpeg.Input(path)
+		}
+	}
+	if durationStrategy == durationShortest && shortestDuration > 0 {
+		mainDuration = shortestDuration
 	}
 
 	outputFormat := strings.ToLower(t.opts.OutputFormat)
@@ -161,16 +365,18 @@ func (t *Templater) Process() (*types.ProcessedOutput, error) {
 
 	codecSettings := ffmpegWrap.GetCodecSettings(outputFormat)
 
-	var output *ffmpeg.Stream
-	var kwargs ffmpeg.KwArgs
-	switch t.opts.TemplateType {
-	case "1x1":
-		if len(streams) == 0 {
-			return nil, fmt.Errorf("no input streams available")
-		}
+	// Captions go on the cells before they're arranged, the streams keep
+	// carrying the inputs' audio
+	videos, err := t.captionInputs(tempDir, streams, captions, targetDims)
+	if err != nil {
+		return nil, err
+	}
 
-		output = streams[0]
-	case "2x2":
+	var output, audio *ffmpeg.Stream
+	var kwargs ffmpeg.KwArgs
+	if cells == 1 && rects == nil {
+		output = videos[0]
+	} else {
 		kwargs = ffmpeg.KwArgs{
 			"c:v":        codecSettings.VideoCodec,
 			"c:a":        codecSettings.AudioCodec,
@@ -181,70 +387,103 @@ func (t *Templater) Process() (*types.ProcessedOutput, error) {
 			"g":          60,
 			"keyint_min": 30,
 		}
-		output = process2x2Template(streams)
-	case "3x1":
-		kwargs = ffmpeg.KwArgs{
-			"c:v":        codecSettings.VideoCodec,
-			"c:a":        codecSettings.AudioCodec,
-			"b:v":        "0",
-			"pix_fmt":    "yuv420p",
-			"threads":    ffmpegWrap.GetOptimalThreadCount(),
-			"movflags":   "+faststart",
-			"g":          60,
-			"keyint_min": 30,
+		if durationStrategy != durationLongestFreeze && mainDuration > 0 {
+			// Looped inputs never end and stacks run to the longest input
+			kwargs["t"] = fmt.Sprintf("%.3f", mainDuration)
+		}
+		if rects != nil {
+			output = t.overlayCells(videos, rects, canvas, mainDuration)
+		} else {
+			output = processGridTemplate(videos, layout, canvas)
+		}
+		if !t.opts.NoAudio {
+			audio = gridAudio(streams, optimizedPaths, cellAudio)
 		}
-		output = process3x1Template(streams)
 	}
 
+	// Sharpening the text and watermark would only give them halos
+	if t.opts.Sharpen != "" && output != nil {
+		output = output.Filter("unsharp", ffmpeg.Args{t.opts.Sharpen})
+	}
 	if t.opts.LandscapeBottomRightText != "" && output != nil {
-		output = t.addBottomRightText(output, t.opts.LandscapeBottomRightText, t.opts.PortraitBottomRightText, plat.ForcePortrait())
+		output, err = t.addBottomRightText(tempDir, output, t.opts.LandscapeBottomRightText, t.opts.PortraitBottomRightText, canvas)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if t.opts.WatermarkImage != "" && output != nil {
+		output = t.addWatermarkImage(output, canvas)
+	}
+	if t.opts.BurnTimecode && output != nil {
+		name, args, _ := strings.Cut(ffmpegWrap.TimecodeFilter(0, safeArea(t.platform), t.opts.TextOptions), "=")
+		output = output.Filter(name, ffmpeg.Args{args})
+	}
+	if output != streams[0] && audio == nil && !t.opts.NoAudio {
+		// Filtering a single input no longer carries its audio along
+		audio = gridAudio(streams, optimizedPaths, cellAudio)
 	}
 
 	if t.opts.Verbose {
 		log.Printf("Creating final output video: %s", t.opts.OutputPath)
 	}
 
-	mainVideoPath := filepath.Join(tempDir, "main."+t.opts.OutputFormat)
-	err = output.Output(mainVideoPath, kwargs).OverWriteOutput().ErrorToStdOut().Run()
+	t.progress.set("compose", 0, 0)
+	mainVideoPath := filepath.Join(tempDir, "main"+ffmpegWrap.FileExtension(t.opts.OutputFormat))
+	outputs := []*ffmpeg.Stream{output}
+	if audio != nil {
+		outputs = append(outputs, audio)
+	}
+	err = t.ffmpeg.Run(ffmpeg.Output(outputs, mainVideoPath, t.ffmpeg.OutputArgs(kwargs, source)), mainDuration)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create main video: %v", err)
 	}
 
-	if len(t.opts.OutroLines) > 0 {
-		outroPath, err := t.createOutroVideo(tempDir, mainVideoPath)
-		if err != nil {
-			return nil, err
-		}
+	videoPath := t.opts.OutputPath
+	if animation != "" {
+		videoPath = filepath.Join(tempDir, "final"+ffmpegWrap.FileExtension(t.opts.OutputFormat))
+	}
 
-		// Create list file for concatenation
-		listPath := filepath.Join(tempDir, "concat.txt")
-		listContent := fmt.Sprintf("file '%s'\nfile '%s'", mainVideoPath, outroPath)
-		if err := os.WriteFile(listPath, []byte(listContent), 0644); err != nil {
-			return nil, fmt.Errorf("failed to create concat list: %v", err)
+	if len(t.opts.IntroLines) > 0 {
+		introducedPath := filepath.Join(tempDir, "introduced"+ffmpegWrap.FileExtension(t.opts.OutputFormat))
+		if err := t.PrependIntro(tempDir, mainVideoPath, introducedPath); err != nil {
+			return nil, err
 		}
+		mainVideoPath = introducedPath
+	}
 
-		// Concatenate main video with outro
-		err = ffmpeg.Input(
-			listPath,
-			ffmpeg.KwArgs{"f": "concat", "safe": "0"},
-		).Output(
-			t.opts.OutputPath,
-			ffmpeg.KwArgs{
-				"c":        "copy",
-				"movflags": "+faststart",
-			},
-		).OverWriteOutput().ErrorToStdOut().Run()
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to concatenate outro: %v", err)
+	if len(t.opts.OutroLines) > 0 {
+		if err := t.AppendOutro(tempDir, mainVideoPath, videoPath); err != nil {
+			if ctx.Err() != nil {
+				// Don't leave a half written output behind
+				os.Remove(videoPath)
+			}
+			return nil, err
 		}
 	} else {
 		// If no outro, just move the main video to final destination
-		if err := os.Rename(mainVideoPath, t.opts.OutputPath); err != nil {
+		if err := os.Rename(mainVideoPath, videoPath); err != nil {
 			return nil, fmt.Errorf("failed to move final video: %v", err)
 		}
 	}
 
+	if t.opts.ProcessesAudio() {
+		t.progress.set("audio", 0, 0)
+		codec, bitrate := outputAudioCodec(t.platform, t.opts.OutputFormat)
+		if err := t.ffmpeg.ProcessAudio(videoPath, codec, bitrate); err != nil {
+			return nil, err
+		}
+	}
+
+	if animation != "" {
+		t.progress.set("animate", 0, 0)
+		if err := t.ffmpeg.ProcessAnimation(videoPath, t.opts.OutputPath, animation, 0, 0); err != nil {
+			if ctx.Err() != nil {
+				os.Remove(t.opts.OutputPath)
+			}
+			return nil, err
+		}
+	}
+
 	finalFileInfo, err := os.Stat(t.opts.OutputPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get final file info: %v", err)
@@ -260,88 +499,685 @@ func (t *Templater) Process() (*types.ProcessedOutput, error) {
 		return nil, fmt.Errorf("error getting video metadata: %v", err)
 	}
 
+	if t.opts.QualityReport {
+		if err := writeQualityReport(TemplateQualityReportPath(t.opts.OutputPath), report); err != nil {
+			return nil, err
+		}
+	}
+
 	return &types.ProcessedOutput{
 		FilePath:        t.opts.OutputPath,
 		DurationSeconds: uint64(metadata.Duration),
+		Quality:         averageQuality(qualities),
 	}, nil
 }
 
-func getRandomColor() string {
-	rand.Seed(uint64(time.Now().UnixNano()))
-	// Vibrant color palette
-	colors := []string{
-		"yellow", "magenta", "cyan", "lime", "red",
-		"orange", "#00ff00", "#ff00ff", "#00ffff", "#ff3366",
+// TemplateInputCount returns the number of input videos a template type consumes
+func TemplateInputCount(templateType string) (int, error) {
+	if templateType == templateGreenscreen {
+		return 1, nil
+	}
+	layout, err := parseTemplateLayout(templateType)
+	if err != nil {
+		return 0, err
+	}
+	return layout.cells(), nil
+}
+
+// TemplateInputs returns the number of input videos the template options
+// consume, one per cell of the custom layout when one is set
+func TemplateInputs(opts *config.VideoTemplateOptions) (int, error) {
+	if opts.Layout != nil {
+		return len(opts.Layout.Cells), nil
 	}
-	return colors[rand.Intn(len(colors))]
+	return TemplateInputCount(opts.TemplateType)
 }
 
-func (t *Templater) addBottomRightText(input *ffmpeg.Stream, landscapeText, portraitText string, isPortrait bool) *ffmpeg.Stream {
-	text := landscapeText
-	fontsize := "32"
-	if isPortrait {
-		fontsize = "24"
-		text = portraitText
+// frameRate returns the frame rate the cells are normalized to, the
+// platform's unless the options set one
+func (t *Templater) frameRate() int {
+	if t.opts.FrameRate > 0 {
+		return t.opts.FrameRate
+	}
+	return t.platform.GetFrameRate()
+}
+
+// checkReframe rejects unknown reframe modes and detectors without smart
+// reframing
+func checkReframe(opts *config.VideoTemplateOptions) error {
+	switch opts.Reframe {
+	case "", ffmpegWrap.ReframeCenter:
+		if opts.ReframeDetector != "" {
+			return fmt.Errorf("a reframe detector needs --reframe %s", ffmpegWrap.ReframeSmart)
+		}
+	case ffmpegWrap.ReframeSmart:
+		if opts.ReframeDetector == "" && !ffmpegWrap.HasFilter("edgedetect") {
+			return fmt.Errorf("smart reframing needs an ffmpeg build with the edgedetect filter or a reframe detector")
+		}
+	default:
+		return fmt.Errorf("unsupported reframe mode: %s (supported: %s, %s)", opts.Reframe, ffmpegWrap.ReframeCenter, ffmpegWrap.ReframeSmart)
+	}
+	return nil
+}
+
+// maxFrameRate bounds frame rates to what players handle
+const maxFrameRate = 120
+
+// maxTemplateCells bounds grids, beyond it the cells get too small to watch
+const maxTemplateCells = 16
+
+// Template types of a grid, e.g. "2x2", and of a vertical stack, e.g. "3v"
+var (
+	templateTypeRe  = regexp.MustCompile(`^(\d+)x(\d+)$`)
+	verticalStackRe = regexp.MustCompile(`^(\d+)v$`)
+)
+
+// templateLayout is a grid template's number of columns and rows
+type templateLayout struct {
+	columns, rows int
+	vertical      bool // A stack filling a portrait canvas whatever the platform
+}
+
+// parseTemplateLayout parses a template type of <columns>x<rows>, e.g. "3x1"
+// for three videos side by side, or <videos>v for a vertical stack, e.g. "2v"
+func parseTemplateLayout(templateType string) (templateLayout, error) {
+	if match := verticalStackRe.FindStringSubmatch(templateType); match != nil {
+		rows, _ := strconv.Atoi(match[1])
+		if rows < 2 || rows > maxTemplateCells {
+			return templateLayout{}, fmt.Errorf("template type %s must stack between 2 and %d videos", templateType, maxTemplateCells)
+		}
+		return templateLayout{columns: 1, rows: rows, vertical: true}, nil
+	}
+
+	match := templateTypeRe.FindStringSubmatch(templateType)
+	if match == nil {
+		return templateLayout{}, fmt.Errorf("unsupported template type: %s (expected <columns>x<rows>, e.g. 2x2, or <videos>v, e.g. 2v)", templateType)
+	}
+
+	columns, _ := strconv.Atoi(match[1])
+	rows, _ := strconv.Atoi(match[2])
+	layout := templateLayout{columns: columns, rows: rows}
+	// Bounding each side first keeps huge ones from overflowing the product
+	if columns < 1 || rows < 1 || columns > maxTemplateCells || rows > maxTemplateCells || layout.cells() > maxTemplateCells {
+		return templateLayout{}, fmt.Errorf("template type %s must have between 1 and %d cells", templateType, maxTemplateCells)
+	}
+	return layout, nil
+}
+
+func (l templateLayout) cells() int {
+	return l.columns * l.rows
+}
+
+// cellDimensions returns the size of one cell, the canvas divided by the grid
+// and rounded down to even dimensions
+func (l templateLayout) cellDimensions(canvas config.VideoDimensions) config.VideoDimensions {
+	return config.VideoDimensions{
+		Width:  canvas.Width / l.columns &^ 1,
+		Height: canvas.Height / l.rows &^ 1,
+	}
+}
+
+// rects places the cells of the layout on canvas, left to right and top to
+// bottom, with gap pixels between them. The grid is centered in the pixels
+// left over from rounding the cells down to even dimensions.
+func (l templateLayout) rects(canvas config.VideoDimensions, gap int) ([]config.LayoutRect, error) {
+	if gap < 0 {
+		return nil, fmt.Errorf("template gap %d can't be negative", gap)
+	}
+	width := (canvas.Width - gap*(l.columns-1)) / l.columns &^ 1
+	height := (canvas.Height - gap*(l.rows-1)) / l.rows &^ 1
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("template gap %d leaves no room for the cells of a %dx%d canvas", gap, canvas.Width, canvas.Height)
+	}
+
+	left := (canvas.Width - l.columns*width - (l.columns-1)*gap) / 2
+	top := (canvas.Height - l.rows*height - (l.rows-1)*gap) / 2
+	rects := make([]config.LayoutRect, 0, l.cells())
+	for r := range l.rows {
+		for c := range l.columns {
+			rects = append(rects, config.LayoutRect{
+				X:      left + c*(width+gap),
+				Y:      top + r*(height+gap),
+				Width:  width,
+				Height: height,
+			})
+		}
+	}
+	return rects, nil
+}
+
+// canvas returns the output resolution of the layout for plat: the vertical
+// stack canvas for vertical stacks, otherwise the output resolution, turned
+// portrait for platforms that force it so the cells are sized for it
+func (l templateLayout) canvas(plat platform.Platform) config.VideoDimensions {
+	if l.vertical {
+		return config.VideoDimensions{Width: config.VerticalStackWidth, Height: config.VerticalStackHeight}
+	}
+	return defaultCanvas(plat)
+}
+
+// defaultCanvas returns the output resolution for plat, portrait for platforms
+// that force it
+func defaultCanvas(plat platform.Platform) config.VideoDimensions {
+	if plat != nil && plat.ForcePortrait() {
+		return config.VideoDimensions{Width: config.OutputHeight, Height: config.OutputWidth}
+	}
+	return config.VideoDimensions{Width: config.OutputWidth, Height: config.OutputHeight}
+}
+
+// checkTransition rejects unknown transitions and ones outlasting the outro of
+// outroDuration seconds
+func checkTransition(transition string, duration float64, outroDuration int) error {
+	if transition == "" {
+		return nil
+	}
+	if !ffmpegWrap.IsTransition(transition) {
+		return fmt.Errorf("unsupported transition: %s (supported: %s)",
+			transition, strings.Join(ffmpegWrap.Transitions(), ", "))
+	}
+	if duration < 0 || duration >= float64(outroDuration) {
+		return fmt.Errorf("transition duration %gs must be shorter than the %ds outro", duration, outroDuration)
+	}
+	return nil
+}
+
+// templateColorRe matches ffmpeg color names and hex codes, with an optional
+// alpha, e.g. "white", "#ff3366" or "black@0.5"
+var templateColorRe = regexp.MustCompile(`^(#|0x)?[0-9A-Za-z]+(@[0-9.]+)?$`)
+
+// checkTemplateFrame rejects malformed colors, conflicting or missing
+// backgrounds and borders too wide for the cells they frame
+func checkTemplateFrame(opts *config.VideoTemplateOptions, rects []config.LayoutRect) error {
+	for _, color := range append([]string{opts.TemplateBorderColor, opts.TemplateBackground, opts.TextColor}, opts.TextPalette...) {
+		if color != "" && !templateColorRe.MatchString(color) {
+			return fmt.Errorf("invalid template color %q, expected a name or hex code, e.g. 'white' or '#ff3366'", color)
+		}
 	}
-	col := getRandomColor()
-
-	return input.Filter("drawtext", ffmpeg.Args{
-		fmt.Sprintf(
-			"text='%s':"+
-				"fontsize="+fontsize+":"+ // Increased font size
-				"fontcolor=%s:"+ // Random vibrant color
-				"bordercolor=black:"+
-				"borderw=3:"+ // Thicker border
-				"x=w-tw-20:"+
-				"y=h-th-20:"+
-				"shadowcolor=black:"+
-				"shadowx=3:"+ // More pronounced shadow
-				"shadowy=3:"+ // More pronounced shadow
-				"box=1:"+
-				"boxcolor=black@0.6:"+ // Slightly more opaque box
-				"boxborderw=6", // Thicker box border
-			text,
-			col,
-		),
+
+	if opts.TemplateBackgroundImage != "" {
+		if opts.TemplateBackgroundBlur {
+			return fmt.Errorf("a template background image can't be combined with the blurred background")
+		}
+		if _, err := os.Stat(opts.TemplateBackgroundImage); err != nil {
+			return fmt.Errorf("template background image not found: %v", err)
+		}
+	}
+
+	if opts.TemplateBorder < 0 {
+		return fmt.Errorf("template border %d can't be negative", opts.TemplateBorder)
+	}
+	for i, r := range rects {
+		if 2*opts.TemplateBorder >= min(r.Width, r.Height) {
+			return fmt.Errorf("template border %d leaves nothing of cell %d (%dx%d)", opts.TemplateBorder, i+1, r.Width, r.Height)
+		}
+	}
+	return nil
+}
+
+// intermediatePath returns the temp file path for an input's processing stage.
+// Custom name templates are prefixed with the stage so stages never collide.
+func (t *Templater) intermediatePath(tempDir, stage string, index int, inputPath string) (string, error) {
+	if t.opts.NameTemplate == "" {
+		return filepath.Join(tempDir, fmt.Sprintf("%s_%d%s", stage, index, ffmpegWrap.FileExtension(t.opts.OutputFormat))), nil
+	}
+
+	name, err := renderName(t.opts.NameTemplate, NameData{
+		Base:     baseName(inputPath),
+		Platform: string(t.opts.TargetPlatform),
+		Stage:    stage,
+		Index:    index + 1,
 	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return filepath.Join(tempDir, fmt.Sprintf("%s_%d_%s%s", stage, index, name, ffmpegWrap.FileExtension(t.opts.OutputFormat))), nil
 }
 
-func process2x2Template(inputs []*ffmpeg.Stream) *ffmpeg.Stream {
-	scaled := make([]*ffmpeg.Stream, 4)
-	for i, input := range inputs {
-		scaled[i] = input.Filter("scale", ffmpeg.Args{"960:540"})
+// textColor returns the bottom right text color, picked from the palette
+// unless one is set
+func (t *Templater) textColor() string {
+	if t.opts.TextColor != "" {
+		return t.opts.TextColor
+	}
+	palette := t.opts.TextPalette
+	if len(palette) == 0 {
+		palette = config.DefaultTextPalette
 	}
+	return palette[t.rng.Intn(len(palette))]
+}
 
-	topRow := ffmpeg.Filter(
-		[]*ffmpeg.Stream{scaled[0], scaled[1]},
-		"hstack",
-		ffmpeg.Args{},
-	)
+func (t *Templater) addBottomRightText(tempDir string, input *ffmpeg.Stream, landscapeText, portraitText string, canvas config.VideoDimensions) (*ffmpeg.Stream, error) {
+	text := landscapeText
+	size := 32
+	if canvas.Height > canvas.Width {
+		size = 24
+		text = portraitText
+	}
+	col := t.textColor()
+	lines := overlayLines(text, t.opts.Wrap)
 
-	bottomRow := ffmpeg.Filter(
-		[]*ffmpeg.Stream{scaled[2], scaled[3]},
-		"hstack",
-		ffmpeg.Args{},
-	)
+	libass, err := shapeWithLibass(text)
+	if err != nil {
+		return nil, err
+	}
+	if libass {
+		// libass can't move or animate text along expressions like drawtext
+		if cmp.Or(t.opts.WatermarkMotion, watermarkStatic) != watermarkStatic || animated(t.opts.TextOptions) {
+			return nil, fmt.Errorf("moving or animating %q needs an ffmpeg build with libfribidi to shape its script", text)
+		}
+		left, top, right, bottom := safeMarginPixels(safeArea(t.platform), watermarkMargin, canvas.Width, canvas.Height)
+		style := assPlacement(cmp.Or(t.opts.Position, textBottomRight), left, top, right, bottom, t.opts.TextOptions)
+		style.fontSize = size
+		style.color = col
+		scriptPath, err := writeASS(tempDir, "bottom_right_text", canvas.Width, canvas.Height, lines, style, t.opts.TextOptions)
+		if err != nil {
+			return nil, err
+		}
+		return drawASS(input, scriptPath, t.opts.TextOptions), nil
+	}
 
-	return ffmpeg.Filter(
-		[]*ffmpeg.Stream{topRow, bottomRow},
-		"vstack",
-		ffmpeg.Args{},
+	overlay, err := writeAnimatedLines(tempDir, "bottom_right_text", lines, t.opts.TextOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lines stay at the text position or move as one block, each line as
+	// wide as its own text
+	spacing := lineSpacing(size)
+	blockH := blockHeight(len(lines), spacing)
+	x, y := textPosition(cmp.Or(t.opts.Position, textBottomRight), safeMargins(safeArea(t.platform), watermarkMargin, "w", "h"), blockH, t.opts.TextOptions)
+	if cmp.Or(t.opts.WatermarkMotion, watermarkStatic) != watermarkStatic {
+		x, y = watermarkPosition(t.opts.WatermarkMotion, t.watermarkInterval(), safeArea(t.platform), "w", "h", "tw", blockH)
+		x, y = withOffset(x, t.opts.OffsetX), withOffset(y, t.opts.OffsetY)
+	}
+	style := fmt.Sprintf(
+		"fontsize=%d:"+ // Increased font size
+			"fontcolor=%s:"+ // Random vibrant color
+			"bordercolor=black:"+
+			"borderw=3:"+ // Thicker border
+			"shadowcolor=black:"+
+			"shadowx=3:"+ // More pronounced shadow
+			"shadowy=3:"+ // More pronounced shadow
+			"box=1:"+
+			"boxcolor=black@0.6:"+ // Slightly more opaque box
+			"boxborderw=6", // Thicker box border
+		size,
+		col,
 	)
+	return drawOverlayLines(input, overlay, style, x, y, spacing, t.opts.TextOptions), nil
+}
+
+// parseInputTexts parses index=text captions of a template of cells inputs
+// into each input's caption
+func parseInputTexts(specs []string, cells int) (map[int]string, error) {
+	captions := make(map[int]string, len(specs))
+	for _, spec := range specs {
+		index, text, ok := strings.Cut(spec, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(index))
+		if !ok || err != nil {
+			return nil, fmt.Errorf("input text %q must be index=text", spec)
+		}
+		if n < 0 || n >= cells {
+			return nil, fmt.Errorf("input text %q must pick an input from 0 to %d", spec, cells-1)
+		}
+		if _, ok := captions[n]; ok {
+			return nil, fmt.Errorf("input %d has more than one input text", n)
+		}
+		captions[n] = text
+	}
+	return captions, nil
 }
 
-func process3x1Template(inputs []*ffmpeg.Stream) *ffmpeg.Stream {
-	scaled := make([]*ffmpeg.Stream, 3)
-	for i, input := range inputs {
-		scaled[i] = input.Filter("scale", ffmpeg.Args{"640:720"})
+// captionInputs returns the inputs with their captions drawn in the bottom
+// left corner, sized to the cells of dims they fill
+func (t *Templater) captionInputs(tempDir string, inputs []*ffmpeg.Stream, captions map[int]string, dims []config.VideoDimensions) ([]*ffmpeg.Stream, error) {
+	// Captions don't take the corner text's animation
+	text := config.TextOptions{FontFile: t.opts.FontFile, Font: t.opts.Font}
+
+	videos := slices.Clone(inputs)
+	for i := range videos {
+		caption, ok := captions[i]
+		if !ok {
+			continue
+		}
+		size := max(dims[i].Height/16, 16)
+		margin := max(dims[i].Height/40, 8)
+		lines := overlayLines(caption, t.opts.Wrap)
+		name := fmt.Sprintf("input_%d_text", i)
+
+		libass, err := shapeWithLibass(caption)
+		if err != nil {
+			return nil, err
+		}
+		if libass {
+			style := assPlacement(textBottomLeft, margin, margin, margin, margin, text)
+			style.fontSize = size
+			style.color = config.TextColor
+			style.box = true
+			scriptPath, err := writeASS(tempDir, name, dims[i].Width, dims[i].Height, lines, style, text)
+			if err != nil {
+				return nil, err
+			}
+			videos[i] = drawASS(videos[i], scriptPath, text)
+			continue
+		}
+
+		overlay, err := writeAnimatedLines(tempDir, name, lines, text)
+		if err != nil {
+			return nil, err
+		}
+		spacing := lineSpacing(size)
+		m := safeMargins(platform.SafeArea{}, margin, "w", "h")
+		x, y := textPosition(textBottomLeft, m, blockHeight(len(lines), spacing), text)
+		style := fmt.Sprintf(
+			"fontsize=%d:fontcolor=%s:bordercolor=%s:borderw=%s:box=1:boxcolor=black@0.5:boxborderw=5",
+			size, config.TextColor, config.TextBorderColor, config.TextBorderWidth,
+		)
+		videos[i] = drawOverlayLines(videos[i], overlay, style, x, y, spacing, text)
 	}
+	return videos, nil
+}
 
-	return ffmpeg.Filter(
-		[]*ffmpeg.Stream{scaled[0], scaled[1], scaled[2]},
-		"hstack",
-		ffmpeg.Args{"inputs=3"},
+// processGridTemplate scales the inputs to the layout's cells of canvas and
+// stacks them into rows, left to right and top to bottom in input order
+func processGridTemplate(inputs []*ffmpeg.Stream, layout templateLayout, canvas config.VideoDimensions) *ffmpeg.Stream {
+	dims := layout.cellDimensions(canvas)
+	scale := fmt.Sprintf("%d:%d", dims.Width, dims.Height)
+
+	rows := make([]*ffmpeg.Stream, layout.rows)
+	for r := range rows {
+		row := make([]*ffmpeg.Stream, layout.columns)
+		for c := range row {
+			row[c] = inputs[r*layout.columns+c].Filter("scale", ffmpeg.Args{scale})
+		}
+		rows[r] = stack(row, "hstack")
+	}
+	return stack(rows, "vstack")
+}
+
+// overlayCells scales the inputs to rects, frames them with the border, if
+// any, and overlays them on a canvas of the background color lasting
+// duration, lowest Z first. Over a background image or blur the cells keep
+// their aspect ratio and are centered in their rects instead of stretched, as
+// does the keyed input of greenscreen templates over its background.
+func (t *Templater) overlayCells(inputs []*ffmpeg.Stream, rects []config.LayoutRect, canvas config.VideoDimensions, duration float64) *ffmpeg.Stream {
+	order := make([]int, len(rects))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(rects[a].Z, rects[b].Z)
+	})
+
+	background := cmp.Or(t.opts.TemplateBackground, config.DefaultTemplateBackground)
+	border := t.opts.TemplateBorder
+	borderColor := cmp.Or(t.opts.TemplateBorderColor, config.DefaultTemplateBorderColor)
+
+	output := ffmpeg.Input(
+		fmt.Sprintf("color=c=%s:s=%dx%d:r=%d:d=%.3f", background, canvas.Width, canvas.Height, t.frameRate(), duration),
+		ffmpeg.KwArgs{"f": "lavfi"},
 	)
+
+	inputs = slices.Clone(inputs)
+	var backdrop *ffmpeg.Stream
+	switch {
+	case t.opts.TemplateType == templateGreenscreen:
+		backdrop = keyBackground(t.opts.KeyBackground, duration)
+		inputs[0] = t.chromaKey(inputs[0])
+	case t.opts.TemplateBackgroundImage != "":
+		backdrop = ffmpeg.Input(t.opts.TemplateBackgroundImage, ffmpeg.KwArgs{"loop": 1, "t": fmt.Sprintf("%.3f", duration)})
+	case t.opts.TemplateBackgroundBlur:
+		copies := inputs[0].Split()
+		backdrop = copies.Get("0")
+		inputs[0] = copies.Get("1")
+	}
+	fit := backdrop != nil
+	if fit {
+		backdrop = coverCanvas(backdrop, canvas)
+		if t.opts.TemplateBackgroundBlur {
+			backdrop = backdrop.Filter("gblur", ffmpeg.Args{}, ffmpeg.KwArgs{"sigma": 30})
+		}
+		output = ffmpeg.Filter([]*ffmpeg.Stream{output, backdrop}, "overlay", ffmpeg.Args{"0:0"})
+	}
+
+	for _, i := range order {
+		r := rects[i]
+		size := fmt.Sprintf("%d:%d", r.Width-2*border, r.Height-2*border)
+		position := fmt.Sprintf("%d:%d", r.X, r.Y)
+		var cell *ffmpeg.Stream
+		if fit {
+			cell = inputs[i].Filter("scale", ffmpeg.Args{size}, ffmpeg.KwArgs{"force_original_aspect_ratio": "decrease", "force_divisible_by": 2})
+			if border > 0 {
+				cell = cell.Filter("pad", ffmpeg.Args{fmt.Sprintf("iw+%d:ih+%d:%d:%d:%s", 2*border, 2*border, border, border, borderColor)})
+			}
+			position = fmt.Sprintf("%d+(%d-w)/2:%d+(%d-h)/2", r.X, r.Width, r.Y, r.Height)
+		} else {
+			cell = inputs[i].Filter("scale", ffmpeg.Args{size})
+			if border > 0 {
+				cell = cell.Filter("pad", ffmpeg.Args{fmt.Sprintf("%d:%d:%d:%d:%s", r.Width, r.Height, border, border, borderColor)})
+			}
+		}
+		output = ffmpeg.Filter([]*ffmpeg.Stream{output, cell}, "overlay", ffmpeg.Args{position})
+	}
+	return output
+}
+
+// templateGreenscreen is the template type keying its single input over a
+// background video or image
+const templateGreenscreen = "greenscreen"
+
+// keyColors names the key colors despill knows how to clean up after
+var keyColors = map[string]string{
+	"green": "0x00ff00",
+	"blue":  "0x0000ff",
+}
+
+// keyColorRe matches hex key colors
+var keyColorRe = regexp.MustCompile(`^(#|0x)([0-9A-Fa-f]{2})([0-9A-Fa-f]{2})([0-9A-Fa-f]{2})$`)
+
+// checkGreenscreen rejects greenscreen templates without a background, keying
+// options of other templates and key settings out of range
+func checkGreenscreen(opts *config.VideoTemplateOptions) error {
+	if opts.TemplateType != templateGreenscreen {
+		if opts.KeyBackground != "" {
+			return fmt.Errorf("a key background needs the %s template", templateGreenscreen)
+		}
+		return nil
+	}
+
+	if opts.KeyBackground == "" {
+		return fmt.Errorf("the %s template needs a key background video or image", templateGreenscreen)
+	}
+	if _, err := os.Stat(opts.KeyBackground); err != nil {
+		return fmt.Errorf("key background not found: %v", err)
+	}
+	if opts.TemplateBackgroundImage != "" || opts.TemplateBackgroundBlur {
+		return fmt.Errorf("the %s template's background is the key background, it can't be combined with another", templateGreenscreen)
+	}
+	if _, _, err := parseKeyColor(opts.KeyColor); err != nil {
+		return err
+	}
+	if opts.KeySimilarity != 0 && (opts.KeySimilarity < 0.01 || opts.KeySimilarity > 1) {
+		return fmt.Errorf("key similarity %g must be between 0.01 and 1", opts.KeySimilarity)
+	}
+	if opts.KeyBlend < 0 || opts.KeyBlend > 1 {
+		return fmt.Errorf("key blend %g must be between 0 and 1", opts.KeyBlend)
+	}
+	for _, filter := range []string{"chromakey", "despill"} {
+		if !ffmpegWrap.HasFilter(filter) {
+			return fmt.Errorf("the %s template needs an ffmpeg build with the %s filter", templateGreenscreen, filter)
+		}
+	}
+	return nil
+}
+
+// parseKeyColor returns the hex code of a key color, DefaultKeyColor when
+// empty, and the despill type cleaning up its spill, "green" or "blue", or ""
+// when it's neither
+func parseKeyColor(color string) (string, string, error) {
+	color = strings.ToLower(cmp.Or(color, config.DefaultKeyColor))
+	if hex, ok := keyColors[color]; ok {
+		color = hex
+	}
+	match := keyColorRe.FindStringSubmatch(color)
+	if match == nil {
+		return "", "", fmt.Errorf("invalid key color %q, expected green, blue or a hex code, e.g. '#00ff00'", color)
+	}
+
+	r, _ := strconv.ParseUint(match[2], 16, 8)
+	g, _ := strconv.ParseUint(match[3], 16, 8)
+	b, _ := strconv.ParseUint(match[4], 16, 8)
+	hex := "0x" + match[2] + match[3] + match[4]
+	switch {
+	case g > r && g > b:
+		return hex, "green", nil
+	case b > r && b > g:
+		return hex, "blue", nil
+	}
+	return hex, "", nil
+}
+
+// chromaKey keys the key color out of video, made transparent, and removes
+// the color the screen spilled onto the subject
+func (t *Templater) chromaKey(video *ffmpeg.Stream) *ffmpeg.Stream {
+	// Validated by checkGreenscreen
+	color, despill, _ := parseKeyColor(t.opts.KeyColor)
+	similarity := cmp.Or(t.opts.KeySimilarity, config.DefaultKeySimilarity)
+	blend := cmp.Or(t.opts.KeyBlend, config.DefaultKeyBlend)
+
+	video = video.Filter("chromakey", ffmpeg.Args{}, ffmpeg.KwArgs{
+		"color":      color,
+		"similarity": similarity,
+		"blend":      blend,
+	})
+	if despill != "" {
+		video = video.Filter("despill", ffmpeg.Args{}, ffmpeg.KwArgs{"type": despill})
+	}
+	return video
+}
+
+// keyBackground returns the background of a greenscreen template lasting
+// duration, a still image or a video looped for as long as it takes
+func keyBackground(path string, duration float64) *ffmpeg.Stream {
+	kwargs := ffmpeg.KwArgs{"stream_loop": -1, "t": fmt.Sprintf("%.3f", duration)}
+	if ffmpegWrap.IsImage(path) {
+		kwargs = ffmpeg.KwArgs{"loop": 1, "t": fmt.Sprintf("%.3f", duration)}
+	}
+	return ffmpeg.Input(path, kwargs)
+}
+
+// coverCanvas scales video to cover canvas, cropping what overflows it
+func coverCanvas(video *ffmpeg.Stream, canvas config.VideoDimensions) *ffmpeg.Stream {
+	size := fmt.Sprintf("%d:%d", canvas.Width, canvas.Height)
+	return video.
+		Filter("scale", ffmpeg.Args{size}, ffmpeg.KwArgs{"force_original_aspect_ratio": "increase"}).
+		Filter("crop", ffmpeg.Args{size})
+}
+
+// stack joins streams with the hstack or vstack filter, which need at least
+// two inputs
+func stack(streams []*ffmpeg.Stream, filter string) *ffmpeg.Stream {
+	if len(streams) == 1 {
+		return streams[0]
+	}
+	return ffmpeg.Filter(streams, filter, ffmpeg.Args{fmt.Sprintf("inputs=%d", len(streams))})
+}
+
+// Duration strategies of templates whose inputs differ in length: trim to the
+// shortest input, or run as long as the longest with the shorter inputs
+// looping or frozen on their last frame
+const (
+	durationShortest      = "shortest"
+	durationLongestLoop   = "longest-loop"
+	durationLongestFreeze = "longest-freeze"
+)
+
+var durationStrategies = []string{durationShortest, durationLongestLoop, durationLongestFreeze}
+
+// templateAudio is the parsed audio strategy of a grid template
+type templateAudio struct {
+	mute    bool
+	cell    int       // Index of the cell whose audio is kept, -1 mixes every cell
+	weights []float64 // Of each cell when mixing
+}
+
+// parseTemplateAudio parses a grid audio strategy for a template of cells inputs
+func parseTemplateAudio(spec string, weights []float64, cells int) (templateAudio, error) {
+	audio := templateAudio{cell: -1, weights: weights}
+	switch {
+	case spec == "" || spec == "mix":
+	case spec == "mute":
+		audio.mute = true
+	case spec == "first":
+		audio.cell = 0
+	case strings.HasPrefix(spec, "index="):
+		n, err := strconv.Atoi(strings.TrimPrefix(spec, "index="))
+		if err != nil || n < 1 || n > cells {
+			return audio, fmt.Errorf("template audio %q must pick a cell from 1 to %d", spec, cells)
+		}
+		audio.cell = n - 1
+	default:
+		return audio, fmt.Errorf("unsupported template audio %q (supported: mix, first, mute, index=N)", spec)
+	}
+
+	if len(weights) > 0 && len(weights) != cells {
+		return audio, fmt.Errorf("got %d template audio weights for %d inputs", len(weights), cells)
+	}
+	for _, w := range weights {
+		if w < 0 {
+			return audio, fmt.Errorf("template audio weight %g can't be negative", w)
+		}
+	}
+	return audio, nil
+}
+
+// gridAudio returns the audio of the grid cells read from paths, picked or
+// mixed per the strategy. Each cell is resampled to 48kHz stereo first, so
+// cells with different sample rates or channel layouts mix without failing
+// or drifting out of sync. It returns nil when the picked cells have no audio.
+func gridAudio(inputs []*ffmpeg.Stream, paths []string, strategy templateAudio) *ffmpeg.Stream {
+	if strategy.mute {
+		return nil
+	}
+
+	var cells []*ffmpeg.Stream
+	var weights []string
+	for i, path := range paths {
+		if strategy.cell >= 0 && i != strategy.cell {
+			continue
+		}
+		if metadata, err := ffmpegWrap.GetVideoMetadata(path); err != nil || !metadata.HasAudio {
+			continue
+		}
+		cells = append(cells, normalizeAudio(inputs[i].Audio()))
+		if len(strategy.weights) > 0 {
+			weights = append(weights, strconv.FormatFloat(strategy.weights[i], 'g', -1, 64))
+		}
+	}
+
+	switch len(cells) {
+	case 0:
+		return nil
+	case 1:
+		return cells[0]
+	}
+	kwargs := ffmpeg.KwArgs{
+		"inputs":   len(cells),
+		"duration": "longest",
+	}
+	if len(weights) > 0 {
+		kwargs["weights"] = strings.Join(weights, " ")
+	}
+	return ffmpeg.Filter(cells, "amix", ffmpeg.Args{}, kwargs)
+}
+
+// normalizeAudio resamples audio to 48kHz stereo, stretching or squeezing it
+// to its timestamps so it starts at zero and stays in sync
+func normalizeAudio(audio *ffmpeg.Stream) *ffmpeg.Stream {
+	return audio.
+		Filter("aresample", ffmpeg.Args{"48000"}, ffmpeg.KwArgs{"async": 1, "first_pts": 0}).
+		Filter("aformat", ffmpeg.Args{}, ffmpeg.KwArgs{"sample_fmts": "fltp", "channel_layouts": "stereo"})
 }
 
 // In config.go, add outro text settings
@@ -371,13 +1207,233 @@ type VideoTemplateOptions struct {
 
 // In processor/template.go, add these new functions
 
+// AppendOutro generates the outro card and concatenates it after the video at
+// inputPath, writing the result to outputPath. With a transition set the video
+// crossfades into the card instead of cutting to it.
+func (t *Templater) AppendOutro(tempDir, inputPath, outputPath string) error {
+	outroPath, err := t.createOutroVideo(tempDir, inputPath)
+	if err != nil {
+		return err
+	}
+
+	if t.opts.Transition != "" {
+		metadata, err := ffmpegWrap.GetVideoMetadata(inputPath)
+		if err != nil {
+			return fmt.Errorf("failed to get main video metadata: %v", err)
+		}
+
+		t.progress.set("outro", 0, 0)
+		duration := cmp.Or(t.opts.TransitionDuration, config.DefaultTransitionDuration)
+		if err := t.ffmpeg.CrossfadeVideos(inputPath, outroPath, outputPath, t.opts.Transition, duration,
+			metadata.Width, metadata.Height, t.platform, t.opts.OutputFormat); err != nil {
+			return fmt.Errorf("failed to append outro: %v", err)
+		}
+		return nil
+	}
+
+	return t.joinCard(tempDir, "outro", inputPath, []string{inputPath, outroPath}, outputPath)
+}
+
+// joinCard concatenates paths, the video at mainPath and a generated card,
+// without re-encoding when their audio matches, and re-encodes both otherwise
+// since the concat demuxer can't join different audio
+func (t *Templater) joinCard(tempDir, stage, mainPath string, paths []string, outputPath string) error {
+	if sameAudio(paths[0], paths[1]) {
+		return t.concatVideos(tempDir, stage, paths, outputPath)
+	}
+
+	metadata, err := ffmpegWrap.GetVideoMetadata(mainPath)
+	if err != nil {
+		return fmt.Errorf("failed to get main video metadata: %v", err)
+	}
+
+	if t.opts.Verbose {
+		log.Printf("The %s audio doesn't match %s, re-encoding to join them\n", stage, mainPath)
+	}
+	t.progress.set(stage, 0, 0)
+	segments := []ffmpegWrap.Segment{{Path: paths[0]}, {Path: paths[1]}}
+	return t.ffmpeg.ConcatSegments(segments, outputPath, metadata.Width, metadata.Height, t.platform, t.opts.OutputFormat)
+}
+
+// sameAudio reports whether the videos at a and b both lack audio, or have
+// audio in the same codec, sample rate and channel count, so they can be
+// joined without re-encoding
+func sameAudio(a, b string) bool {
+	ma, err := ffmpegWrap.GetVideoMetadata(a)
+	if err != nil {
+		return false
+	}
+	mb, err := ffmpegWrap.GetVideoMetadata(b)
+	if err != nil {
+		return false
+	}
+	if !ma.HasAudio || !mb.HasAudio {
+		return ma.HasAudio == mb.HasAudio
+	}
+	return ma.AudioCodec == mb.AudioCodec &&
+		ma.AudioRate == mb.AudioRate &&
+		ma.AudioChannels == mb.AudioChannels
+}
+
+// PrependIntro generates the title card and concatenates it before the video
+// at inputPath, writing the result to outputPath
+func (t *Templater) PrependIntro(tempDir, inputPath, outputPath string) error {
+	introPath, err := t.createCardVideo(tempDir, "intro", inputPath, t.opts.IntroLines, t.introStyle())
+	if err != nil {
+		return err
+	}
+	return t.joinCard(tempDir, "intro", inputPath, []string{introPath, inputPath}, outputPath)
+}
+
+// concatVideos joins paths, which share their codecs, without re-encoding and
+// writes the result to outputPath
+func (t *Templater) concatVideos(tempDir, stage string, paths []string, outputPath string) error {
+	// Create list file for concatenation
+	listPath := filepath.Join(tempDir, "concat_"+stage+".txt")
+	var listContent strings.Builder
+	var totalDuration float64
+	for _, path := range paths {
+		fmt.Fprintf(&listContent, "file '%s'\n", path)
+		if metadata, err := ffmpegWrap.GetVideoMetadata(path); err == nil {
+			totalDuration += metadata.Duration
+		}
+	}
+	if err := os.WriteFile(listPath, []byte(listContent.String()), 0644); err != nil {
+		return fmt.Errorf("failed to create concat list: %v", err)
+	}
+
+	outputKwargs := ffmpeg.KwArgs{
+		"c":        "copy",
+		"movflags": "+faststart",
+	}
+	t.ffmpeg.MuxArgs(outputKwargs)
+
+	t.progress.set(stage, 0, 0)
+	err := t.ffmpeg.Run(ffmpeg.Input(
+		listPath,
+		ffmpeg.KwArgs{"f": "concat", "safe": "0"},
+	).Output(outputPath, outputKwargs), totalDuration)
+
+	if err != nil {
+		return fmt.Errorf("failed to concatenate %s: %v", stage, err)
+	}
+
+	return nil
+}
+
 // createOutroVideo generates a video with centered text lines
 func (t *Templater) createOutroVideo(tempDir, mainVideoPath string) (string, error) {
 	if len(t.opts.OutroLines) == 0 {
 		return "", nil
 	}
+	return t.createCardVideo(tempDir, "outro", mainVideoPath, t.opts.OutroLines, t.outroStyle())
+}
+
+// cardAudioFadeOut is how many seconds the music under a card fades out over,
+// at most half the card
+const cardAudioFadeOut = 2.0
+
+// cardStyle is how a generated intro or outro card looks
+type cardStyle struct {
+	duration   int     // Seconds
+	fontSize   int     // Zero scales the text with the card height
+	textColor  string  // ffmpeg color name or hex code
+	background string  // ffmpeg color name or hex code
+	fadeIn     float64 // Seconds the text and logo fade in over
+	fadeOut    float64 // Seconds the card fades out to the background at the end, zero for none
+	logo       string  // Image centered above the lines, if any
+	qr         string  // Encoded into a QR code shown on the card, if set
+	qrSize     int     // QR code side in pixels, zero for a third of the card's smaller side
+	qrPosition string  // One of qrPositions
+	audio      string  // Audio file played under the card, faded out at its end, if any
+}
+
+// introStyle returns the style of the title card
+func (t *Templater) introStyle() cardStyle {
+	return cardStyle{
+		duration:   cmp.Or(t.opts.IntroDuration, config.DefaultIntroDuration),
+		textColor:  config.OutroTextColor,
+		background: config.DefaultOutroBackground,
+		fadeIn:     config.DefaultOutroFadeIn,
+	}
+}
+
+// outroStyle returns the style of the outro card, filling in the defaults
+func (t *Templater) outroStyle() cardStyle {
+	return cardStyle{
+		duration:   cmp.Or(t.opts.OutroDuration, config.OutroDuration),
+		fontSize:   t.opts.OutroFontSize,
+		textColor:  cmp.Or(t.opts.OutroTextColor, config.OutroTextColor),
+		background: cmp.Or(t.opts.OutroBackground, config.DefaultOutroBackground),
+		fadeIn:     cmp.Or(t.opts.OutroFadeIn, config.DefaultOutroFadeIn),
+		fadeOut:    t.opts.OutroFadeOut,
+		logo:       t.opts.OutroLogo,
+		qr:         t.opts.OutroQR,
+		qrSize:     t.opts.OutroQRSize,
+		qrPosition: cmp.Or(t.opts.OutroQRPosition, defaultQRPosition),
+		audio:      t.opts.OutroAudio,
+	}
+}
+
+// checkOutroStyle rejects outro styles ffmpeg can't draw
+func checkOutroStyle(opts *config.VideoTemplateOptions) error {
+	for _, color := range []string{opts.OutroTextColor, opts.OutroBackground} {
+		if color != "" && !templateColorRe.MatchString(color) {
+			return fmt.Errorf("invalid outro color %q, expected a name or hex code, e.g. 'white' or '#ff3366'", color)
+		}
+	}
 
-	outroPath := filepath.Join(tempDir, "outro."+t.opts.OutputFormat)
+	duration := float64(cmp.Or(opts.OutroDuration, config.OutroDuration))
+	switch {
+	case opts.OutroDuration < 0:
+		return fmt.Errorf("outro duration %ds can't be negative", opts.OutroDuration)
+	case opts.OutroFontSize < 0:
+		return fmt.Errorf("outro font size %d can't be negative", opts.OutroFontSize)
+	case opts.OutroFadeIn < 0 || opts.OutroFadeOut < 0:
+		return fmt.Errorf("outro fades can't be negative")
+	case opts.OutroFadeIn+opts.OutroFadeOut > duration:
+		return fmt.Errorf("outro fades of %gs and %gs don't fit the %gs outro", opts.OutroFadeIn, opts.OutroFadeOut, duration)
+	}
+
+	if opts.OutroQRSize < 0 {
+		return fmt.Errorf("outro QR code size %d can't be negative", opts.OutroQRSize)
+	}
+	if err := checkQRPosition(opts.OutroQRPosition); err != nil {
+		return err
+	}
+
+	if opts.OutroLogo != "" {
+		if _, err := os.Stat(opts.OutroLogo); err != nil {
+			return fmt.Errorf("outro logo not found: %v", err)
+		}
+	}
+	if err := checkText(opts.TextOptions); err != nil {
+		return err
+	}
+	if opts.OutroAudio != "" {
+		if opts.NoAudio {
+			return fmt.Errorf("outro audio can't be combined with dropping the audio")
+		}
+		if _, err := os.Stat(opts.OutroAudio); err != nil {
+			return fmt.Errorf("outro audio not found: %v", err)
+		}
+	}
+	return nil
+}
+
+// fadeInImage fades a looped image in over fadeIn seconds, keeping what's
+// beneath it visible until then
+func fadeInImage(image *ffmpeg.Stream, fadeIn string) *ffmpeg.Stream {
+	return image.
+		Filter("format", ffmpeg.Args{"rgba"}).
+		Filter("fade", ffmpeg.Args{}, ffmpeg.KwArgs{"t": "in", "d": fadeIn, "alpha": 1})
+}
+
+// createCardVideo generates a card showing lines centered on the style's
+// background, encoded like the video at mainVideoPath so the two concatenate
+// without re-encoding
+func (t *Templater) createCardVideo(tempDir, name, mainVideoPath string, lines []string, style cardStyle) (string, error) {
+	cardPath := filepath.Join(tempDir, name+ffmpegWrap.FileExtension(t.opts.OutputFormat))
 
 	metadata, err := ffmpegWrap.GetVideoMetadata(mainVideoPath)
 	if err != nil {
@@ -394,81 +1450,175 @@ func (t *Templater) createOutroVideo(tempDir, mainVideoPath string) (string, err
 		}
 	}
 
-	// Create filter complex string for text overlays
-	var filterParts []string
-	lineSpacing := height / 15 // Dynamic spacing based on video height
-	totalHeight := len(t.opts.OutroLines) * lineSpacing
+	// Create a background video to draw the logo and text on
+	video := ffmpeg.Input(
+		fmt.Sprintf("color=c=%s:s=%dx%d:r=30", style.background, width, height),
+		ffmpeg.KwArgs{
+			"f": "lavfi",
+			"t": style.duration,
+		},
+	)
+
+	// Scale font size based on video height unless the style sets one
+	fontSize := style.fontSize
+	if fontSize == 0 {
+		fontSize = height / 20
+	}
+
+	// Lines break and wrap like every other text overlay
+	var wrapped []string
+	for _, line := range lines {
+		wrapped = append(wrapped, overlayLines(line, t.opts.Wrap)...)
+	}
+	lines = wrapped
+
+	spacing := lineSpacing(fontSize)
+	totalHeight := len(lines) * spacing
 	startY := fmt.Sprintf("(h-%d)/2", totalHeight)
+	fadeIn := fmt.Sprintf("%g", style.fadeIn)
+
+	// The logo sits above the lines, fading in with them
+	if style.logo != "" {
+		logoHeight := height / 4
+		logoY := max((height-totalHeight)/2-logoHeight-spacing/2, 0)
+		logo := ffmpeg.Input(style.logo, ffmpeg.KwArgs{"loop": 1, "t": style.duration}).
+			Filter("scale", ffmpeg.Args{fmt.Sprintf("-2:%d", logoHeight)})
+		video = ffmpeg.Filter([]*ffmpeg.Stream{video, fadeInImage(logo, fadeIn)}, "overlay", ffmpeg.Args{fmt.Sprintf("(W-w)/2:%d", logoY)})
+	}
 
-	// Start with black background input label
-	filterParts = append(filterParts, "[0:v]")
-
-	// Add each text overlay
-	for i, line := range t.opts.OutroLines {
-		yPos := fmt.Sprintf("%s+%d", startY, i*lineSpacing)
-
-		// Scale font size based on video height
-		fontSize := height / 20 // Dynamic font size
-
-		// Escape single quotes in the text
-		escapedText := strings.ReplaceAll(line, "'", "'\\''")
-
-		filter := fmt.Sprintf("drawtext=text='%s':"+
-			"fontsize=%d:"+ // Using calculated font size
-			"fontcolor=%s:"+
-			"x=(w-text_w)/2:"+
-			"y=%s:"+
-			"alpha='if(lt(t,%s),t/%s,1)':"+
-			"box=1:boxcolor=black@0.5:boxborderw=5",
-			escapedText,
-			fontSize,
-			OutroTextColor,
-			yPos,
-			OutroFadeIn,
-			OutroFadeIn,
-		)
-		filterParts = append(filterParts, filter)
+	if style.qr != "" {
+		size := style.qrSize
+		if size == 0 {
+			size = min(width, height) / 3
+		}
+		if size > min(width, height) {
+			return "", fmt.Errorf("QR code of %dpx doesn't fit the %dx%d %s", size, width, height, name)
+		}
 
-		if i < len(t.opts.OutroLines)-1 {
-			filterParts = append(filterParts, ",")
+		qrPath := filepath.Join(tempDir, name+"_qr.png")
+		if err := writeQRCode(style.qr, size, qrPath); err != nil {
+			return "", err
 		}
+		qr := ffmpeg.Input(qrPath, ffmpeg.KwArgs{"loop": 1, "t": style.duration})
+		video = ffmpeg.Filter([]*ffmpeg.Stream{video, fadeInImage(qr, fadeIn)}, "overlay",
+			ffmpeg.Args{qrOverlayPosition(style.qrPosition, safeMargins(safeArea(t.platform), height/20, "W", "H"))})
 	}
 
-	// Create a black video with the text overlays
-	stream := ffmpeg.Input(
-		fmt.Sprintf("color=c=black:s=%dx%d:r=30", width, height),
-		ffmpeg.KwArgs{
-			"f": "lavfi",
-			"t": OutroDuration,
-		},
-	)
+	// Without drawtext shaping, libass draws lines in scripts that need it
+	libass, err := shapeWithLibass(strings.Join(lines, "\n"))
+	if err != nil {
+		return "", err
+	}
+	if libass {
+		scriptPath, err := writeASS(tempDir, name+"_text", width, height, lines, assStyle{
+			fontSize:  fontSize,
+			color:     style.textColor,
+			alignment: assCenter,
+			fadeIn:    style.fadeIn,
+			box:       true,
+		}, t.opts.TextOptions)
+		if err != nil {
+			return "", err
+		}
+		video = drawASS(video, scriptPath, t.opts.TextOptions)
+	} else {
+		linePaths, err := writeOverlayLines(tempDir, name, lines)
+		if err != nil {
+			return "", err
+		}
 
-	// Apply the complete filter complex
-	filterComplex := strings.Join(filterParts, "")
+		// Add each text overlay
+		for i, textPath := range linePaths {
+			yPos := fmt.Sprintf("%s+%d", startY, i*spacing)
+
+			video = video.Filter("drawtext", ffmpeg.Args{fmt.Sprintf("%s:"+
+				"fontsize=%d:"+
+				"fontcolor=%s:"+
+				"x=(w-text_w)/2:"+
+				"y=%s:"+
+				"alpha='if(lt(t,%s),t/%s,1)':"+
+				"box=1:boxcolor=black@0.5:boxborderw=5%s",
+				ffmpegWrap.TextFileOption(textPath),
+				fontSize,
+				style.textColor,
+				yPos,
+				fadeIn,
+				fadeIn,
+				ffmpegWrap.FontOption(t.opts.TextOptions),
+			)})
+		}
+	}
+
+	if style.fadeOut > 0 {
+		video = video.Filter("fade", ffmpeg.Args{}, ffmpeg.KwArgs{
+			"t":     "out",
+			"st":    fmt.Sprintf("%g", float64(style.duration)-style.fadeOut),
+			"d":     fmt.Sprintf("%g", style.fadeOut),
+			"color": style.background,
+		})
+	}
 
 	// Get codec settings
 	codecSettings := ffmpegWrap.GetCodecSettings(t.opts.OutputFormat)
+	kwargs := ffmpeg.KwArgs{
+		"c:v":      codecSettings.VideoCodec,
+		"pix_fmt":  "yuv420p",
+		"threads":  ffmpegWrap.GetOptimalThreadCount(),
+		"movflags": "+faststart",
+		// Match video settings with platform requirements
+		"r":         "30",                         // Match framerate
+		"b:v":       t.platform.GetVideoBitrate(), // Match bitrate
+		"profile:v": "high",
+		"level":     "4.0",
+	}
+	outputs := []*ffmpeg.Stream{video}
+
+	// Music under the card, or silence when the video has audio, encoded like
+	// the video's audio where known so the two still concatenate without
+	// re-encoding
+	var audio *ffmpeg.Stream
+	switch {
+	case style.audio != "":
+		fadeOut := min(cardAudioFadeOut, float64(style.duration)/2)
+		audio = ffmpeg.Input(style.audio, ffmpeg.KwArgs{"t": style.duration}).Audio().
+			Filter("afade", ffmpeg.Args{}, ffmpeg.KwArgs{
+				"t":  "out",
+				"st": fmt.Sprintf("%g", float64(style.duration)-fadeOut),
+				"d":  fmt.Sprintf("%g", fadeOut),
+			}).
+			Filter("apad", ffmpeg.Args{}, ffmpeg.KwArgs{"whole_dur": style.duration})
+	case metadata.HasAudio && !t.opts.NoAudio:
+		audio = ffmpeg.Input(
+			fmt.Sprintf("anullsrc=r=%d:cl=stereo", cmp.Or(metadata.AudioRate, 48000)),
+			ffmpeg.KwArgs{
+				"f": "lavfi",
+				"t": style.duration,
+			},
+		).Audio()
+	}
+	if audio != nil {
+		outputs = append(outputs, audio)
 
-	// Generate the outro video
-	err = stream.Output(
-		outroPath,
-		ffmpeg.KwArgs{
-			"c:v":      codecSettings.VideoCodec,
-			"vf":       filterComplex,
-			"pix_fmt":  "yuv420p",
-			"threads":  ffmpegWrap.GetOptimalThreadCount(),
-			"movflags": "+faststart",
-			// Match video settings with platform requirements
-			"r":         "30",                         // Match framerate
-			"b:v":       t.platform.GetVideoBitrate(), // Match bitrate
-			"profile:v": "high",
-			"level":     "4.0",
-		},
-	).OverWriteOutput().ErrorToStdOut().Run()
+		codec, bitrate := outputAudioCodec(t.platform, t.opts.OutputFormat)
+		kwargs["c:a"] = codec
+		if bitrate != "" {
+			kwargs["b:a"] = bitrate
+		}
+		if metadata.AudioRate > 0 {
+			kwargs["ar"] = metadata.AudioRate
+		}
+		if metadata.AudioChannels > 0 {
+			kwargs["ac"] = metadata.AudioChannels
+		}
+	}
+
+	// Generate the card video
+	t.progress.set(name, 0, 0)
+	err = t.ffmpeg.Run(ffmpeg.Output(outputs, cardPath, t.ffmpeg.OutputArgs(kwargs, metadata)), float64(style.duration))
 
 	if err != nil {
-		return "", fmt.Errorf("failed to create outro video: %v", err)
+		return "", fmt.Errorf("failed to create %s video: %v", name, err)
 	}
 
-	return outroPath, nil
+	return cardPath, nil
 }
@@ -0,0 +1,66 @@
+package processor
+
+import "testing"
+
+func TestParseTemplateLayout(t *testing.T) {
+	tests := []struct {
+		templateType string
+		want         templateLayout
+		wantErr      bool
+	}{
+		{templateType: "1x1", want: templateLayout{columns: 1, rows: 1}},
+		{templateType: "2x2", want: templateLayout{columns: 2, rows: 2}},
+		{templateType: "3x1", want: templateLayout{columns: 3, rows: 1}},
+		{templateType: "1x2", want: templateLayout{columns: 1, rows: 2}},
+		{templateType: "4x4", want: templateLayout{columns: 4, rows: 4}},
+		{templateType: "16x1", want: templateLayout{columns: 16, rows: 1}},
+		{templateType: "2v", want: templateLayout{columns: 1, rows: 2, vertical: true}},
+		{templateType: "16v", want: templateLayout{columns: 1, rows: 16, vertical: true}},
+
+		// Too few or too many cells
+		{templateType: "0x2", wantErr: true},
+		{templateType: "2x0", wantErr: true},
+		{templateType: "5x4", wantErr: true},
+		{templateType: "17x1", wantErr: true},
+		{templateType: "1v", wantErr: true},
+		{templateType: "17v", wantErr: true},
+		{templateType: "0v", wantErr: true},
+
+		// Out of range numbers mustn't wrap around to a valid cell count
+		{templateType: "99999999999999999999x1", wantErr: true},
+		{templateType: "4294967296x4294967296", wantErr: true},
+		{templateType: "99999999999999999999v", wantErr: true},
+
+		// Malformed
+		{templateType: "", wantErr: true},
+		{templateType: "2", wantErr: true},
+		{templateType: "x2", wantErr: true},
+		{templateType: "2x", wantErr: true},
+		{templateType: "2X2", wantErr: true},
+		{templateType: " 2x2", wantErr: true},
+		{templateType: "2x2 ", wantErr: true},
+		{templateType: "-1x2", wantErr: true},
+		{templateType: "2x2x2", wantErr: true},
+		{templateType: "2V", wantErr: true},
+		{templateType: "v", wantErr: true},
+		{templateType: "greenscreen", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.templateType, func(t *testing.T) {
+			got, err := parseTemplateLayout(tt.templateType)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseTemplateLayout(%q) = %+v, want an error", tt.templateType, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTemplateLayout(%q) failed: %v", tt.templateType, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseTemplateLayout(%q) = %+v, want %+v", tt.templateType, got, tt.want)
+			}
+		})
+	}
+}
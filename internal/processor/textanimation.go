@@ -0,0 +1,156 @@
+package processor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	ffmpegWrap "github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Overlay text animations: fade fades the text in and out, scroll rolls it up
+// the frame like credits and typewriter reveals it a character at a time
+const (
+	animationNone       = "none"
+	animationFade       = "fade"
+	animationScroll     = "scroll"
+	animationTypewriter = "typewriter"
+)
+
+var overlayAnimations = []string{animationNone, animationFade, animationScroll, animationTypewriter}
+
+const (
+	overlayFade    = 0.5  // Seconds overlay text takes to fade in or out
+	scrollDuration = 10.0 // Seconds scrolling text takes to cross the frame without an end time
+	typewriterRate = 15.0 // Characters the typewriter reveals per second
+)
+
+// checkOverlayAnimation rejects unknown animations and show times that end
+// before they start
+func checkOverlayAnimation(text config.TextOptions) error {
+	if text.Animation != "" && !slices.Contains(overlayAnimations, text.Animation) {
+		return fmt.Errorf("unsupported overlay animation: %s (supported: %s)",
+			text.Animation, strings.Join(overlayAnimations, ", "))
+	}
+	if text.ShowAt < 0 {
+		return fmt.Errorf("overlay start %gs can't be negative", text.ShowAt)
+	}
+	if text.HideAt != 0 && text.HideAt <= text.ShowAt {
+		return fmt.Errorf("overlay end %gs must be after its start %gs", text.HideAt, text.ShowAt)
+	}
+	return nil
+}
+
+// animated reports whether overlay text moves or shows for only part of the
+// video
+func animated(text config.TextOptions) bool {
+	return text.Animation != "" && text.Animation != animationNone || text.ShowAt > 0 || text.HideAt > 0
+}
+
+// overlayLine is a line of overlay text written out for drawtext
+type overlayLine struct {
+	paths []string // File holding the line, or each of its prefixes in turn for the typewriter
+	typed int      // Characters the typewriter reveals before the line
+}
+
+// writeAnimatedLines writes lines to files under dir named after name for
+// drawing with drawOverlayLines
+func writeAnimatedLines(dir, name string, lines []string, text config.TextOptions) ([]overlayLine, error) {
+	res := make([]overlayLine, len(lines))
+	if text.Animation != animationTypewriter {
+		paths, err := writeOverlayLines(dir, name, lines)
+		if err != nil {
+			return nil, err
+		}
+		for i, path := range paths {
+			res[i] = overlayLine{paths: []string{path}}
+		}
+		return res, nil
+	}
+
+	typed := 0
+	for i, line := range lines {
+		runes := []rune(line)
+		prefixes := make([]string, len(runes))
+		for j := range runes {
+			prefixes[j] = string(runes[:j+1])
+		}
+		paths, err := writeOverlayLines(dir, fmt.Sprintf("%s_%d", name, i+1), prefixes)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = overlayLine{paths: paths, typed: typed}
+		typed += len(runes)
+	}
+	return res, nil
+}
+
+// drawOverlayLines draws lines on stream with drawtext at x and y, each line
+// spacing below the one before, animated per text. style holds the other
+// drawtext options, e.g. "fontsize=24:fontcolor=white". Scrolling text rolls
+// up the horizontal center of the frame, wherever it'd be placed otherwise.
+func drawOverlayLines(stream *ffmpeg.Stream, lines []overlayLine, style, x, y string, spacing int, text config.TextOptions) *ffmpeg.Stream {
+	start := text.ShowAt
+	window := ""
+	switch {
+	case text.HideAt > 0:
+		window = fmt.Sprintf("between(t,%g,%g)", start, text.HideAt)
+	case start > 0:
+		window = fmt.Sprintf("gte(t,%g)", start)
+	}
+
+	animation := ""
+	switch text.Animation {
+	case animationFade:
+		fade := fmt.Sprintf("(t-%g)/%g", start, overlayFade)
+		if text.HideAt > 0 {
+			fade = fmt.Sprintf("min(%s,(%g-t)/%g)", fade, text.HideAt, overlayFade)
+		}
+		animation = fmt.Sprintf(":alpha='clip(%s,0,1)'", fade)
+	case animationScroll:
+		// The block rises from below the frame to above it
+		duration := scrollDuration
+		if text.HideAt > 0 {
+			duration = text.HideAt - start
+		}
+		x = "(w-tw)/2"
+		y = fmt.Sprintf("h-(t-%g)*(h+%s)/%g", start, blockHeight(len(lines), spacing), duration)
+	}
+
+	for i, line := range lines {
+		for j, path := range line.paths {
+			enable := window
+			if text.Animation == animationTypewriter {
+				// Each prefix shows until the next one replaces it
+				typedAt := func(chars int) float64 {
+					return start + float64(chars)/typewriterRate
+				}
+				reveal := fmt.Sprintf("gte(t,%.3f)", typedAt(line.typed+j))
+				if j < len(line.paths)-1 {
+					reveal = fmt.Sprintf("gte(t,%.3f)*lt(t,%.3f)", typedAt(line.typed+j), typedAt(line.typed+j+1))
+				}
+				enable = reveal
+				if window != "" {
+					enable = fmt.Sprintf("%s*%s", reveal, window)
+				}
+			}
+			if enable != "" {
+				enable = fmt.Sprintf(":enable='%s'", enable)
+			}
+
+			stream = stream.Filter("drawtext", ffmpeg.Args{fmt.Sprintf("%s:%s:x=%s:y=%s+%d%s%s%s",
+				ffmpegWrap.TextFileOption(path),
+				style,
+				x,
+				y,
+				i*spacing,
+				animation,
+				enable,
+				ffmpegWrap.FontOption(text),
+			)})
+		}
+	}
+	return stream
+}
@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/ffmpeg"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+)
+
+// Transform rotates and mirrors opts.InputPath into opts.OutputPath,
+// re-encoding it, or only rewrites its display rotation when
+// opts.MetadataOnly is set. It stops ffmpeg and returns ctx's error once ctx
+// is done.
+func Transform(ctx context.Context, opts *config.TransformOptions) (*types.ProcessedOutput, error) {
+	if opts.Rotate%90 != 0 {
+		return nil, fmt.Errorf("rotation %d isn't a multiple of 90 degrees", opts.Rotate)
+	}
+	if opts.MetadataOnly && (opts.FlipHorizontal || opts.FlipVertical) {
+		return nil, fmt.Errorf("flipping needs re-encoding, players don't reliably honor mirroring metadata")
+	}
+	if !opts.MetadataOnly && ffmpeg.TransformFilter(opts.Rotate, opts.FlipHorizontal, opts.FlipVertical) == "" {
+		return nil, fmt.Errorf("nothing to do: set a rotation or a flip")
+	}
+
+	p := ffmpeg.NewProcessor(opts.Verbose)
+	p.SetContext(ctx)
+
+	var progress progressTracker
+	progress.attach(p, opts.OnProgress)
+	progress.set("transform", 0, 0)
+
+	var err error
+	if opts.MetadataOnly {
+		err = p.Reorient(opts.InputPath, opts.OutputPath, opts.Rotate)
+	} else {
+		err = p.Transform(opts.InputPath, opts.OutputPath, opts.Rotate, opts.FlipHorizontal, opts.FlipVertical)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := ffmpeg.GetVideoMetadata(opts.OutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting video metadata: %v", err)
+	}
+
+	return &types.ProcessedOutput{
+		FilePath:        opts.OutputPath,
+		DurationSeconds: uint64(metadata.Duration),
+	}, nil
+}
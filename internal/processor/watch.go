@@ -0,0 +1,140 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ZacxDev/video-splitter/config"
+)
+
+// fileState tracks a candidate file until its size stops changing
+type fileState struct {
+	size        int64
+	modTime     time.Time
+	stableSince time.Time
+}
+
+// Watcher polls a directory and splits new videos once they're fully copied
+type Watcher struct {
+	opts  *config.WatchOptions
+	files map[string]fileState
+}
+
+// NewWatcher creates a new watch-folder daemon
+func NewWatcher(opts *config.WatchOptions) *Watcher {
+	return &Watcher{
+		opts:  opts,
+		files: make(map[string]fileState),
+	}
+}
+
+// Run polls the watch directory until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) error {
+	for _, dir := range []string{w.opts.DoneDir, w.opts.FailedDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error creating directory %s: %v", dir, err)
+		}
+	}
+
+	log.Printf("Watching %s for new videos\n", w.opts.WatchDir)
+
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.poll(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll scans the watch directory once and processes every stable file
+func (w *Watcher) poll(ctx context.Context) error {
+	entries, err := os.ReadDir(w.opts.WatchDir)
+	if err != nil {
+		return fmt.Errorf("failed to read watch directory: %v", err)
+	}
+
+	now := time.Now()
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !IsVideoFile(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(w.opts.WatchDir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		present[path] = true
+
+		// Reset the stability clock whenever the file is still being written
+		state, ok := w.files[path]
+		if !ok || state.size != info.Size() || !state.modTime.Equal(info.ModTime()) {
+			w.files[path] = fileState{
+				size:        info.Size(),
+				modTime:     info.ModTime(),
+				stableSince: now,
+			}
+			continue
+		}
+
+		if now.Sub(state.stableSince) < w.opts.StableFor {
+			continue
+		}
+
+		w.process(ctx, path)
+		delete(w.files, path)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+
+	// Forget files that disappeared before becoming stable
+	for path := range w.files {
+		if !present[path] {
+			delete(w.files, path)
+		}
+	}
+
+	return nil
+}
+
+// process splits a single file and moves it to the done or failed directory.
+// A file interrupted by ctx ending is left in place to be picked up again.
+func (w *Watcher) process(ctx context.Context, path string) {
+	log.Printf("Processing %s\n", path)
+
+	opts := w.opts.Split
+	opts.InputPath = path
+
+	destDir := w.opts.DoneDir
+	clips, err := NewSplitter(&opts).ProcessContext(ctx)
+	if err != nil && ctx.Err() != nil {
+		log.Printf("Stopped processing %s: %v\n", path, err)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to process %s: %v\n", path, err)
+		destDir = w.opts.FailedDir
+	} else {
+		log.Printf("Processed %s into %d chunks\n", path, len(clips))
+	}
+
+	dest := filepath.Join(destDir, filepath.Base(path))
+	if err := moveFile(path, dest); err != nil {
+		log.Printf("Warning: failed to move %s to %s: %v\n", path, destDir, err)
+	}
+}
@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Watermark motions: static stays in the bottom right corner, or at the text
+// position for corner text, corners hops between the corners and drift
+// wanders across the frame, making the watermark harder to crop out
+const (
+	watermarkStatic  = "static"
+	watermarkCorners = "corners"
+	watermarkDrift   = "drift"
+)
+
+var watermarkMotions = []string{watermarkStatic, watermarkCorners, watermarkDrift}
+
+// watermarkMargin is the distance in pixels between the watermark and the
+// edges it's placed against, on top of the platform's safe area
+const watermarkMargin = 20
+
+// checkWatermark rejects unknown motions, negative intervals and missing
+// watermark images
+func checkWatermark(opts *config.VideoTemplateOptions) error {
+	if opts.WatermarkMotion != "" && !slices.Contains(watermarkMotions, opts.WatermarkMotion) {
+		return fmt.Errorf("unsupported watermark motion: %s (supported: %s)",
+			opts.WatermarkMotion, strings.Join(watermarkMotions, ", "))
+	}
+	if opts.WatermarkMotion != "" && opts.WatermarkMotion != watermarkStatic && opts.Position != "" {
+		return fmt.Errorf("text position %s can't be combined with the %s watermark motion", opts.Position, opts.WatermarkMotion)
+	}
+	if opts.WatermarkInterval < 0 {
+		return fmt.Errorf("watermark interval %gs can't be negative", opts.WatermarkInterval)
+	}
+	if opts.WatermarkImage != "" {
+		if _, err := os.Stat(opts.WatermarkImage); err != nil {
+			return fmt.Errorf("watermark image not found: %v", err)
+		}
+	}
+	return nil
+}
+
+// watermarkPosition returns the x and y expressions placing an item of
+// itemW x itemH on a frameW x frameH frame per motion, within area, where the
+// arguments are the size variables of the filter evaluating them, e.g. "w",
+// "h", "tw" and "th" for drawtext. Corners starts in the bottom right corner
+// and moves counterclockwise every interval seconds, drift crosses the frame
+// horizontally every interval seconds and vertically somewhat slower so the
+// path doesn't repeat quickly.
+func watermarkPosition(motion string, interval float64, area platform.SafeArea, frameW, frameH, itemW, itemH string) (x, y string) {
+	m := safeMargins(area, watermarkMargin, frameW, frameH)
+	right := fmt.Sprintf("%s-%s-%s", frameW, itemW, m.right)
+	bottom := fmt.Sprintf("%s-%s-%s", frameH, itemH, m.bottom)
+
+	switch motion {
+	case watermarkCorners:
+		corner := fmt.Sprintf("mod(floor(t/%g),4)", interval)
+		return fmt.Sprintf("if(between(%s,1,2),%s,%s)", corner, m.left, right),
+			fmt.Sprintf("if(lt(%s,2),%s,%s)", corner, bottom, m.top)
+	case watermarkDrift:
+		drift := func(start, end string, period float64) string {
+			return fmt.Sprintf("%s+(%s-%s)*(1-cos(PI*t/%g))/2", start, end, start, period)
+		}
+		return drift(m.left, right, interval), drift(m.top, bottom, interval*1.5)
+	default:
+		return right, bottom
+	}
+}
+
+// addWatermarkImage overlays the watermark image on input, a tenth of the
+// canvas height tall and moving per the watermark motion
+func (t *Templater) addWatermarkImage(input *ffmpeg.Stream, canvas config.VideoDimensions) *ffmpeg.Stream {
+	x, y := watermarkPosition(t.opts.WatermarkMotion, t.watermarkInterval(), safeArea(t.platform), "W", "H", "w", "h")
+	watermark := ffmpeg.Input(t.opts.WatermarkImage, ffmpeg.KwArgs{"loop": 1}).
+		Filter("scale", ffmpeg.Args{fmt.Sprintf("-2:%d", max(canvas.Height/10&^1, 2))})
+	return ffmpeg.Filter([]*ffmpeg.Stream{input, watermark}, "overlay", ffmpeg.Args{}, ffmpeg.KwArgs{
+		"x":        x,
+		"y":        y,
+		"shortest": 1,
+	})
+}
+
+// watermarkInterval returns the seconds between watermark moves
+func (t *Templater) watermarkInterval() float64 {
+	return cmp.Or(t.opts.WatermarkInterval, config.DefaultWatermarkInterval)
+}
@@ -0,0 +1,43 @@
+package remote
+
+import (
+	"context"
+	"io"
+
+	"cloud.google.com/go/storage"
+)
+
+// Credentials come from Application Default Credentials
+// (GOOGLE_APPLICATION_CREDENTIALS, gcloud auth or the metadata server)
+
+func downloadGCS(ctx context.Context, bucket, key string, w io.Writer) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	r, err := client.Bucket(bucket).Object(key).NewReader(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func uploadGCS(ctx context.Context, bucket, key string, r io.Reader) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	w := client.Bucket(bucket).Object(key).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
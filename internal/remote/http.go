@@ -0,0 +1,219 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	downloadAttempts  = 5
+	partialFileSuffix = ".part"
+	validatorSuffix   = ".validator" // Holds the ETag or Last-Modified of the partial file's body
+)
+
+// statusError is an HTTP error status retrying won't change, such as a 404
+type statusError struct {
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "unexpected status: " + e.status
+}
+
+// IsURL reports whether p is an http:// or https:// URL
+func IsURL(p string) bool {
+	return strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://")
+}
+
+// DownloadURL fetches rawURL into dir and returns the local file path. The
+// file name is derived from the URL so an already downloaded file in dir is
+// reused, and an interrupted download is resumed from its partial file as long
+// as the server confirms the body hasn't changed since.
+func DownloadURL(ctx context.Context, rawURL, dir string) (string, error) {
+	localPath, err := downloadPath(rawURL, dir)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(localPath); err == nil {
+		return localPath, nil
+	}
+
+	partPath := localPath + partialFileSuffix
+	for attempt := 1; ; attempt++ {
+		err = downloadRange(ctx, rawURL, partPath)
+		if err == nil {
+			break
+		}
+		if _, ok := err.(*statusError); ok || ctx.Err() != nil || attempt == downloadAttempts {
+			return "", fmt.Errorf("failed to download %s: %v", rawURL, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("failed to download %s: %v", rawURL, ctx.Err())
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+
+	if err := os.Rename(partPath, localPath); err != nil {
+		return "", errors.WithStack(err)
+	}
+	os.Remove(partPath + validatorSuffix)
+
+	return localPath, nil
+}
+
+// downloadPath returns the path in dir rawURL is downloaded to
+func downloadPath(rawURL, dir string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %v", rawURL, err)
+	}
+
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = "download"
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(rawURL)))
+	return filepath.Join(dir, hash[:16]+"_"+name), nil
+}
+
+// downloadRange appends the rest of rawURL to partPath, returning nil only once
+// partPath holds the whole body. The partial file is resumed with an If-Range
+// request, so the server sends the whole body again when it changed, and
+// started over when there is no validator to send.
+func downloadRange(ctx context.Context, rawURL, partPath string) error {
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	validatorPath := partPath + validatorSuffix
+	if offset > 0 {
+		validator, _ := os.ReadFile(validatorPath)
+		if len(validator) > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", string(validator))
+		} else if offset, err = restart(f, validatorPath); err != nil {
+			return err
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	total := int64(-1)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			restart(f, validatorPath)
+			return fmt.Errorf("server sent range %q for bytes=%d-", resp.Header.Get("Content-Range"), offset)
+		}
+		total = size
+	case resp.StatusCode == http.StatusOK:
+		if offset, err = restart(f, validatorPath); err != nil {
+			return err
+		}
+		if validator := resumeValidator(resp.Header); validator != "" {
+			if err := os.WriteFile(validatorPath, []byte(validator), 0644); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		total = resp.ContentLength
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// Only the whole body being there already makes the range empty
+		_, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if ok && size == offset {
+			return nil
+		}
+		restart(f, validatorPath)
+		return fmt.Errorf("partial file of %d bytes doesn't fit the body, content range %q", offset, resp.Header.Get("Content-Range"))
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		return &statusError{status: resp.Status}
+	default:
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	n, err := io.Copy(f, resp.Body)
+	if err != nil {
+		return err
+	}
+	if total >= 0 && offset+n != total {
+		return fmt.Errorf("got %d of %d bytes", offset+n, total)
+	}
+	return nil
+}
+
+// restart empties the partial file and forgets its validator
+func restart(f *os.File, validatorPath string) (int64, error) {
+	os.Remove(validatorPath)
+	if err := f.Truncate(0); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return f.Seek(0, io.SeekStart)
+}
+
+// resumeValidator returns the If-Range value identifying the body of a
+// response: its ETag when strong, as If-Range doesn't take weak ones, or its
+// Last-Modified date
+func resumeValidator(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
+
+// parseContentRange returns the first byte and the total size a Content-Range
+// header gives, each -1 when it's "*" as in "bytes */1000"
+func parseContentRange(h string) (int64, int64, bool) {
+	rest, ok := strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return 0, 0, false
+	}
+	rng, size, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, 0, false
+	}
+
+	start, total := int64(-1), int64(-1)
+	var err error
+	if rng != "*" {
+		first, _, _ := strings.Cut(rng, "-")
+		if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
@@ -0,0 +1,114 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header string
+		start  int64
+		total  int64
+		ok     bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-0/*", 0, -1, true},
+		{"bytes */1000", -1, 1000, true},
+		{"bytes 1-2", 0, 0, false},
+		{"items 0-1/2", 0, 0, false},
+		{"bytes x-1/2", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.header)
+		if start != tt.start || total != tt.total || ok != tt.ok {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v, want %d, %d, %v",
+				tt.header, start, total, ok, tt.start, tt.total, tt.ok)
+		}
+	}
+}
+
+func TestDownloadURL(t *testing.T) {
+	body := []byte(strings.Repeat("0123456789", 100))
+
+	tests := []struct {
+		name      string
+		part      []byte // Partial file left by an earlier download
+		validator string // Its validator
+		etag      string // ETag the server sends
+		status    int    // Status the server sends instead of the body
+		wantErr   bool
+		wantReqs  int32
+	}{
+		{name: "fresh", etag: `"v1"`, wantReqs: 1},
+		{name: "resume", part: body[:300], validator: `"v1"`, etag: `"v1"`, wantReqs: 1},
+		{name: "changed", part: []byte(strings.Repeat("x", 300)), validator: `"v0"`, etag: `"v1"`, wantReqs: 1},
+		{name: "no validator", part: []byte(strings.Repeat("x", 300)), etag: `"v1"`, wantReqs: 1},
+		{name: "complete", part: body, validator: `"v1"`, etag: `"v1"`, wantReqs: 1},
+		{name: "oversized", part: append(append([]byte{}, body...), 'x'), validator: `"v1"`, etag: `"v1"`, wantReqs: 2},
+		{name: "not found", status: http.StatusNotFound, wantErr: true, wantReqs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reqs int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&reqs, 1)
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set("ETag", tt.etag)
+				http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(body))
+			}))
+			defer srv.Close()
+
+			rawURL := srv.URL + "/video.mp4"
+			dir := t.TempDir()
+			localPath, err := downloadPath(rawURL, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.part != nil {
+				os.WriteFile(localPath+partialFileSuffix, tt.part, 0644)
+			}
+			if tt.validator != "" {
+				os.WriteFile(localPath+partialFileSuffix+validatorSuffix, []byte(tt.validator), 0644)
+			}
+
+			got, err := DownloadURL(context.Background(), rawURL, dir)
+			if n := atomic.LoadInt32(&reqs); n != tt.wantReqs {
+				t.Errorf("made %d requests, want %d", n, tt.wantReqs)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("DownloadURL succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, body) {
+				t.Errorf("downloaded %d bytes not matching the %d byte body", len(data), len(body))
+			}
+			if _, err := os.Stat(got + partialFileSuffix + validatorSuffix); !os.IsNotExist(err) {
+				t.Errorf("validator file left behind")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IsRemote reports whether p is an object storage URI (s3:// or gs://)
+func IsRemote(p string) bool {
+	return strings.HasPrefix(p, "s3://") || strings.HasPrefix(p, "gs://")
+}
+
+// Join appends a file name to a remote directory URI
+func Join(dirURI, name string) string {
+	return strings.TrimSuffix(dirURI, "/") + "/" + name
+}
+
+// Download fetches the object at uri into dir and returns the local file path
+func Download(ctx context.Context, uri, dir string) (string, error) {
+	scheme, bucket, key, err := parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	localPath := filepath.Join(dir, path.Base(key))
+	f, err := os.Create(localPath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+
+	switch scheme {
+	case "s3":
+		err = downloadS3(ctx, bucket, key, f)
+	case "gs":
+		err = downloadGCS(ctx, bucket, key, f)
+	}
+	if err != nil {
+		os.Remove(localPath)
+		return "", fmt.Errorf("failed to download %s: %v", uri, err)
+	}
+
+	return localPath, nil
+}
+
+// Upload copies the local file to the object at uri
+func Upload(ctx context.Context, localPath, uri string) error {
+	scheme, bucket, key, err := parse(uri)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	switch scheme {
+	case "s3":
+		err = uploadS3(ctx, bucket, key, f)
+	case "gs":
+		err = uploadGCS(ctx, bucket, key, f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %v", uri, err)
+	}
+
+	return nil
+}
+
+func parse(uri string) (scheme, bucket, key string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid remote URI %q: %v", uri, err)
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return "", "", "", fmt.Errorf("unsupported remote URI scheme: %s", u.Scheme)
+	}
+
+	key = strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return "", "", "", fmt.Errorf("remote URI %q must include a bucket and object key", uri)
+	}
+
+	return u.Scheme, u.Host, key, nil
+}
@@ -0,0 +1,46 @@
+package remote
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// newS3Session resolves credentials and region from the standard AWS
+// environment variables, shared config files and instance roles
+func newS3Session() (*session.Session, error) {
+	return session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+}
+
+func downloadS3(ctx context.Context, bucket, key string, w io.WriterAt) error {
+	sess, err := newS3Session()
+	if err != nil {
+		return err
+	}
+
+	_, err = s3manager.NewDownloader(sess).DownloadWithContext(ctx, w, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func uploadS3(ctx context.Context, bucket, key string, r io.Reader) error {
+	sess, err := newS3Session()
+	if err != nil {
+		return err
+	}
+
+	_, err = s3manager.NewUploader(sess).UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	})
+	return err
+}
@@ -0,0 +1,135 @@
+package server
+
+//go:generate protoc -I ../../proto --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative videoprocessor.proto
+
+import (
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/jobs"
+	"github.com/ZacxDev/video-splitter/internal/server/pb"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer exposes the job manager as the VideoProcessor gRPC service
+type GRPCServer struct {
+	pb.UnimplementedVideoProcessorServer
+	jobs        *jobs.Manager
+	allowRemote bool // Accept http(s) URL and s3:// / gs:// inputs
+}
+
+// NewGRPC creates a new gRPC server for the job manager. Inputs must be files
+// on the server unless allowRemote is set.
+func NewGRPC(manager *jobs.Manager, allowRemote bool) *grpc.Server {
+	srv := grpc.NewServer()
+	pb.RegisterVideoProcessorServer(srv, &GRPCServer{jobs: manager, allowRemote: allowRemote})
+	return srv
+}
+
+// SplitVideo submits a split job and streams its updates
+func (s *GRPCServer) SplitVideo(req *pb.SplitRequest, stream grpc.ServerStreamingServer[pb.JobUpdate]) error {
+	if err := validateSplitRequest(req); err != nil {
+		return err
+	}
+	if err := checkInputs([]string{req.GetInputPath()}, s.allowRemote); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	job, err := s.jobs.SubmitSplit(config.VideoSplitterOptions{
+		InputPath:      req.GetInputPath(),
+		ChunkDuration:  int(req.GetChunkDuration()),
+		Skip:           req.GetSkip(),
+		TargetPlatform: types.ProcessingPlatform(req.GetTargetPlatform()),
+		OutputFormat:   req.GetOutputFormat(),
+		Verbose:        req.GetVerbose(),
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return s.streamJob(job.ID, stream)
+}
+
+// validateSplitRequest checks the fields proto3 leaves at zero when omitted
+func validateSplitRequest(req *pb.SplitRequest) error {
+	switch {
+	case req.GetInputPath() == "":
+		return status.Error(codes.InvalidArgument, "input_path is required")
+	case req.GetChunkDuration() <= 0:
+		return status.Error(codes.InvalidArgument, "chunk_duration must be positive")
+	}
+	return nil
+}
+
+// ApplyTemplate submits a template job and streams its updates
+func (s *GRPCServer) ApplyTemplate(req *pb.TemplateRequest, stream grpc.ServerStreamingServer[pb.JobUpdate]) error {
+	if err := checkInputs(req.GetInputPaths(), s.allowRemote); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	portraitText := req.GetPortraitBottomRightText()
+	if portraitText == "" {
+		portraitText = req.GetLandscapeBottomRightText()
+	}
+
+	job, err := s.jobs.SubmitTemplate(config.VideoTemplateOptions{
+		InputPaths:               req.GetInputPaths(),
+		TemplateType:             req.GetTemplateType(),
+		OutputFormat:             req.GetOutputFormat(),
+		Verbose:                  req.GetVerbose(),
+		Obscurify:                req.GetObscurify(),
+		LandscapeBottomRightText: req.GetLandscapeBottomRightText(),
+		PortraitBottomRightText:  portraitText,
+		TargetPlatform:           types.ProcessingPlatform(req.GetTargetPlatform()),
+		OutroLines:               req.GetOutroLines(),
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return s.streamJob(job.ID, stream)
+}
+
+// WatchJob streams the updates of a previously submitted job
+func (s *GRPCServer) WatchJob(req *pb.WatchJobRequest, stream grpc.ServerStreamingServer[pb.JobUpdate]) error {
+	if _, ok := s.jobs.Get(req.GetId()); !ok {
+		return status.Error(codes.NotFound, "job not found")
+	}
+
+	return s.streamJob(req.GetId(), stream)
+}
+
+// streamJob sends every update of a job until it finishes or the client goes away
+func (s *GRPCServer) streamJob(id string, stream grpc.ServerStreamingServer[pb.JobUpdate]) error {
+	updates, cancel, err := s.jobs.Subscribe(id)
+	if err != nil {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	defer cancel()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case job, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toJobUpdate(job)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toJobUpdate(job jobs.Job) *pb.JobUpdate {
+	return &pb.JobUpdate{
+		Id:       job.ID,
+		Kind:     string(job.Kind),
+		Status:   string(job.Status),
+		Error:    job.Error,
+		Outputs:  job.Outputs,
+		Progress: job.Progress,
+	}
+}
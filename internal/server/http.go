@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/ZacxDev/video-splitter/internal/jobs"
+)
+
+// Server exposes the job manager over a REST API
+type Server struct {
+	jobs        *jobs.Manager
+	allowRemote bool // Accept http(s) URL and s3:// / gs:// inputs
+}
+
+// New creates a new HTTP API server. Inputs must be files on the server
+// unless allowRemote is set.
+func New(manager *jobs.Manager, allowRemote bool) *Server {
+	return &Server{
+		jobs:        manager,
+		allowRemote: allowRemote,
+	}
+}
+
+// Handler returns the HTTP handler with every API route registered
+//
+//	POST /jobs/split                 submit a split job (body: splitRequest)
+//	POST /jobs/template              submit a template job (body: templateRequest)
+//	GET  /jobs                       list jobs
+//	GET  /jobs/{id}                  get job status
+//	GET  /jobs/{id}/files/{name}     download an output file
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs/split", s.submitSplit)
+	mux.HandleFunc("POST /jobs/template", s.submitTemplate)
+	mux.HandleFunc("GET /jobs", s.listJobs)
+	mux.HandleFunc("GET /jobs/{id}", s.getJob)
+	mux.HandleFunc("GET /jobs/{id}/files/{name}", s.downloadFile)
+	return mux
+}
+
+func (s *Server) submitSplit(w http.ResponseWriter, r *http.Request) {
+	var req splitRequest
+	if err := decodeRequest(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := checkInputs([]string{req.InputPath}, s.allowRemote); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Clients never choose where outputs are written
+	job, err := s.jobs.SubmitSplit(req.options())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newJobResponse(*job))
+}
+
+func (s *Server) submitTemplate(w http.ResponseWriter, r *http.Request) {
+	var req templateRequest
+	if err := decodeRequest(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := checkInputs(req.InputPaths, s.allowRemote); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Clients never choose where outputs are written
+	job, err := s.jobs.SubmitTemplate(req.options())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newJobResponse(*job))
+}
+
+func (s *Server) listJobs(w http.ResponseWriter, r *http.Request) {
+	list := s.jobs.List()
+	res := make([]jobResponse, 0, len(list))
+	for _, job := range list {
+		res = append(res, newJobResponse(job))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newJobResponse(job))
+}
+
+func (s *Server) downloadFile(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	// Only serve files the job actually produced
+	name := r.PathValue("name")
+	for _, output := range job.Outputs {
+		if filepath.Base(output) == name {
+			w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+			http.ServeFile(w, r, output)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "file not found")
+}
+
+// jobResponse is what clients see of a job: its state and the names of its
+// output files, downloaded from /jobs/{id}/files/{name}. Its options stay on
+// the server, they hold the server's paths and, for jobs batch shares the
+// store with, commands.
+type jobResponse struct {
+	ID        string      `json:"id"`
+	Kind      jobs.Kind   `json:"kind"`
+	Status    jobs.Status `json:"status"`
+	Error     string      `json:"error,omitempty"`
+	Outputs   []string    `json:"outputs,omitempty"`
+	Progress  string      `json:"progress,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+func newJobResponse(job jobs.Job) jobResponse {
+	outputs := make([]string, 0, len(job.Outputs))
+	for _, output := range job.Outputs {
+		outputs = append(outputs, filepath.Base(output))
+	}
+	return jobResponse{
+		ID:        job.ID,
+		Kind:      job.Kind,
+		Status:    job.Status,
+		Error:     job.Error,
+		Outputs:   outputs,
+		Progress:  job.Progress,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Warning: failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,206 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: videoprocessor.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	VideoProcessor_SplitVideo_FullMethodName    = "/videoprocessor.v1.VideoProcessor/SplitVideo"
+	VideoProcessor_ApplyTemplate_FullMethodName = "/videoprocessor.v1.VideoProcessor/ApplyTemplate"
+	VideoProcessor_WatchJob_FullMethodName      = "/videoprocessor.v1.VideoProcessor/WatchJob"
+)
+
+// VideoProcessorClient is the client API for VideoProcessor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VideoProcessorClient interface {
+	SplitVideo(ctx context.Context, in *SplitRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobUpdate], error)
+	ApplyTemplate(ctx context.Context, in *TemplateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobUpdate], error)
+	WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobUpdate], error)
+}
+
+type videoProcessorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVideoProcessorClient(cc grpc.ClientConnInterface) VideoProcessorClient {
+	return &videoProcessorClient{cc}
+}
+
+func (c *videoProcessorClient) SplitVideo(ctx context.Context, in *SplitRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoProcessor_ServiceDesc.Streams[0], VideoProcessor_SplitVideo_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SplitRequest, JobUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoProcessor_SplitVideoClient = grpc.ServerStreamingClient[JobUpdate]
+
+func (c *videoProcessorClient) ApplyTemplate(ctx context.Context, in *TemplateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoProcessor_ServiceDesc.Streams[1], VideoProcessor_ApplyTemplate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TemplateRequest, JobUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoProcessor_ApplyTemplateClient = grpc.ServerStreamingClient[JobUpdate]
+
+func (c *videoProcessorClient) WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoProcessor_ServiceDesc.Streams[2], VideoProcessor_WatchJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchJobRequest, JobUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoProcessor_WatchJobClient = grpc.ServerStreamingClient[JobUpdate]
+
+// VideoProcessorServer is the server API for VideoProcessor service.
+// All implementations must embed UnimplementedVideoProcessorServer
+// for forward compatibility.
+type VideoProcessorServer interface {
+	SplitVideo(*SplitRequest, grpc.ServerStreamingServer[JobUpdate]) error
+	ApplyTemplate(*TemplateRequest, grpc.ServerStreamingServer[JobUpdate]) error
+	WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[JobUpdate]) error
+	mustEmbedUnimplementedVideoProcessorServer()
+}
+
+// UnimplementedVideoProcessorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedVideoProcessorServer struct{}
+
+func (UnimplementedVideoProcessorServer) SplitVideo(*SplitRequest, grpc.ServerStreamingServer[JobUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method SplitVideo not implemented")
+}
+func (UnimplementedVideoProcessorServer) ApplyTemplate(*TemplateRequest, grpc.ServerStreamingServer[JobUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method ApplyTemplate not implemented")
+}
+func (UnimplementedVideoProcessorServer) WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[JobUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchJob not implemented")
+}
+func (UnimplementedVideoProcessorServer) mustEmbedUnimplementedVideoProcessorServer() {}
+func (UnimplementedVideoProcessorServer) testEmbeddedByValue()                        {}
+
+// UnsafeVideoProcessorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VideoProcessorServer will
+// result in compilation errors.
+type UnsafeVideoProcessorServer interface {
+	mustEmbedUnimplementedVideoProcessorServer()
+}
+
+func RegisterVideoProcessorServer(s grpc.ServiceRegistrar, srv VideoProcessorServer) {
+	// If the following call pancis, it indicates UnimplementedVideoProcessorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&VideoProcessor_ServiceDesc, srv)
+}
+
+func _VideoProcessor_SplitVideo_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SplitRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoProcessorServer).SplitVideo(m, &grpc.GenericServerStream[SplitRequest, JobUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoProcessor_SplitVideoServer = grpc.ServerStreamingServer[JobUpdate]
+
+func _VideoProcessor_ApplyTemplate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TemplateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoProcessorServer).ApplyTemplate(m, &grpc.GenericServerStream[TemplateRequest, JobUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoProcessor_ApplyTemplateServer = grpc.ServerStreamingServer[JobUpdate]
+
+func _VideoProcessor_WatchJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchJobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoProcessorServer).WatchJob(m, &grpc.GenericServerStream[WatchJobRequest, JobUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoProcessor_WatchJobServer = grpc.ServerStreamingServer[JobUpdate]
+
+// VideoProcessor_ServiceDesc is the grpc.ServiceDesc for VideoProcessor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VideoProcessor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "videoprocessor.v1.VideoProcessor",
+	HandlerType: (*VideoProcessorServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SplitVideo",
+			Handler:       _VideoProcessor_SplitVideo_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ApplyTemplate",
+			Handler:       _VideoProcessor_ApplyTemplate_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchJob",
+			Handler:       _VideoProcessor_WatchJob_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "videoprocessor.proto",
+}
@@ -0,0 +1,371 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/jobs"
+	"github.com/ZacxDev/video-splitter/internal/remote"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// The request bodies list the options API clients may set. Commands, and
+// files on the server other than the inputs, such as effect chains, fonts and
+// caches, are left to whoever runs it, so a client can't run a program or
+// read and write files through them. Field names match the options'.
+
+// splitRequest is the body of POST /jobs/split
+type splitRequest struct {
+	InputPath       string
+	ChunkDuration   int
+	Skip            string
+	TargetPlatform  types.ProcessingPlatform
+	OutputFormat    string
+	Verbose         bool
+	RecapSeconds    int
+	RecapText       string
+	StreamCopy      bool
+	PreserveHDR     bool
+	TonemapOperator string
+	TwoPass         bool
+	Quality         int
+	EncodePreset    string
+	QualityReport   bool
+	Fragmented      bool
+	AnimationFPS    int
+	AnimationWidth  int
+	NoAudio         bool
+	BurnTimecode    bool
+	AudioCopy       bool
+	AudioCodec      string
+	AudioBitrate    string
+	Renditions      []config.Rendition
+	AllowCopy       bool
+	AudioTracks     []int
+	Sharpen         string
+	FrameRate       int
+	SmoothFrameRate bool
+	InputFPS        float64
+	audioRequest
+	textRequest
+	effectsRequest
+}
+
+// templateRequest is the body of POST /jobs/template
+type templateRequest struct {
+	InputPaths               []string
+	TemplateType             string
+	Layout                   *config.Layout
+	TemplateGap              int
+	TemplateBorder           int
+	TemplateBorderColor      string
+	TemplateBackground       string
+	TemplateBackgroundBlur   bool
+	KeyColor                 string
+	KeySimilarity            float64
+	KeyBlend                 float64
+	ImageDuration            float64
+	NoKenBurns               bool
+	TemplateAudio            string
+	TemplateAudioWeights     []float64
+	DurationStrategy         string
+	FrameRate                int
+	SmoothFrameRate          bool
+	Reframe                  string
+	OutputFormat             string
+	Verbose                  bool
+	Obscurify                bool
+	LandscapeBottomRightText string
+	PortraitBottomRightText  string
+	InputTexts               []string
+	TextColor                string
+	TextPalette              []string
+	Seed                     uint64
+	WatermarkMotion          string
+	WatermarkInterval        float64
+	TargetPlatform           types.ProcessingPlatform
+	IntroLines               []string
+	IntroDuration            int
+	OutroLines               []string
+	OutroDuration            int
+	OutroFontSize            int
+	OutroTextColor           string
+	OutroBackground          string
+	OutroFadeIn              float64
+	OutroFadeOut             float64
+	OutroQR                  string
+	OutroQRSize              int
+	OutroQRPosition          string
+	Transition               string
+	TransitionDuration       float64
+	PreserveHDR              bool
+	TonemapOperator          string
+	TwoPass                  bool
+	Quality                  int
+	EncodePreset             string
+	QualityReport            bool
+	Fragmented               bool
+	AnimationFPS             int
+	AnimationWidth           int
+	NoAudio                  bool
+	BurnTimecode             bool
+	AudioCopy                bool
+	AudioCodec               string
+	AudioBitrate             string
+	Sharpen                  string
+	audioRequest
+	textRequest
+	effectsRequest
+	config.ObscurifyOptions
+}
+
+// audioRequest holds the audio options API clients may set
+type audioRequest struct {
+	MusicVolume     float64
+	DuckThreshold   float64
+	DenoiseAudio    bool
+	DenoiseStrength float64
+	VoiceBoost      bool
+}
+
+// textRequest holds the text options API clients may set
+type textRequest struct {
+	Font      string
+	Wrap      int
+	Position  string
+	OffsetX   int
+	OffsetY   int
+	Animation string
+	ShowAt    float64
+	HideAt    float64
+}
+
+// effectsRequest holds the effect options API clients may set
+type effectsRequest struct {
+	BlurRegions     []string
+	Crop            string
+	Stabilize       bool
+	Speed           float64
+	Interpolate     bool
+	SpeedAudio      string
+	MinDuration     float64
+	LoopMode        string
+	Boomerang       bool
+	BoomerangRepeat int
+	Denoise         string
+	Look            string
+}
+
+// checkInputs rejects http(s) URL and s3:// / gs:// inputs unless the server
+// allows them. Fetching them would let clients reach whatever the server can,
+// internal services and buckets included.
+func checkInputs(paths []string, allowRemote bool) error {
+	if allowRemote {
+		return nil
+	}
+	for _, p := range paths {
+		if remote.IsURL(p) || remote.IsRemote(p) {
+			return fmt.Errorf("remote input %s is not allowed on this server", p)
+		}
+	}
+	return nil
+}
+
+// decodeRequest decodes a request body into v, rejecting fields v doesn't
+// list rather than silently dropping them
+func decodeRequest(body io.Reader, v interface{}) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// SanitizeJob returns the check the server's job manager runs on the API jobs
+// it resumes. Their options are put through the request bodies and checkInputs
+// again, dropping whatever a client couldn't set, output locations included.
+func SanitizeJob(allowRemote bool) func(job *jobs.Job) error {
+	return func(job *jobs.Job) error {
+		switch {
+		case job.Split != nil:
+			var req splitRequest
+			if err := reencode(job.Split, &req); err != nil {
+				return err
+			}
+			if err := checkInputs([]string{req.InputPath}, allowRemote); err != nil {
+				return err
+			}
+			opts := req.options()
+			job.Split = &opts
+		case job.Template != nil:
+			var req templateRequest
+			if err := reencode(job.Template, &req); err != nil {
+				return err
+			}
+			if err := checkInputs(req.InputPaths, allowRemote); err != nil {
+				return err
+			}
+			opts := req.options()
+			job.Template = &opts
+		}
+		return nil
+	}
+}
+
+// reencode copies the options into the request body v, keeping the fields v
+// lists and dropping the rest
+func reencode(opts, v interface{}) error {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(json.Unmarshal(data, v))
+}
+
+func (r *splitRequest) options() config.VideoSplitterOptions {
+	return config.VideoSplitterOptions{
+		InputPath:       r.InputPath,
+		ChunkDuration:   r.ChunkDuration,
+		Skip:            r.Skip,
+		TargetPlatform:  r.TargetPlatform,
+		OutputFormat:    r.OutputFormat,
+		Verbose:         r.Verbose,
+		RecapSeconds:    r.RecapSeconds,
+		RecapText:       r.RecapText,
+		StreamCopy:      r.StreamCopy,
+		PreserveHDR:     r.PreserveHDR,
+		TonemapOperator: r.TonemapOperator,
+		TwoPass:         r.TwoPass,
+		Quality:         r.Quality,
+		EncodePreset:    r.EncodePreset,
+		QualityReport:   r.QualityReport,
+		Fragmented:      r.Fragmented,
+		AnimationFPS:    r.AnimationFPS,
+		AnimationWidth:  r.AnimationWidth,
+		NoAudio:         r.NoAudio,
+		BurnTimecode:    r.BurnTimecode,
+		AudioCopy:       r.AudioCopy,
+		AudioCodec:      r.AudioCodec,
+		AudioBitrate:    r.AudioBitrate,
+		Renditions:      r.Renditions,
+		AllowCopy:       r.AllowCopy,
+		AudioTracks:     r.AudioTracks,
+		Sharpen:         r.Sharpen,
+		FrameRate:       r.FrameRate,
+		SmoothFrameRate: r.SmoothFrameRate,
+		InputFPS:        r.InputFPS,
+		AudioOptions:    r.audioRequest.options(),
+		TextOptions:     r.textRequest.options(),
+		EffectOptions:   r.effectsRequest.options(),
+	}
+}
+
+func (r *templateRequest) options() config.VideoTemplateOptions {
+	return config.VideoTemplateOptions{
+		InputPaths:               r.InputPaths,
+		TemplateType:             r.TemplateType,
+		Layout:                   r.Layout,
+		TemplateGap:              r.TemplateGap,
+		TemplateBorder:           r.TemplateBorder,
+		TemplateBorderColor:      r.TemplateBorderColor,
+		TemplateBackground:       r.TemplateBackground,
+		TemplateBackgroundBlur:   r.TemplateBackgroundBlur,
+		KeyColor:                 r.KeyColor,
+		KeySimilarity:            r.KeySimilarity,
+		KeyBlend:                 r.KeyBlend,
+		ImageDuration:            r.ImageDuration,
+		NoKenBurns:               r.NoKenBurns,
+		TemplateAudio:            r.TemplateAudio,
+		TemplateAudioWeights:     r.TemplateAudioWeights,
+		DurationStrategy:         r.DurationStrategy,
+		FrameRate:                r.FrameRate,
+		SmoothFrameRate:          r.SmoothFrameRate,
+		Reframe:                  r.Reframe,
+		OutputFormat:             r.OutputFormat,
+		Verbose:                  r.Verbose,
+		Obscurify:                r.Obscurify,
+		LandscapeBottomRightText: r.LandscapeBottomRightText,
+		PortraitBottomRightText:  r.PortraitBottomRightText,
+		InputTexts:               r.InputTexts,
+		TextColor:                r.TextColor,
+		TextPalette:              r.TextPalette,
+		Seed:                     r.Seed,
+		WatermarkMotion:          r.WatermarkMotion,
+		WatermarkInterval:        r.WatermarkInterval,
+		TargetPlatform:           r.TargetPlatform,
+		IntroLines:               r.IntroLines,
+		IntroDuration:            r.IntroDuration,
+		OutroLines:               r.OutroLines,
+		OutroDuration:            r.OutroDuration,
+		OutroFontSize:            r.OutroFontSize,
+		OutroTextColor:           r.OutroTextColor,
+		OutroBackground:          r.OutroBackground,
+		OutroFadeIn:              r.OutroFadeIn,
+		OutroFadeOut:             r.OutroFadeOut,
+		OutroQR:                  r.OutroQR,
+		OutroQRSize:              r.OutroQRSize,
+		OutroQRPosition:          r.OutroQRPosition,
+		Transition:               r.Transition,
+		TransitionDuration:       r.TransitionDuration,
+		PreserveHDR:              r.PreserveHDR,
+		TonemapOperator:          r.TonemapOperator,
+		TwoPass:                  r.TwoPass,
+		Quality:                  r.Quality,
+		EncodePreset:             r.EncodePreset,
+		QualityReport:            r.QualityReport,
+		Fragmented:               r.Fragmented,
+		AnimationFPS:             r.AnimationFPS,
+		AnimationWidth:           r.AnimationWidth,
+		NoAudio:                  r.NoAudio,
+		BurnTimecode:             r.BurnTimecode,
+		AudioCopy:                r.AudioCopy,
+		AudioCodec:               r.AudioCodec,
+		AudioBitrate:             r.AudioBitrate,
+		Sharpen:                  r.Sharpen,
+		AudioOptions:             r.audioRequest.options(),
+		TextOptions:              r.textRequest.options(),
+		EffectOptions:            r.effectsRequest.options(),
+		ObscurifyOptions:         r.ObscurifyOptions,
+	}
+}
+
+func (r *audioRequest) options() config.AudioOptions {
+	return config.AudioOptions{
+		MusicVolume:     r.MusicVolume,
+		DuckThreshold:   r.DuckThreshold,
+		DenoiseAudio:    r.DenoiseAudio,
+		DenoiseStrength: r.DenoiseStrength,
+		VoiceBoost:      r.VoiceBoost,
+	}
+}
+
+func (r *textRequest) options() config.TextOptions {
+	return config.TextOptions{
+		Font:      r.Font,
+		Wrap:      r.Wrap,
+		Position:  r.Position,
+		OffsetX:   r.OffsetX,
+		OffsetY:   r.OffsetY,
+		Animation: r.Animation,
+		ShowAt:    r.ShowAt,
+		HideAt:    r.HideAt,
+	}
+}
+
+func (r *effectsRequest) options() config.EffectOptions {
+	return config.EffectOptions{
+		BlurRegions:     r.BlurRegions,
+		Crop:            r.Crop,
+		Stabilize:       r.Stabilize,
+		Speed:           r.Speed,
+		Interpolate:     r.Interpolate,
+		SpeedAudio:      r.SpeedAudio,
+		MinDuration:     r.MinDuration,
+		LoopMode:        r.LoopMode,
+		Boomerang:       r.Boomerang,
+		BoomerangRepeat: r.BoomerangRepeat,
+		Denoise:         r.Denoise,
+		Look:            r.Look,
+	}
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/jobs"
+)
+
+func TestCheckInputs(t *testing.T) {
+	tests := []struct {
+		name        string
+		paths       []string
+		allowRemote bool
+		wantErr     bool
+	}{
+		{"local", []string{"in.mp4", "/videos/b.mov"}, false, false},
+		{"none", nil, false, false},
+		{"http", []string{"in.mp4", "http://internal/admin"}, false, true},
+		{"https", []string{"https://example.com/a.mp4"}, false, true},
+		{"s3", []string{"s3://bucket/a.mp4"}, false, true},
+		{"gs", []string{"gs://bucket/a.mp4"}, false, true},
+		{"allowed url", []string{"https://example.com/a.mp4"}, true, false},
+		{"allowed bucket", []string{"s3://bucket/a.mp4", "gs://bucket/b.mp4"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkInputs(tt.paths, tt.allowRemote)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkInputs(%q, %v) error = %v, want error %v", tt.paths, tt.allowRemote, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeSplitRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"known fields", `{"InputPath": "in.mp4", "ChunkDuration": 30, "Crop": "9:16", "Font": "Inter"}`, ""},
+		{"face detector", `{"InputPath": "in.mp4", "ChunkDuration": 30, "FaceDetector": "sh -c id"}`, "unknown field"},
+		{"reframe detector", `{"InputPath": "in.mp4", "ReframeDetector": "sh -c id"}`, "unknown field"},
+		{"output args", `{"InputPath": "in.mp4", "ExtraOutputArgs": ["-f", "null"]}`, "unknown field"},
+		{"output dir", `{"InputPath": "in.mp4", "OutputDir": "/etc"}`, "unknown field"},
+		{"cache dir", `{"InputPath": "in.mp4", "CacheDir": "/tmp"}`, "unknown field"},
+		{"malformed", `{"InputPath": `, "EOF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req splitRequest
+			err := decodeRequest(strings.NewReader(tt.body), &req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("decodeRequest(%s) error = %v", tt.body, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("decodeRequest(%s) error = %v, want %q", tt.body, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitRequestOptions(t *testing.T) {
+	var req splitRequest
+	body := `{"InputPath": "in.mp4", "ChunkDuration": 30, "Crop": "9:16", "Font": "Inter", "MusicVolume": 0.5}`
+	if err := decodeRequest(strings.NewReader(body), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := req.options()
+	if opts.InputPath != "in.mp4" || opts.ChunkDuration != 30 {
+		t.Errorf("options() = %q, %d, want in.mp4, 30", opts.InputPath, opts.ChunkDuration)
+	}
+	if opts.Crop != "9:16" || opts.Font != "Inter" || opts.MusicVolume != 0.5 {
+		t.Errorf("options() = %q, %q, %g, want the embedded options carried over", opts.Crop, opts.Font, opts.MusicVolume)
+	}
+}
+
+func TestDecodeTemplateRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"known fields", `{"InputPaths": ["a.mp4", "b.mp4"], "TemplateType": "split", "Look": "warm"}`, false},
+		{"reframe detector", `{"InputPaths": ["a.mp4"], "ReframeDetector": "sh -c id"}`, true},
+		{"face detector", `{"InputPaths": ["a.mp4"], "FaceDetector": "sh -c id"}`, true},
+		{"effects file", `{"InputPaths": ["a.mp4"], "EffectsFile": "/etc/passwd"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req templateRequest
+			err := decodeRequest(strings.NewReader(tt.body), &req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeRequest(%s) error = %v, want error %v", tt.body, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSanitizeJob(t *testing.T) {
+	split := jobs.Job{Kind: jobs.KindSplit, Origin: jobs.OriginAPI, Split: &config.VideoSplitterOptions{
+		InputPath:     "in.mp4",
+		ChunkDuration: 30,
+		OutputDir:     "/etc",
+		Verbose:       true,
+		EffectOptions: config.EffectOptions{Crop: "9:16", FaceDetector: "sh -c id", BlurFaces: true},
+	}}
+	if err := SanitizeJob(false)(&split); err != nil {
+		t.Fatal(err)
+	}
+	opts := split.Split
+	if opts.InputPath != "in.mp4" || opts.ChunkDuration != 30 || !opts.Verbose || opts.Crop != "9:16" {
+		t.Errorf("SanitizeJob dropped allowed options: %+v", opts)
+	}
+	if opts.OutputDir != "" || opts.FaceDetector != "" || opts.BlurFaces {
+		t.Errorf("SanitizeJob kept options clients can't set: %+v", opts)
+	}
+
+	template := jobs.Job{Kind: jobs.KindTemplate, Origin: jobs.OriginAPI, Template: &config.VideoTemplateOptions{
+		InputPaths:      []string{"a.mp4", "b.mp4"},
+		TemplateType:    "split",
+		OutputPath:      "/etc/out.webm",
+		ReframeDetector: "sh -c id",
+	}}
+	if err := SanitizeJob(false)(&template); err != nil {
+		t.Fatal(err)
+	}
+	if template.Template.TemplateType != "split" || len(template.Template.InputPaths) != 2 {
+		t.Errorf("SanitizeJob dropped allowed options: %+v", template.Template)
+	}
+	if template.Template.OutputPath != "" || template.Template.ReframeDetector != "" {
+		t.Errorf("SanitizeJob kept options clients can't set: %+v", template.Template)
+	}
+
+	remote := jobs.Job{Kind: jobs.KindSplit, Origin: jobs.OriginAPI, Split: &config.VideoSplitterOptions{
+		InputPath:     "s3://bucket/in.mp4",
+		ChunkDuration: 30,
+	}}
+	if err := SanitizeJob(false)(&remote); err == nil {
+		t.Error("SanitizeJob(false) accepted a remote input")
+	}
+	if err := SanitizeJob(true)(&remote); err != nil {
+		t.Errorf("SanitizeJob(true) rejected a remote input: %v", err)
+	}
+}
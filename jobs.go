@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ZacxDev/video-splitter/internal/jobs"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Inspect jobs persisted by serve or batch",
+	Long: `Inspect the jobs recorded in a jobs database written by "serve --jobs-db" or
+"batch --jobs-db". The database can only be opened while no other process is using it.`,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List persisted jobs",
+	RunE:  runJobsList,
+}
+
+var jobsShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the full details of a persisted job",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobsShow,
+}
+
+func init() {
+	jobsCmd.PersistentFlags().String("jobs-db", "", "BoltDB file jobs are persisted in")
+	jobsCmd.MarkPersistentFlagRequired("jobs-db")
+
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsShowCmd)
+	rootCmd.AddCommand(jobsCmd)
+}
+
+func runJobsList(cmd *cobra.Command, args []string) error {
+	stored, err := loadJobs(cmd)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tKIND\tSTATUS\tPROGRESS\tUPDATED\tINPUT")
+	for _, job := range stored {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			job.ID, job.Kind, job.Status, job.Progress,
+			job.UpdatedAt.Format("2006-01-02 15:04:05"), strings.Join(jobInputs(job), ", "))
+	}
+	return w.Flush()
+}
+
+func runJobsShow(cmd *cobra.Command, args []string) error {
+	stored, err := loadJobs(cmd)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range stored {
+		if job.ID == args[0] {
+			data, err := json.MarshalIndent(job, "", "  ")
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("job not found: %s", args[0])
+}
+
+func loadJobs(cmd *cobra.Command) ([]jobs.Job, error) {
+	path, _ := cmd.Flags().GetString("jobs-db")
+	store, err := jobs.OpenBoltStore(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer store.Close()
+
+	return store.Load()
+}
+
+// openJobStore opens the jobs database at path, returning a nil Store when no path is given
+func openJobStore(path string) (jobs.Store, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	store, err := jobs.OpenBoltStore(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return store, nil
+}
+
+// jobInputs returns the input paths of a job
+func jobInputs(job jobs.Job) []string {
+	switch {
+	case job.Split != nil:
+		return []string{job.Split.InputPath}
+	case job.Template != nil:
+		return job.Template.InputPaths
+	default:
+		return nil
+	}
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/ZacxDev/video-splitter/config"
-	"github.com/ZacxDev/video-splitter/pkg/types"
 	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -18,6 +20,35 @@ var rootCmd = &cobra.Command{
 	Short: "A video processing tool for social media content",
 	Long: `video-processor is a command-line tool for processing videos for social media platforms.
 It supports splitting videos into chunks and arranging multiple videos in templates.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if f := cmd.Flags().Lookup("progress-format"); f != nil && f.Value.String() != "text" && f.Value.String() != "json" {
+			return fmt.Errorf("unsupported progress format: %s (supported: text, json)", f.Value.String())
+		}
+		if f := cmd.Flags().Lookup("progress-format"); f != nil && f.Value.String() == "json" {
+			if show, _ := cmd.Flags().GetBool("progress"); !show {
+				return fmt.Errorf("--progress-format json can't be combined with --progress=false")
+			}
+		}
+		if f := cmd.Flags().Lookup("ladder"); f != nil && f.Value.String() != "" {
+			if _, err := config.ParseLadder(f.Value.String()); err != nil {
+				return err
+			}
+		}
+		if f := cmd.Flags().Lookup("video-template"); f != nil {
+			layout := cmd.Flags().Lookup("layout")
+			if layout.Value.String() != "" {
+				if f.Value.String() != "" {
+					return fmt.Errorf("--video-template and --layout can't be combined")
+				}
+				if _, err := config.LoadLayout(layout.Value.String()); err != nil {
+					return err
+				}
+			} else if f.Value.String() == "" {
+				return fmt.Errorf("one of --video-template or --layout is required")
+			}
+		}
+		return nil
+	},
 }
 
 var splitCmd = &cobra.Command{
@@ -42,109 +73,189 @@ var templateCmd = &cobra.Command{
 Supported templates:
 - 1x1: Single video with optional text overlay
 - 2x2: Arrange 4 videos in a 2x2 grid
-- 3x1: Arrange 3 videos side by side`,
+- 3x1: Arrange 3 videos side by side
+- greenscreen: Key the green screen out of a video and put it over
+  the video or image passed with --key-background
+
+Inputs may also be still images, which play for --image-duration seconds
+while slowly zooming in or out.
+
+Any other arrangement can be described in a layout file passed with --layout:
+  width: 1280
+  height: 720
+  cells:
+    - {x: 0, y: 0, width: 100%, height: 100%}
+    - {x: 70%, y: 5%, width: 25%, height: 25%, z: 1}`,
 	RunE: runTemplate,
 }
 
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline",
+	Short: "Split a video and arrange the chunks into templates",
+	Long: `Split a video into chunks and arrange consecutive chunks into the chosen template,
+producing one templated video per group of chunks.
+
+Example:
+  video-processor pipeline -i input.mp4 -o ./output -d 15 --video-template 2x2 -t instagram-reel`,
+	RunE: runPipeline,
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <pipeline.yaml>",
+	Short: "Run a declarative pipeline spec",
+	Long: `Run a sequence of operations described in a YAML or JSON pipeline spec.
+
+Example spec:
+  inputs: [input.mp4]
+  output: ./output
+  platform: instagram-reel
+  format: mp4
+  steps:
+    - split: {duration: 15}
+    - obscurify: {}
+    - template: {type: 2x2, landscape_text: "@me"}
+    - outro: {lines: ["Follow for more"]}
+    - upload: {command: ["rclone", "copy", "{{.Path}}", "remote:videos"]}`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSpec,
+}
+
 func init() {
 	// Split command flags
-	splitCmd.Flags().StringP("input", "i", "", "Input video file")
-	splitCmd.Flags().StringP("output", "o", "", "Output directory")
-	splitCmd.Flags().IntP("duration", "d", 15, "Duration of each chunk in seconds")
-	splitCmd.Flags().StringP("skip", "s", "", "Duration to skip from start (e.g., '1s', '10s', '1m')")
-	var plats []string
-	for _, o := range videoprocessor.GetSupportedPlatforms() {
-		plats = append(plats, string(o))
-	}
-
-	splitCmd.Flags().StringP("target-platform", "t", "",
-		fmt.Sprintf("Target platform for optimization (%s)",
-			strings.Join(plats, ", ")))
-	splitCmd.Flags().StringP("format", "f", "webm", "Output format (webm or mp4)")
-	splitCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
+	splitCmd.Flags().StringP("input", "i", "", "Input video file, image sequence such as frames/%05d.png, http(s) URL or s3:// / gs:// URI")
+	splitCmd.Flags().StringP("output", "o", "", "Output directory or s3:// / gs:// URI")
+	addCommonFlags(splitCmd)
+	addSplitFlags(splitCmd)
+	splitCmd.Flags().String("name-template", config.DefaultChunkNameTemplate,
+		"Chunk file name template (fields: .Base, .Platform, .Stage, .Index)")
 
 	splitCmd.MarkFlagRequired("input")
 	splitCmd.MarkFlagRequired("output")
 
 	// Template command flags
-	templateCmd.Flags().StringP("output", "o", "", "Output video path")
-	templateCmd.Flags().String("video-template", "", "Template type (1x1, 2x2, or 3x1)")
-	templateCmd.Flags().StringP("format", "f", "webm", "Output format (webm or mp4)")
-	templateCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
-	templateCmd.Flags().Bool("obscurify", false, "Apply obscurify effects to input videos")
-	templateCmd.Flags().String("landscape-bottom-right-text", "", "Add text overlay to bottom right of video if landscape")
-	templateCmd.Flags().String("portrait-bottom-right-text", "", "Add text overlay to bottom right of video if portrait")
-	templateCmd.Flags().StringP("target-platform", "t", "",
-		fmt.Sprintf("Target platform for optimization (%s)",
-			strings.Join(plats, ", ")))
-	templateCmd.Flags().StringArray("outro-text", []string{}, "Lines of text to display in the outro (can be specified multiple times)")
+	templateCmd.Flags().StringP("output", "o", "", "Output video path or s3:// / gs:// URI")
+	addCommonFlags(templateCmd)
+	addTemplateFlags(templateCmd)
+	templateCmd.Flags().String("name-template", "",
+		"Output and intermediate file name template (fields: .Base, .Platform, .Stage, .Index)")
 
 	templateCmd.MarkFlagRequired("output")
-	templateCmd.MarkFlagRequired("video-template")
+
+	// Pipeline command flags
+	pipelineCmd.Flags().StringP("input", "i", "", "Input video file or image sequence such as frames/%05d.png")
+	pipelineCmd.Flags().StringP("output", "o", "", "Output directory")
+	addCommonFlags(pipelineCmd)
+	addSplitFlags(pipelineCmd)
+	addTemplateFlags(pipelineCmd)
+	pipelineCmd.Flags().Bool("keep-chunks", false, "Keep the intermediate chunks in <output>/chunks")
+
+	pipelineCmd.MarkFlagRequired("input")
+	pipelineCmd.MarkFlagRequired("output")
+	pipelineCmd.MarkFlagRequired("target-platform")
+
+	// Run command flags
+	addProgressFlags(runCmd)
 
 	rootCmd.AddCommand(splitCmd)
 	rootCmd.AddCommand(templateCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	rootCmd.AddCommand(runCmd)
 }
 
+// exitCancelled is the exit code for runs stopped by SIGINT or SIGTERM, matching
+// the shell convention for SIGINT
+const exitCancelled = 130
+
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, videoprocessor.ErrCancelled) {
+			os.Exit(exitCancelled)
+		}
 		os.Exit(1)
 	}
 }
 
 func runSplit(cmd *cobra.Command, args []string) error {
-	opts := &config.VideoSplitterOptions{}
+	opts := splitOptionsFromFlags(cmd)
 
 	opts.InputPath, _ = cmd.Flags().GetString("input")
 	opts.OutputDir, _ = cmd.Flags().GetString("output")
-	opts.ChunkDuration, _ = cmd.Flags().GetInt("duration")
-	opts.Skip, _ = cmd.Flags().GetString("skip")
+	opts.NameTemplate, _ = cmd.Flags().GetString("name-template")
 
-	targetPlat, _ := cmd.Flags().GetString("target-platform")
-	opts.TargetPlatform = types.ProcessingPlatform(targetPlat)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	opts.OutputFormat, _ = cmd.Flags().GetString("format")
-	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
-
-	processedClips, err := videoprocessor.SplitVideo(opts)
+	processedClips, err := videoprocessor.SplitVideoContext(ctx, opts)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	fmt.Printf("processedClips %+v\n", processedClips)
+	fmt.Fprintf(resultOutput(cmd), "processedClips %+v\n", processedClips)
 
 	return nil
 }
 
 func runTemplate(cmd *cobra.Command, args []string) error {
-	opts := &config.VideoTemplateOptions{}
+	opts := templateOptionsFromFlags(cmd)
 
 	opts.InputPaths = args
 	opts.OutputPath, _ = cmd.Flags().GetString("output")
-	opts.TemplateType, _ = cmd.Flags().GetString("video-template")
-	opts.OutputFormat, _ = cmd.Flags().GetString("format")
-	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
-	opts.Obscurify, _ = cmd.Flags().GetBool("obscurify")
-	opts.LandscapeBottomRightText, _ = cmd.Flags().GetString("landscape-bottom-right-text")
-	opts.PortraitBottomRightText, _ = cmd.Flags().GetString("portrait-bottom-right-text")
-	if opts.PortraitBottomRightText == "" {
-		opts.PortraitBottomRightText = opts.LandscapeBottomRightText
+	opts.NameTemplate, _ = cmd.Flags().GetString("name-template")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	processedOutput, err := videoprocessor.ApplyTemplateContext(ctx, opts)
+	if err != nil {
+		return errors.WithStack(err)
 	}
 
-	tarPlat, _ := cmd.Flags().GetString("target-platform")
-	opts.TargetPlatform = types.ProcessingPlatform(tarPlat)
+	fmt.Fprintf(resultOutput(cmd), "processedOutput %+v\n", processedOutput)
+
+	return nil
+}
+
+func runPipeline(cmd *cobra.Command, args []string) error {
+	opts := &config.PipelineOptions{
+		Split:    *splitOptionsFromFlags(cmd),
+		Template: *templateOptionsFromFlags(cmd),
+	}
+
+	opts.Split.InputPath, _ = cmd.Flags().GetString("input")
+	opts.OutputDir, _ = cmd.Flags().GetString("output")
+	opts.KeepChunks, _ = cmd.Flags().GetBool("keep-chunks")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	processedOutputs, err := videoprocessor.RunPipelineContext(ctx, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Fprintf(resultOutput(cmd), "processedOutputs %+v\n", processedOutputs)
+
+	return nil
+}
+
+func runSpec(cmd *cobra.Command, args []string) error {
+	spec, err := config.LoadPipelineSpec(args[0])
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	spec.OnProgress = progressFromFlags(cmd)
 
-	outroText, _ := cmd.Flags().GetStringArray("outro-text")
-	opts.OutroLines = outroText
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	processedOutput, err := videoprocessor.ApplyTemplate(opts)
+	processedOutputs, err := videoprocessor.RunPipelineSpecContext(ctx, spec)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	fmt.Printf("processedOutput %+v\n", processedOutput)
+	fmt.Fprintf(resultOutput(cmd), "processedOutputs %+v\n", processedOutputs)
 
 	return nil
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var overlayCmd = &cobra.Command{
+	Use:   "overlay <input> <overlay>",
+	Short: "Put a picture-in-picture clip, e.g. a facecam, over a video",
+	Long: `Composite a clip, e.g. a facecam, in a corner of a main video such as
+gameplay or a screen recording, scaled to a share of its width, optionally
+framed with a border and rounded. The output is as long as the main video,
+the overlay disappearing if it ends first.
+
+Example:
+  video-processor overlay gameplay.mp4 facecam.mp4 -o stream.mp4 --position top-right --radius 24`,
+	Args: cobra.ExactArgs(2),
+	RunE: runOverlay,
+}
+
+func init() {
+	overlayCmd.Flags().StringP("output", "o", "", "Output file path")
+	overlayCmd.Flags().String("position", "bottom-right", "Corner of the overlay (bottom-right, bottom-left, top-right, top-left)")
+	overlayCmd.Flags().Float64("scale", config.DefaultOverlayScale, "Overlay width as a share of the main video's")
+	overlayCmd.Flags().Int("margin", config.DefaultOverlayMargin, "Pixels between the overlay and the edges")
+	overlayCmd.Flags().Int("radius", 0, "Pixels to round the overlay's corners by")
+	overlayCmd.Flags().Int("border", 0, "Width in pixels of a border around the overlay")
+	overlayCmd.Flags().String("border-color", config.DefaultTemplateBorderColor, "Color of the overlay border, e.g. 'white' or '#ff3366'")
+	overlayCmd.Flags().Float64("offset", 0, "Seconds into the main video the overlay starts, to sync the two")
+	overlayCmd.Flags().String("audio", "main", "Audio to keep (main, overlay, mix)")
+	overlayCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
+	addProgressFlags(overlayCmd)
+	overlayCmd.MarkFlagRequired("output")
+
+	rootCmd.AddCommand(overlayCmd)
+}
+
+func runOverlay(cmd *cobra.Command, args []string) error {
+	opts := &config.OverlayOptions{
+		InputPath:   args[0],
+		OverlayPath: args[1],
+		OnProgress:  progressFromFlags(cmd),
+	}
+	opts.OutputPath, _ = cmd.Flags().GetString("output")
+	opts.Position, _ = cmd.Flags().GetString("position")
+	opts.Scale, _ = cmd.Flags().GetFloat64("scale")
+	opts.Margin, _ = cmd.Flags().GetInt("margin")
+	opts.Radius, _ = cmd.Flags().GetInt("radius")
+	opts.Border, _ = cmd.Flags().GetInt("border")
+	opts.BorderColor, _ = cmd.Flags().GetString("border-color")
+	opts.Offset, _ = cmd.Flags().GetFloat64("offset")
+	opts.Audio, _ = cmd.Flags().GetString("audio")
+	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	processedOutput, err := videoprocessor.OverlayContext(ctx, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Fprintf(resultOutput(cmd), "processedOutput %+v\n", processedOutput)
+
+	return nil
+}
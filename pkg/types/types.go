@@ -1,5 +1,10 @@
 package types
 
+import (
+	"fmt"
+	"time"
+)
+
 type ProcessingPlatform string
 
 const (
@@ -13,9 +18,46 @@ const (
 type ProcessedClip struct {
 	FilePath        string
 	DurationSeconds uint64
+	Rendition       string         // Name of the bitrate ladder rung, empty without a ladder
+	StreamCopied    bool           // Cut without re-encoding instead of transcoded
+	Quality         *QualityScores // Set when a quality report was requested
 }
 
 type ProcessedOutput struct {
 	FilePath        string
 	DurationSeconds uint64
+	Quality         *QualityScores // Set when a quality report was requested, averaged over the inputs
+}
+
+// QualityScores measure how closely an encode matches its source
+type QualityScores struct {
+	VMAF float64 // 0-100, 0 when ffmpeg lacks libvmaf
+	SSIM float64 // 0-1
+	PSNR float64 // In dB
+}
+
+func (q *QualityScores) String() string {
+	if q == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("VMAF %.2f, SSIM %.4f, PSNR %.2fdB", q.VMAF, q.SSIM, q.PSNR)
+}
+
+// BatchResult records the outcome of processing one input group in a batch
+type BatchResult struct {
+	InputPaths  []string
+	OutputPaths []string
+	Err         error
+}
+
+// ProgressEvent reports how far a single ffmpeg encode within a run has got
+type ProgressEvent struct {
+	Stage   string // What is being encoded, e.g. "chunk", "optimize" or "compose"
+	Index   int    // 1-based position within the stage, 0 when the stage has one encode
+	Total   int
+	Percent float64 // 0-100, or 0 when the output duration is unknown
+	FPS     float64
+	Speed   float64 // Encoding speed as a multiple of real time
+	ETA     time.Duration
+	Done    bool // Set on the final event of the encode
 }
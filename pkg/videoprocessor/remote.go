@@ -0,0 +1,162 @@
+package videoprocessor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/internal/platform"
+	"github.com/ZacxDev/video-splitter/internal/processor"
+	"github.com/ZacxDev/video-splitter/internal/remote"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// splitRemote downloads a remote or URL input, splits it locally and uploads the chunks
+// when the output directory is remote
+func splitRemote(ctx context.Context, opts *config.VideoSplitterOptions) ([]types.ProcessedClip, error) {
+	tempDir, err := os.MkdirTemp("", "video_remote_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	localOpts := *opts
+	localOpts.InputPath, err = fetchInput(ctx, opts.InputPath, tempDir, opts.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+	if remote.IsRemote(opts.OutputDir) {
+		localOpts.OutputDir = filepath.Join(tempDir, "output")
+	}
+
+	clips, err := processor.NewSplitter(&localOpts).ProcessContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if remote.IsRemote(opts.OutputDir) {
+		for i := range clips {
+			uri := remote.Join(opts.OutputDir, filepath.Base(clips[i].FilePath))
+			if err := remote.Upload(ctx, clips[i].FilePath, uri); err != nil {
+				return nil, err
+			}
+
+			// A DASH manifest is only playable with its segments next to it
+			if filepath.Ext(clips[i].FilePath) == processor.FileExtension("dash") {
+				segments, err := processor.DASHSegments(clips[i].FilePath)
+				if err != nil {
+					return nil, err
+				}
+				for _, segment := range segments {
+					if err := remote.Upload(ctx, segment, remote.Join(opts.OutputDir, filepath.Base(segment))); err != nil {
+						return nil, err
+					}
+				}
+			}
+			clips[i].FilePath = uri
+		}
+
+		if opts.QualityReport {
+			reportPath := filepath.Join(localOpts.OutputDir, config.QualityReportFileName)
+			if err := remote.Upload(ctx, reportPath, remote.Join(opts.OutputDir, config.QualityReportFileName)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return clips, nil
+}
+
+// applyTemplateRemote downloads remote inputs, applies the template locally and
+// uploads the result when the output path is remote
+func applyTemplateRemote(ctx context.Context, opts *config.VideoTemplateOptions, plat platform.Platform) (*types.ProcessedOutput, error) {
+	tempDir, err := os.MkdirTemp("", "video_remote_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	localOpts := *opts
+	localOpts.InputPaths = make([]string, len(opts.InputPaths))
+	for i, inputPath := range opts.InputPaths {
+		localOpts.InputPaths[i] = inputPath
+		if !isRemoteInput(inputPath) {
+			continue
+		}
+
+		// Inputs get their own directory since remote names may collide
+		inputDir := filepath.Join(tempDir, fmt.Sprintf("input_%d", i))
+		if err := os.MkdirAll(inputDir, 0755); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		localOpts.InputPaths[i], err = fetchInput(ctx, inputPath, inputDir, opts.CacheDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	remoteDir := ""
+	if remote.IsRemote(opts.OutputPath) {
+		remoteDir = opts.OutputPath[:strings.LastIndex(opts.OutputPath, "/")]
+		localOpts.OutputPath = filepath.Join(tempDir, opts.OutputPath[len(remoteDir)+1:])
+	}
+
+	output, err := processor.NewTemplater(&localOpts, plat).ProcessContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if remoteDir != "" {
+		// Upload under the final name, which a name template may have changed
+		uri := remote.Join(remoteDir, filepath.Base(output.FilePath))
+		if err := remote.Upload(ctx, output.FilePath, uri); err != nil {
+			return nil, err
+		}
+
+		if opts.QualityReport {
+			reportPath := processor.TemplateQualityReportPath(output.FilePath)
+			if err := remote.Upload(ctx, reportPath, remote.Join(remoteDir, filepath.Base(reportPath))); err != nil {
+				return nil, err
+			}
+		}
+		output.FilePath = uri
+	}
+
+	return output, nil
+}
+
+// fetchInput downloads a remote or URL input into dir, or into cacheDir for
+// URLs when set, and returns the local path. Local paths are returned as is.
+func fetchInput(ctx context.Context, input, dir, cacheDir string) (string, error) {
+	switch {
+	case remote.IsURL(input):
+		if cacheDir != "" {
+			if err := os.MkdirAll(cacheDir, 0755); err != nil {
+				return "", fmt.Errorf("error creating cache directory: %v", err)
+			}
+			dir = cacheDir
+		}
+		return remote.DownloadURL(ctx, input, dir)
+	case remote.IsRemote(input):
+		return remote.Download(ctx, input, dir)
+	}
+
+	return input, nil
+}
+
+func isRemoteInput(p string) bool {
+	return remote.IsRemote(p) || remote.IsURL(p)
+}
+
+func hasRemoteInput(paths []string) bool {
+	for _, p := range paths {
+		if isRemoteInput(p) {
+			return true
+		}
+	}
+	return false
+}
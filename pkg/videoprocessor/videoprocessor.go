@@ -1,25 +1,338 @@
 package videoprocessor
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
 	"github.com/ZacxDev/video-splitter/config"
 	"github.com/ZacxDev/video-splitter/internal/platform"
 	"github.com/ZacxDev/video-splitter/internal/processor"
+	"github.com/ZacxDev/video-splitter/internal/remote"
 	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/pkg/errors"
 )
 
-// SplitVideo splits a video into chunks according to the provided options
+// ErrCancelled is returned when processing stops because its context was
+// cancelled or timed out. Partial outputs and temp files are removed first.
+var ErrCancelled = errors.New("processing cancelled")
+
+// SplitVideo splits a video into chunks according to the provided options.
+// The input may be an http(s) URL and the input and output directory may be
+// s3:// or gs:// URIs.
 func SplitVideo(opts *config.VideoSplitterOptions) ([]types.ProcessedClip, error) {
-	return processor.NewSplitter(opts).Process()
+	return SplitVideoContext(context.Background(), opts)
 }
 
-// ApplyTemplate applies a video template to multiple input videos
+// SplitVideoContext is SplitVideo with a context. Cancelling ctx or hitting its
+// deadline kills the running encode and returns ctx's error.
+func SplitVideoContext(ctx context.Context, opts *config.VideoSplitterOptions) ([]types.ProcessedClip, error) {
+	var clips []types.ProcessedClip
+	var err error
+	if isRemoteInput(opts.InputPath) || remote.IsRemote(opts.OutputDir) {
+		clips, err = splitRemote(ctx, opts)
+	} else {
+		clips, err = processor.NewSplitter(opts).ProcessContext(ctx)
+	}
+
+	return clips, cancelledErr(ctx, err)
+}
+
+// ApplyTemplate applies a video template to multiple input videos.
+// The inputs may be http(s) URLs and the inputs and output path may be
+// s3:// or gs:// URIs.
 func ApplyTemplate(opts *config.VideoTemplateOptions) (*types.ProcessedOutput, error) {
+	return ApplyTemplateContext(context.Background(), opts)
+}
+
+// ApplyTemplateContext is ApplyTemplate with a context. Cancelling ctx or
+// hitting its deadline kills the running encode and returns ctx's error.
+func ApplyTemplateContext(ctx context.Context, opts *config.VideoTemplateOptions) (*types.ProcessedOutput, error) {
+	plat, err := platform.Get(opts.TargetPlatform)
+	if err != nil {
+		return nil, err
+	}
+
+	var output *types.ProcessedOutput
+	if hasRemoteInput(opts.InputPaths) || remote.IsRemote(opts.OutputPath) {
+		output, err = applyTemplateRemote(ctx, opts, plat)
+	} else {
+		output, err = processor.NewTemplater(opts, plat).ProcessContext(ctx)
+	}
+
+	return output, cancelledErr(ctx, err)
+}
+
+// RunPipeline splits a video and arranges the resulting chunks into templates
+func RunPipeline(opts *config.PipelineOptions) ([]types.ProcessedOutput, error) {
+	return RunPipelineContext(context.Background(), opts)
+}
+
+// RunPipelineContext is RunPipeline with a context
+func RunPipelineContext(ctx context.Context, opts *config.PipelineOptions) ([]types.ProcessedOutput, error) {
+	plat, err := platform.Get(opts.Template.TargetPlatform)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := processor.NewPipeline(opts, plat).ProcessContext(ctx)
+	return outputs, cancelledErr(ctx, err)
+}
+
+// RunPipelineSpec executes the steps of a declarative pipeline spec
+func RunPipelineSpec(spec *config.PipelineSpec) ([]types.ProcessedOutput, error) {
+	return RunPipelineSpecContext(context.Background(), spec)
+}
+
+// RunPipelineSpecContext is RunPipelineSpec with a context. Cancelling ctx or
+// hitting its deadline kills the running encode or upload command and
+// returns ctx's error.
+func RunPipelineSpecContext(ctx context.Context, spec *config.PipelineSpec) ([]types.ProcessedOutput, error) {
+	var plat platform.Platform
+	if spec.TargetPlatform != "" {
+		var err error
+		plat, err = platform.Get(spec.TargetPlatform)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	outputs, err := processor.NewRunner(spec, plat).ProcessContext(ctx)
+	return outputs, cancelledErr(ctx, err)
+}
+
+// Remux rewraps a video into the container of the output path's extension
+// (e.g. mkv to mp4) without re-encoding. It fails before writing anything
+// when a stream's codec isn't allowed in the target container.
+func Remux(opts *config.RemuxOptions) (*types.ProcessedOutput, error) {
+	return RemuxContext(context.Background(), opts)
+}
+
+// RemuxContext is Remux with a context
+func RemuxContext(ctx context.Context, opts *config.RemuxOptions) (*types.ProcessedOutput, error) {
+	output, err := processor.Remux(ctx, opts)
+	return output, cancelledErr(ctx, err)
+}
+
+// RemuxExtensions returns the output file extensions Remux can write
+func RemuxExtensions() []string {
+	return processor.RemuxExtensions()
+}
+
+// Transform rotates a video by multiples of 90 degrees and mirrors it,
+// relative to how it displays, re-encoding the video and copying the audio.
+// With MetadataOnly set it only rewrites the display rotation instead.
+func Transform(opts *config.TransformOptions) (*types.ProcessedOutput, error) {
+	return TransformContext(context.Background(), opts)
+}
+
+// TransformContext is Transform with a context
+func TransformContext(ctx context.Context, opts *config.TransformOptions) (*types.ProcessedOutput, error) {
+	output, err := processor.Transform(ctx, opts)
+	return output, cancelledErr(ctx, err)
+}
+
+// Overlay composites a picture-in-picture clip, e.g. a facecam, in a corner
+// of a main video, independent of the template grids
+func Overlay(opts *config.OverlayOptions) (*types.ProcessedOutput, error) {
+	return OverlayContext(context.Background(), opts)
+}
+
+// OverlayContext is Overlay with a context
+func OverlayContext(ctx context.Context, opts *config.OverlayOptions) (*types.ProcessedOutput, error) {
+	output, err := processor.Overlay(ctx, opts)
+	return output, cancelledErr(ctx, err)
+}
+
+// Slideshow turns a folder of photos into a video for the target platform,
+// slowly zooming into each and crossfading between them
+func Slideshow(opts *config.SlideshowOptions) (*types.ProcessedOutput, error) {
+	return SlideshowContext(context.Background(), opts)
+}
+
+// SlideshowContext is Slideshow with a context
+func SlideshowContext(ctx context.Context, opts *config.SlideshowOptions) (*types.ProcessedOutput, error) {
 	plat, err := platform.Get(opts.TargetPlatform)
 	if err != nil {
 		return nil, err
 	}
 
-	return processor.NewTemplater(opts, plat).Process()
+	output, err := processor.Slideshow(ctx, opts, plat)
+	return output, cancelledErr(ctx, err)
+}
+
+// ExtractAudio pulls the audio track out of a video into mp3, aac, opus or
+// wav, optionally split on the same chunk boundaries as SplitVideo
+func ExtractAudio(opts *config.ExtractAudioOptions) ([]types.ProcessedClip, error) {
+	return ExtractAudioContext(context.Background(), opts)
+}
+
+// ExtractAudioContext is ExtractAudio with a context
+func ExtractAudioContext(ctx context.Context, opts *config.ExtractAudioOptions) ([]types.ProcessedClip, error) {
+	clips, err := processor.ExtractAudio(ctx, opts)
+	return clips, cancelledErr(ctx, err)
+}
+
+// AudioFormats returns the formats ExtractAudio writes
+func AudioFormats() []string {
+	return processor.AudioFormats()
+}
+
+// SplitBatch splits every video matching pattern (a directory, glob or file)
+// with the same options. A failure on one input doesn't stop the rest; check
+// each result's Err.
+func SplitBatch(pattern string, opts *config.VideoSplitterOptions) ([]types.BatchResult, error) {
+	return SplitBatchContext(context.Background(), pattern, opts)
+}
+
+// SplitBatchContext is SplitBatch with a context. Once ctx ends the batch stops
+// and returns ErrCancelled.
+func SplitBatchContext(ctx context.Context, pattern string, opts *config.VideoSplitterOptions) ([]types.BatchResult, error) {
+	inputs, err := processor.ResolveInputs(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]types.BatchResult, 0, len(inputs))
+	for _, input := range inputs {
+		fileOpts := *opts
+		fileOpts.InputPath = input
+
+		result := types.BatchResult{InputPaths: []string{input}}
+		clips, err := SplitVideoContext(ctx, &fileOpts)
+		if errors.Is(err, ErrCancelled) {
+			return nil, err
+		}
+		if err != nil {
+			result.Err = err
+		}
+		for _, clip := range clips {
+			result.OutputPaths = append(result.OutputPaths, clip.FilePath)
+		}
+		res = append(res, result)
+	}
+
+	return res, nil
+}
+
+// ApplyTemplateBatch groups the videos matching pattern by the template's input
+// count and applies the template to each group, writing the outputs into
+// outputDir. A failure on one group doesn't stop the rest; check each result's Err.
+func ApplyTemplateBatch(pattern, outputDir string, opts *config.VideoTemplateOptions) ([]types.BatchResult, error) {
+	return ApplyTemplateBatchContext(context.Background(), pattern, outputDir, opts)
+}
+
+// ApplyTemplateBatchContext is ApplyTemplateBatch with a context. Once ctx ends
+// the batch stops and returns ErrCancelled.
+func ApplyTemplateBatchContext(ctx context.Context, pattern, outputDir string, opts *config.VideoTemplateOptions) ([]types.BatchResult, error) {
+	groups, err := TemplateBatchGroups(pattern, outputDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]types.BatchResult, 0, len(groups))
+	for i := range groups {
+		result := types.BatchResult{InputPaths: append([]string{}, groups[i].InputPaths...)}
+		output, err := ApplyTemplateContext(ctx, &groups[i])
+		if errors.Is(err, ErrCancelled) {
+			return nil, err
+		}
+		if err != nil {
+			result.Err = err
+		} else {
+			result.OutputPaths = []string{output.FilePath}
+		}
+		res = append(res, result)
+	}
+
+	return res, nil
+}
+
+// TemplateBatchGroups resolves the videos matching pattern and splits them into
+// groups of the template's input count, returning the options for each group
+// with InputPaths and an OutputPath in outputDir filled in
+func TemplateBatchGroups(pattern, outputDir string, opts *config.VideoTemplateOptions) ([]config.VideoTemplateOptions, error) {
+	inputs, err := processor.ResolveInputs(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	groupSize, err := processor.TemplateInputs(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	if remainder := len(inputs) % groupSize; remainder != 0 {
+		log.Printf("Warning: %s template needs %d videos per output, ignoring last %d videos",
+			opts.TemplateType, groupSize, remainder)
+	}
+
+	res := make([]config.VideoTemplateOptions, 0, len(inputs)/groupSize)
+	for start := 0; start+groupSize <= len(inputs); start += groupSize {
+		group := inputs[start : start+groupSize]
+
+		groupOpts := *opts
+		groupOpts.InputPaths = append([]string{}, group...)
+		base := filepath.Base(group[0])
+		base = base[:len(base)-len(filepath.Ext(base))]
+		groupOpts.OutputPath = filepath.Join(outputDir, fmt.Sprintf("%s_%s%s", base, opts.TemplateType, processor.FileExtension(opts.OutputFormat)))
+		res = append(res, groupOpts)
+	}
+
+	return res, nil
+}
+
+// ResolveInputs expands a directory, glob pattern or single file into the matching video files
+func ResolveInputs(pattern string) ([]string, error) {
+	return processor.ResolveInputs(pattern)
+}
+
+// Watch monitors a directory and splits new videos as they appear, until ctx is cancelled
+func Watch(ctx context.Context, opts *config.WatchOptions) error {
+	return processor.NewWatcher(opts).Run(ctx)
+}
+
+// cancelledErr replaces err with ErrCancelled when it was caused by ctx ending
+func cancelledErr(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ErrCancelled, ctx.Err())
+	}
+	return err
+}
+
+// GetSupportedFormats returns the supported output formats
+func GetSupportedFormats() []string {
+	return processor.GetSupportedFormats()
+}
+
+// FileExtension returns the file extension, with the dot, for files of the
+// output format, e.g. ".mp4" for av1
+func FileExtension(outputFormat string) string {
+	return processor.FileExtension(outputFormat)
+}
+
+// DefaultTonemapOperator is the tone mapping operator used for HDR sources when
+// TonemapOperator isn't set
+const DefaultTonemapOperator = processor.DefaultTonemapOperator
+
+// TonemapOperators returns the supported tone mapping operators
+func TonemapOperators() []string {
+	return processor.TonemapOperators()
+}
+
+// DefaultEncodePreset is the encoder speed/quality tier used when EncodePreset
+// isn't set
+const DefaultEncodePreset = processor.DefaultEncodePreset
+
+// EncodePresets returns the encoder speed/quality tiers, fastest first
+func EncodePresets() []string {
+	return processor.EncodePresets()
 }
 
 // GetSupportedPlatforms returns a list of supported social media platforms
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ZacxDev/video-splitter/pkg/types"
+)
+
+// textProgress renders progress events as a single line that is redrawn in place
+func textProgress(w io.Writer) func(types.ProgressEvent) {
+	return func(ev types.ProgressEvent) {
+		label := ev.Stage
+		if ev.Total > 0 {
+			label = fmt.Sprintf("%s %d/%d", ev.Stage, ev.Index, ev.Total)
+		}
+
+		line := fmt.Sprintf("%-16s %5.1f%%  %6.1f fps  %5.2fx  ETA %s",
+			label, ev.Percent, ev.FPS, ev.Speed, formatETA(ev.ETA))
+		if ev.Done {
+			fmt.Fprintf(w, "\r%s\n", line)
+			return
+		}
+		fmt.Fprintf(w, "\r%s", line)
+	}
+}
+
+func formatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// progressRecord is the JSON form of a progress event
+type progressRecord struct {
+	JobID      string  `json:"job_id"`
+	Stage      string  `json:"stage"`
+	Index      int     `json:"index"`
+	Total      int     `json:"total"`
+	Percent    float64 `json:"percent"`
+	FPS        float64 `json:"fps"`
+	Speed      float64 `json:"speed"`
+	ETASeconds float64 `json:"eta_seconds"`
+	Done       bool    `json:"done"`
+}
+
+// jsonProgress writes progress events as newline-delimited JSON, tagged with
+// an id that identifies this run
+func jsonProgress(w io.Writer) func(types.ProgressEvent) {
+	id := make([]byte, 8)
+	rand.Read(id)
+	jobID := hex.EncodeToString(id)
+
+	enc := json.NewEncoder(w)
+	return func(ev types.ProgressEvent) {
+		enc.Encode(progressRecord{
+			JobID:      jobID,
+			Stage:      ev.Stage,
+			Index:      ev.Index,
+			Total:      ev.Total,
+			Percent:    ev.Percent,
+			FPS:        ev.FPS,
+			Speed:      ev.Speed,
+			ETASeconds: ev.ETA.Seconds(),
+			Done:       ev.Done,
+		})
+	}
+}
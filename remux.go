@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var remuxCmd = &cobra.Command{
+	Use:   "remux <input>",
+	Short: "Change a video's container without re-encoding",
+	Long: fmt.Sprintf(`Copy the video and audio streams into the container picked by the output's
+extension (%s). MP4 and MOV outputs get +faststart. Fails before writing
+anything when a codec isn't allowed in the target container.
+
+Example:
+  video-processor remux recording.mkv -o recording.mp4`,
+		strings.Join(videoprocessor.RemuxExtensions(), ", ")),
+	Args: cobra.ExactArgs(1),
+	RunE: runRemux,
+}
+
+func init() {
+	remuxCmd.Flags().StringP("output", "o", "", "Output file path")
+	remuxCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
+	remuxCmd.Flags().Bool("fragmented", false, "Write fragmented MP4 (fMP4/CMAF) instead of faststart MP4")
+	addProgressFlags(remuxCmd)
+	remuxCmd.MarkFlagRequired("output")
+
+	rootCmd.AddCommand(remuxCmd)
+}
+
+func runRemux(cmd *cobra.Command, args []string) error {
+	opts := &config.RemuxOptions{
+		InputPath:  args[0],
+		OnProgress: progressFromFlags(cmd),
+	}
+	opts.OutputPath, _ = cmd.Flags().GetString("output")
+	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+	opts.Fragmented, _ = cmd.Flags().GetBool("fragmented")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	processedOutput, err := videoprocessor.RemuxContext(ctx, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Fprintf(resultOutput(cmd), "processedOutput %+v\n", processedOutput)
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ZacxDev/video-splitter/internal/jobs"
+	"github.com/ZacxDev/video-splitter/internal/server"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an HTTP or gRPC API for submitting split and template jobs",
+	Long: `Run an HTTP server that accepts split and template jobs, reports their status
+and serves their output files.
+
+Endpoints:
+  POST /jobs/split               submit a split job
+  POST /jobs/template            submit a template job
+  GET  /jobs                     list jobs
+  GET  /jobs/{id}                get job status
+  GET  /jobs/{id}/files/{name}   download an output file
+
+With --grpc the VideoProcessor gRPC service (see proto/videoprocessor.proto) is
+served instead, streaming job updates to the caller.
+
+Inputs must be files on the server; URLs and s3:// / gs:// URIs are rejected
+unless --allow-remote-inputs is set.
+
+Example:
+  video-processor serve --addr :8080 --work-dir ./jobs
+  video-processor serve --grpc --addr :9090 --work-dir ./jobs`,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().String("addr", ":8080", "Address to listen on")
+	serveCmd.Flags().String("work-dir", "./jobs", "Directory job outputs are written to")
+	serveCmd.Flags().Int("workers", 1, "Number of jobs processed concurrently")
+	serveCmd.Flags().String("jobs-db", "", "BoltDB file to persist jobs in so unfinished jobs resume after a restart")
+	serveCmd.Flags().Bool("grpc", false, "Serve the gRPC API instead of the REST API")
+	serveCmd.Flags().Bool("allow-remote-inputs", false, "Accept http(s) URL and s3:// / gs:// inputs, which the server fetches with its own network access and credentials")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	addr, _ := cmd.Flags().GetString("addr")
+	workDir, _ := cmd.Flags().GetString("work-dir")
+	workers, _ := cmd.Flags().GetInt("workers")
+	useGRPC, _ := cmd.Flags().GetBool("grpc")
+	jobsDB, _ := cmd.Flags().GetString("jobs-db")
+	allowRemote, _ := cmd.Flags().GetBool("allow-remote-inputs")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store, err := openJobStore(jobsDB)
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		defer store.Close()
+	}
+
+	manager := jobs.NewManager(workDir, workers, store, jobs.OriginAPI, server.SanitizeJob(allowRemote))
+	if err := manager.Start(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		// Let interrupted jobs stop their encodes before the store closes
+		if ctx.Err() != nil {
+			manager.Drain()
+		}
+	}()
+
+	if useGRPC {
+		return serveGRPC(ctx, addr, manager, allowRemote)
+	}
+
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: server.New(manager, allowRemote).Handler(),
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Listening on %s\n", addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func serveGRPC(ctx context.Context, addr string, manager *jobs.Manager, allowRemote bool) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	srv := server.NewGRPC(manager, allowRemote)
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+
+	log.Printf("Serving gRPC on %s\n", addr)
+	return errors.WithStack(srv.Serve(lis))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/pkg/types"
+	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var slideshowCmd = &cobra.Command{
+	Use:   "slideshow <directory or pattern>",
+	Short: "Turn a folder of photos into a video",
+	Long: `Show the images of a directory, or matching a glob pattern, in name order,
+each slowly zooming in or out (the Ken Burns effect) and optionally
+crossfading into the next, encoded for the target platform.
+
+Example:
+  video-processor slideshow ./photos -o trip.mp4 -t instagram-reel --transition fade`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSlideshow,
+}
+
+func init() {
+	var plats []string
+	for _, o := range videoprocessor.GetSupportedPlatforms() {
+		plats = append(plats, string(o))
+	}
+
+	slideshowCmd.Flags().StringP("output", "o", "", "Output file path")
+	slideshowCmd.Flags().StringP("target-platform", "t", "",
+		fmt.Sprintf("Target platform for optimization (%s)", strings.Join(plats, ", ")))
+	slideshowCmd.Flags().StringP("format", "f", "mp4", "Output format (mp4, webm, av1, hevc, prores, mkv)")
+	slideshowCmd.Flags().Float64("image-duration", config.DefaultImageDuration, "Seconds each image shows for")
+	slideshowCmd.Flags().Bool("no-ken-burns", false, "Show the images still instead of slowly zooming in and out")
+	slideshowCmd.Flags().String("transition", "", "Crossfade between images (fade, dissolve, slide); hard cuts when empty")
+	slideshowCmd.Flags().Float64("transition-duration", config.DefaultTransitionDuration, "Seconds each crossfade lasts")
+	slideshowCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
+	addProgressFlags(slideshowCmd)
+	slideshowCmd.MarkFlagRequired("output")
+	slideshowCmd.MarkFlagRequired("target-platform")
+
+	rootCmd.AddCommand(slideshowCmd)
+}
+
+func runSlideshow(cmd *cobra.Command, args []string) error {
+	opts := &config.SlideshowOptions{
+		InputDir:   args[0],
+		OnProgress: progressFromFlags(cmd),
+	}
+	opts.OutputPath, _ = cmd.Flags().GetString("output")
+	targetPlat, _ := cmd.Flags().GetString("target-platform")
+	opts.TargetPlatform = types.ProcessingPlatform(targetPlat)
+	opts.OutputFormat, _ = cmd.Flags().GetString("format")
+	opts.ImageDuration, _ = cmd.Flags().GetFloat64("image-duration")
+	opts.NoKenBurns, _ = cmd.Flags().GetBool("no-ken-burns")
+	opts.Transition, _ = cmd.Flags().GetString("transition")
+	opts.TransitionDuration, _ = cmd.Flags().GetFloat64("transition-duration")
+	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	processedOutput, err := videoprocessor.SlideshowContext(ctx, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Fprintf(resultOutput(cmd), "processedOutput %+v\n", processedOutput)
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var transformCmd = &cobra.Command{
+	Use:   "transform <input>",
+	Short: "Rotate or flip a video",
+	Long: `Rotate a video by 90, 180 or 270 degrees clockwise and mirror it, e.g. to
+line up mixed-orientation clips before templating. The rotation is relative
+to how the video displays, so phone clips with a rotation tag turn as they
+look, and the output carries no rotation tag of its own. The audio is copied.
+
+With --metadata-only the display rotation is rewritten instead, without
+re-encoding, which is instant and lossless but needs ffmpeg 6.0 or later and
+can't flip.
+
+Example:
+  video-processor transform sideways.mp4 -o upright.mp4 --rotate 90`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTransform,
+}
+
+func init() {
+	transformCmd.Flags().StringP("output", "o", "", "Output file path")
+	transformCmd.Flags().Int("rotate", 0, "Degrees to rotate clockwise (90, 180 or 270; negative turns counterclockwise)")
+	transformCmd.Flags().Bool("hflip", false, "Mirror left to right, after rotating")
+	transformCmd.Flags().Bool("vflip", false, "Mirror top to bottom, after rotating")
+	transformCmd.Flags().Bool("metadata-only", false, "Only rewrite the display rotation, without re-encoding")
+	transformCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging")
+	addProgressFlags(transformCmd)
+	transformCmd.MarkFlagRequired("output")
+
+	rootCmd.AddCommand(transformCmd)
+}
+
+func runTransform(cmd *cobra.Command, args []string) error {
+	opts := &config.TransformOptions{
+		InputPath:  args[0],
+		OnProgress: progressFromFlags(cmd),
+	}
+	opts.OutputPath, _ = cmd.Flags().GetString("output")
+	opts.Rotate, _ = cmd.Flags().GetInt("rotate")
+	opts.FlipHorizontal, _ = cmd.Flags().GetBool("hflip")
+	opts.FlipVertical, _ = cmd.Flags().GetBool("vflip")
+	opts.MetadataOnly, _ = cmd.Flags().GetBool("metadata-only")
+	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	processedOutput, err := videoprocessor.TransformContext(ctx, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Fprintf(resultOutput(cmd), "processedOutput %+v\n", processedOutput)
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/ZacxDev/video-splitter/config"
+	"github.com/ZacxDev/video-splitter/pkg/videoprocessor"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Split new videos as they appear in a directory",
+	Long: `Monitor a drop folder and split every new video once it has finished copying.
+Processed inputs are moved to a done folder and failed inputs to a failed folder.
+
+Example:
+  video-processor watch ./inbox -o ./output -d 15 -t instagram-reel`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().StringP("output", "o", "", "Output directory")
+	addCommonFlags(watchCmd)
+	addSplitFlags(watchCmd)
+	watchCmd.Flags().String("done-dir", "", "Directory for processed inputs (default <dir>/done)")
+	watchCmd.Flags().String("failed-dir", "", "Directory for failed inputs (default <dir>/failed)")
+	watchCmd.Flags().Duration("poll-interval", 2*time.Second, "How often to scan the directory")
+	watchCmd.Flags().Duration("stable-for", 5*time.Second, "How long a file must stay unchanged before it is processed")
+
+	watchCmd.MarkFlagRequired("output")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	opts := &config.WatchOptions{
+		WatchDir: args[0],
+		Split:    *splitOptionsFromFlags(cmd),
+	}
+
+	opts.Split.OutputDir, _ = cmd.Flags().GetString("output")
+	opts.DoneDir, _ = cmd.Flags().GetString("done-dir")
+	if opts.DoneDir == "" {
+		opts.DoneDir = filepath.Join(opts.WatchDir, "done")
+	}
+	opts.FailedDir, _ = cmd.Flags().GetString("failed-dir")
+	if opts.FailedDir == "" {
+		opts.FailedDir = filepath.Join(opts.WatchDir, "failed")
+	}
+	opts.PollInterval, _ = cmd.Flags().GetDuration("poll-interval")
+	opts.StableFor, _ = cmd.Flags().GetDuration("stable-for")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return errors.WithStack(videoprocessor.Watch(ctx, opts))
+}